@@ -0,0 +1,39 @@
+package suitrace
+
+import "time"
+
+// Tuning of adaptive batch sizing
+var (
+	adaptiveStartBatch    = 5                      // First batch size
+	adaptiveBatchStep     = 5                      // Added after each fast batch
+	adaptiveTargetLatency = 750 * time.Millisecond // Batches slower than this shrink
+)
+
+// Batch size that adapts AIMD-style: it grows by a fixed step while batches
+// come back fast and halves on errors (including 429s) or slow batches
+type batchTuner struct {
+	size int
+	max  int
+}
+
+func newBatchTuner(limit int) *batchTuner {
+	return &batchTuner{size: min(adaptiveStartBatch, limit), max: limit}
+}
+
+// Record a successful batch and how long it took
+func (t *batchTuner) success(latency time.Duration) {
+	if latency > adaptiveTargetLatency {
+		t.shrink()
+		return
+	}
+	t.size = min(t.size+adaptiveBatchStep, t.max)
+}
+
+// Record a failed batch
+func (t *batchTuner) failure() {
+	t.shrink()
+}
+
+func (t *batchTuner) shrink() {
+	t.size = max(t.size/2, 1)
+}
@@ -0,0 +1,70 @@
+package suitrace
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBatchTuner(t *testing.T) {
+	tuner := newBatchTuner(12)
+	if tuner.size != adaptiveStartBatch {
+		t.Fatalf("starting size = %d, want %d", tuner.size, adaptiveStartBatch)
+	}
+
+	steps := []struct {
+		name  string
+		apply func()
+		want  int
+	}{
+		{"fast batch grows", func() { tuner.success(time.Millisecond) }, 10},
+		{"growth is capped", func() { tuner.success(time.Millisecond) }, 12},
+		{"slow batch halves", func() { tuner.success(2 * adaptiveTargetLatency) }, 6},
+		{"error halves", func() { tuner.failure() }, 3},
+		{"never below one", func() { tuner.failure(); tuner.failure(); tuner.failure() }, 1},
+	}
+	for _, step := range steps {
+		step.apply()
+		if tuner.size != step.want {
+			t.Fatalf("%s: size = %d, want %d", step.name, tuner.size, step.want)
+		}
+	}
+
+	if small := newBatchTuner(2); small.size != 2 {
+		t.Errorf("size = %d, want the start capped at the limit", small.size)
+	}
+}
+
+func TestFetchCheckpointRangeAdaptive(t *testing.T) {
+	oldRetry, oldBatch := retryDelay, batchDelay
+	retryDelay, batchDelay = 0, 0
+	defer func() { retryDelay, batchDelay = oldRetry, oldBatch }()
+
+	// The fourth request is rate limited once
+	var limits []int
+	pages := checkpointPages(1000, nil, new(int))
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getCheckpoints": func(params []interface{}) mockResponse {
+			limits = append(limits, int(params[1].(float64)))
+			if len(limits) == 4 {
+				return mockResponse{Status: 429}
+			}
+			return pages(params)
+		},
+	})
+
+	checkpoints, err := FetchCheckpointRangeWithOptions(context.Background(), client, 0, 59, CheckpointRangeOptions{BatchSize: 20, Adaptive: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(checkpoints) != 60 {
+		t.Fatalf("got %d checkpoints, want 60", len(checkpoints))
+	}
+
+	want := []int{5, 10, 15, 20, 10, 15}
+	for i, limit := range want {
+		if i >= len(limits) || limits[i] != limit {
+			t.Fatalf("requested page sizes %v, want them to start with %v", limits, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package suitrace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of hex characters in a Sui address or object ID (32 bytes)
+const suiAddressHexLength = 64
+
+// Convert a Sui address or object ID to its canonical form: lowercase,
+// 0x-prefixed and left-padded with zeros to 64 hex characters. Accepts
+// input with or without the 0x prefix and with leading zeros truncated.
+func NormalizeSuiAddress(s string) (string, error) {
+	hex := strings.ToLower(strings.TrimSpace(s))
+	hex = strings.TrimPrefix(hex, "0x")
+
+	if hex == "" {
+		return "", fmt.Errorf("invalid Sui address %q: empty", s)
+	}
+	if len(hex) > suiAddressHexLength {
+		return "", fmt.Errorf("invalid Sui address %q: longer than %d hex characters", s, suiAddressHexLength)
+	}
+	for _, r := range hex {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return "", fmt.Errorf("invalid Sui address %q: non-hex character %q", s, r)
+		}
+	}
+
+	return "0x" + strings.Repeat("0", suiAddressHexLength-len(hex)) + hex, nil
+}
+
+// Report whether two addresses refer to the same account or object
+func sameSuiAddress(a, b string) bool {
+	na, errA := NormalizeSuiAddress(a)
+	nb, errB := NormalizeSuiAddress(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return na == nb
+}
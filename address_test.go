@@ -0,0 +1,41 @@
+package suitrace
+
+import "testing"
+
+func TestNormalizeSuiAddress(t *testing.T) {
+	const full = "0x5d8b8a7f9c2e4b6a1d3f0e9c8b7a6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c"
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: full, want: full},
+		{in: "5d8b8a7f9c2e4b6a1d3f0e9c8b7a6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c", want: full},
+		{in: "0X5D8B8A7F9C2E4B6A1D3F0E9C8B7A6D5E4F3A2B1C0D9E8F7A6B5C4D3E2F1A0B9C", want: full},
+		{in: "  " + full + "\n", want: full},
+		{in: "0x6", want: "0x0000000000000000000000000000000000000000000000000000000000000006"},
+		{in: "2", want: "0x0000000000000000000000000000000000000000000000000000000000000002"},
+		{in: "", wantErr: true},
+		{in: "0x", wantErr: true},
+		{in: "0xg1", wantErr: true},
+		{in: full + "0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeSuiAddress(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NormalizeSuiAddress(%q) = %q, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NormalizeSuiAddress(%q) unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeSuiAddress(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
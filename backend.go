@@ -0,0 +1,114 @@
+package suitrace
+
+import (
+	"context"
+)
+
+// Backend is the transport the fetch functions read chain data through.
+// Client implements it over JSON-RPC and GraphQLClient over GraphQL, so
+// FetchCheckpointRange, FetchObjectHistory and BackfillEvents work with either.
+type Backend interface {
+	// Sequence number of the latest checkpoint
+	LatestSequenceNumber(ctx context.Context) (int64, error)
+
+	// A single checkpoint by sequence number
+	GetCheckpoint(ctx context.Context, seq int64) (*CheckpointData, error)
+
+	// A contiguous run of checkpoints from start, ending no later than end.
+	// On error, the checkpoints fetched before the failure are returned too.
+	GetCheckpoints(ctx context.Context, start, end int64) ([]CheckpointData, error)
+
+	// The current state of an object
+	GetObject(ctx context.Context, objectID string) (*ObjectState, error)
+
+	// Digests of the newest limit transactions that touched an object,
+	// newest first, or of all of them when limit is 0
+	ObjectTransactions(ctx context.Context, objectID string, limit int) ([]string, error)
+
+	// The state of an object as written by a transaction
+	ObjectAtTransaction(ctx context.Context, txDigest, objectID string, opts HistoryOptions) (*ObjectState, error)
+
+	// One page of events matching filter after cursor, and the cursor of the next page
+	QueryEvents(ctx context.Context, filter map[string]interface{}, cursor *EventCursor, opts EventQueryOptions) ([]map[string]interface{}, *EventCursor, error)
+}
+
+var (
+	_ Backend = (*Client)(nil)
+	_ Backend = (*GraphQLClient)(nil)
+)
+
+// Print debug output if the backend supports it
+func debugPrint(b Backend, format string, a ...interface{}) {
+	if d, ok := b.(interface {
+		DebugPrint(format string, a ...interface{})
+	}); ok {
+		d.DebugPrint(format, a...)
+	}
+}
+
+func (c *Client) LatestSequenceNumber(ctx context.Context) (int64, error) {
+	return c.withContext(ctx).FetchLatestSequenceNumber()
+}
+
+func (c *Client) GetCheckpoint(ctx context.Context, seq int64) (*CheckpointData, error) {
+	return c.withContext(ctx).FetchCheckpoint(seq)
+}
+
+func (c *Client) GetCheckpoints(ctx context.Context, start, end int64) ([]CheckpointData, error) {
+	return c.withContext(ctx).fetchCheckpointSpan(int(start), int(end))
+}
+
+func (c *Client) GetObject(ctx context.Context, objectID string) (*ObjectState, error) {
+	return c.withContext(ctx).GetObjectCurrentState(objectID)
+}
+
+func (c *Client) ObjectTransactions(ctx context.Context, objectID string, limit int) ([]string, error) {
+	return c.withContext(ctx).GetObjectTransactions(objectID, limit)
+}
+
+func (c *Client) ObjectAtTransaction(ctx context.Context, txDigest, objectID string, opts HistoryOptions) (*ObjectState, error) {
+	return c.withContext(ctx).GetObjectDetailsFromTransaction(txDigest, objectID, opts)
+}
+
+func (c *Client) QueryEvents(ctx context.Context, filter map[string]interface{}, cursor *EventCursor, opts EventQueryOptions) ([]map[string]interface{}, *EventCursor, error) {
+	return c.withContext(ctx).FetchEventsWithOptions(filter, cursor, opts)
+}
+
+func (g *GraphQLClient) LatestSequenceNumber(ctx context.Context) (int64, error) {
+	return g.withContext(ctx).FetchLatestSequenceNumber()
+}
+
+func (g *GraphQLClient) GetCheckpoint(ctx context.Context, seq int64) (*CheckpointData, error) {
+	return g.withContext(ctx).FetchCheckpoint(seq)
+}
+
+// GraphQL has no range query by sequence number, so checkpoints are fetched one by one
+func (g *GraphQLClient) GetCheckpoints(ctx context.Context, start, end int64) ([]CheckpointData, error) {
+	g = g.withContext(ctx)
+
+	checkpoints := []CheckpointData{}
+	for seq := start; seq <= end; seq++ {
+		checkpoint, err := g.FetchCheckpoint(seq)
+		if err != nil {
+			return checkpoints, err
+		}
+		checkpoints = append(checkpoints, *checkpoint)
+	}
+	return checkpoints, nil
+}
+
+func (g *GraphQLClient) GetObject(ctx context.Context, objectID string) (*ObjectState, error) {
+	return nil, ErrNotSupported
+}
+
+func (g *GraphQLClient) ObjectTransactions(ctx context.Context, objectID string, limit int) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+func (g *GraphQLClient) ObjectAtTransaction(ctx context.Context, txDigest, objectID string, opts HistoryOptions) (*ObjectState, error) {
+	return nil, ErrNotSupported
+}
+
+func (g *GraphQLClient) QueryEvents(ctx context.Context, filter map[string]interface{}, cursor *EventCursor, opts EventQueryOptions) ([]map[string]interface{}, *EventCursor, error) {
+	return nil, nil, ErrNotSupported
+}
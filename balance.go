@@ -0,0 +1,99 @@
+package suitrace
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Total balance of one coin type held by an address
+type Balance struct {
+	CoinType        string   `json:"coinType"`
+	TotalBalance    *big.Int `json:"totalBalance"`
+	CoinObjectCount int      `json:"coinObjectCount"`
+}
+
+// Fetch the balance of coinType held by address. An empty coinType means SUI.
+func (c *Client) GetBalance(address, coinType string) (*Balance, error) {
+	address, err := NormalizeSuiAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	params := []interface{}{address}
+	if coinType != "" {
+		params = append(params, coinType)
+	}
+
+	result, err := c.MakeRPCCall("suix_getBalance", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	resultObj, ok := result["result"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid balance response for %s", address)
+	}
+
+	balance, err := parseBalance(resultObj)
+	if err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+// Fetch the balance of every coin type held by address, sorted by coin type
+func (c *Client) GetAllBalances(address string) ([]Balance, error) {
+	address, err := NormalizeSuiAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.MakeRPCCall("suix_getAllBalances", []interface{}{address})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	entries, _ := result["result"].([]interface{})
+	balances := []Balance{}
+	for _, entry := range entries {
+		entryObj, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		balance, err := parseBalance(entryObj)
+		if err != nil {
+			return balances, err
+		}
+		balances = append(balances, balance)
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].CoinType < balances[j].CoinType
+	})
+
+	c.DebugPrint("Found %d coin types held by %s", len(balances), address)
+	return balances, nil
+}
+
+// Extract a balance entry. Totals are u128 decimal strings, so they are
+// parsed into a big.Int rather than an int64.
+func parseBalance(entry map[string]interface{}) (Balance, error) {
+	balance := Balance{}
+
+	if coinType, ok := entry["coinType"].(string); ok {
+		balance.CoinType = coinType
+	}
+
+	total, ok := new(big.Int).SetString(fmt.Sprintf("%v", entry["totalBalance"]), 10)
+	if !ok {
+		return balance, fmt.Errorf("invalid totalBalance %v for %s", entry["totalBalance"], balance.CoinType)
+	}
+	balance.TotalBalance = total
+
+	if count, err := parseU64(entry["coinObjectCount"]); err == nil {
+		balance.CoinObjectCount = int(count)
+	}
+
+	return balance, nil
+}
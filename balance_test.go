@@ -0,0 +1,83 @@
+package suitrace
+
+import (
+	"testing"
+)
+
+func TestGetAllBalancesParsesBigTotals(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_getAllBalances": func(params []interface{}) mockResponse {
+			if len(params) != 1 || params[0] != testObjectID {
+				t.Errorf("unexpected params: %v", params)
+			}
+			return mockResponse{Result: []interface{}{
+				map[string]interface{}{
+					"coinType":        "0xabc::usdc::USDC",
+					"coinObjectCount": 1,
+					"totalBalance":    "340282366920938463463374607431768211455",
+					"lockedBalance":   map[string]interface{}{},
+				},
+				map[string]interface{}{
+					"coinType":        "0x2::sui::SUI",
+					"coinObjectCount": 3,
+					"totalBalance":    "1500000000",
+					"lockedBalance":   map[string]interface{}{},
+				},
+			}}
+		},
+	})
+
+	balances, err := client.GetAllBalances(testObjectID)
+	if err != nil {
+		t.Fatalf("GetAllBalances: %v", err)
+	}
+	if len(balances) != 2 {
+		t.Fatalf("got %d balances, want 2", len(balances))
+	}
+
+	// Sorted by coin type
+	if balances[0].CoinType != "0x2::sui::SUI" || balances[0].TotalBalance.String() != "1500000000" || balances[0].CoinObjectCount != 3 {
+		t.Errorf("unexpected SUI balance: %+v", balances[0])
+	}
+	if balances[1].TotalBalance.String() != "340282366920938463463374607431768211455" {
+		t.Errorf("u128 total = %s", balances[1].TotalBalance)
+	}
+}
+
+func TestGetBalance(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_getBalance": func(params []interface{}) mockResponse {
+			if len(params) != 2 || params[1] != "0x2::sui::SUI" {
+				t.Errorf("unexpected params: %v", params)
+			}
+			return mockResponse{Result: map[string]interface{}{
+				"coinType":        "0x2::sui::SUI",
+				"coinObjectCount": 2,
+				"totalBalance":    "42",
+			}}
+		},
+	})
+
+	balance, err := client.GetBalance(testObjectID, "0x2::sui::SUI")
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if balance.TotalBalance.Int64() != 42 || balance.CoinObjectCount != 2 {
+		t.Errorf("unexpected balance: %+v", balance)
+	}
+}
+
+func TestGetBalanceRejectsInvalidTotal(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_getBalance": func(params []interface{}) mockResponse {
+			return mockResponse{Result: map[string]interface{}{
+				"coinType":     "0x2::sui::SUI",
+				"totalBalance": "not a number",
+			}}
+		},
+	})
+
+	if _, err := client.GetBalance(testObjectID, ""); err == nil {
+		t.Fatal("expected an error for an invalid totalBalance")
+	}
+}
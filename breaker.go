@@ -0,0 +1,104 @@
+package suitrace
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Default time a tripped CircuitBreaker fails requests before letting one through
+const DefaultBreakerCooldown = 30 * time.Second
+
+// Circuit breaker shared by every request to an endpoint. After Threshold
+// consecutive transient failures it trips, and for Cooldown every request
+// fails fast with EndpointUnhealthyError instead of reaching the endpoint.
+// After the cooldown a single probe request is let through while the rest
+// keep failing fast; if it fails the breaker trips again, if it succeeds the
+// breaker closes. A probe that never reports back, such as one cancelled
+// before it was sent, is replaced by another after a further cooldown.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	lastErr   error
+	openUntil time.Time
+	probing   bool // A probe request is in flight
+}
+
+// Create a breaker that trips after threshold consecutive failures. Share
+// one between clients to cover all requests of a run.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+// Returned instead of sending a request while the breaker is tripped
+type EndpointUnhealthyError struct {
+	Failures int       // Consecutive failures that tripped the breaker
+	Until    time.Time // When requests are let through again
+	Last     error     // Last failure before the breaker tripped
+}
+
+func (e *EndpointUnhealthyError) Error() string {
+	return fmt.Sprintf("endpoint appears unhealthy: %d consecutive requests failed (last: %v); failing fast until %s",
+		e.Failures, e.Last, e.Until.Format(time.RFC3339))
+}
+
+func (e *EndpointUnhealthyError) Unwrap() error {
+	return e.Last
+}
+
+// Check whether a request may be sent. A nil breaker allows everything.
+func (b *CircuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return nil
+	}
+	if time.Now().Before(b.openUntil) {
+		return &EndpointUnhealthyError{Failures: b.failures, Until: b.openUntil, Last: b.lastErr}
+	}
+
+	// Cooldown over: let this request through as the probe and hold the
+	// others back until it reports
+	b.openUntil = time.Now().Add(b.Cooldown)
+	b.probing = true
+	return nil
+}
+
+// Record the outcome of a request. Only transient errors count as failures;
+// permanent ones such as a missing object mean the endpoint is answering.
+func (b *CircuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || !IsTransient(err) {
+		b.failures = 0
+		if b.probing {
+			b.openUntil = time.Time{}
+			b.probing = false
+		}
+		return
+	}
+
+	b.failures++
+	b.lastErr = err
+	if b.probing {
+		b.openUntil = time.Now().Add(b.Cooldown)
+		b.probing = false
+		return
+	}
+	if b.Threshold > 0 && b.failures >= b.Threshold && b.openUntil.IsZero() {
+		b.openUntil = time.Now().Add(b.Cooldown)
+	}
+}
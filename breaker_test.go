@@ -0,0 +1,122 @@
+package suitrace
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls, healthy atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if healthy.Load() == 0 {
+			http.Error(w, "upstream down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"42"}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	client.Breaker = NewCircuitBreaker(3, 50*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		var httpErr *HTTPError
+		if _, err := client.FetchLatestSequenceNumber(); !errors.As(err, &httpErr) {
+			t.Fatalf("request %d: got %v, want the endpoint's HTTP error", i+1, err)
+		}
+	}
+
+	// Tripped: fail fast without reaching the endpoint
+	_, err := client.FetchLatestSequenceNumber()
+	var unhealthy *EndpointUnhealthyError
+	if !errors.As(err, &unhealthy) {
+		t.Fatalf("got %v, want EndpointUnhealthyError", err)
+	}
+	if unhealthy.Failures != 3 || IsTransient(err) {
+		t.Errorf("unexpected breaker error %v (transient %v)", err, IsTransient(err))
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("endpoint got %d calls, want 3", n)
+	}
+
+	// After the cooldown one failure trips it again
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.FetchLatestSequenceNumber(); errors.As(err, &unhealthy) {
+		t.Fatalf("request after cooldown failed fast: %v", err)
+	}
+	if _, err := client.FetchLatestSequenceNumber(); !errors.As(err, &unhealthy) {
+		t.Fatalf("got %v, want the breaker to trip again after one failure", err)
+	}
+
+	// A success closes it
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(1)
+	if seq, err := client.FetchLatestSequenceNumber(); err != nil || seq != 42 {
+		t.Fatalf("got %d, %v after recovery, want 42", seq, err)
+	}
+	healthy.Store(0)
+	if _, err := client.FetchLatestSequenceNumber(); errors.As(err, &unhealthy) {
+		t.Errorf("one failure after recovery tripped the breaker: %v", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbe(t *testing.T) {
+	down := &HTTPError{StatusCode: http.StatusServiceUnavailable}
+	b := NewCircuitBreaker(1, 20*time.Millisecond)
+	b.record(down)
+	if err := b.allow(); err == nil {
+		t.Fatal("breaker did not trip")
+	}
+
+	// Only one probe is let through after the cooldown
+	time.Sleep(30 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe was refused: %v", err)
+	}
+	var unhealthy *EndpointUnhealthyError
+	if err := b.allow(); !errors.As(err, &unhealthy) {
+		t.Fatalf("got %v while the probe is in flight, want EndpointUnhealthyError", err)
+	}
+
+	// A probe that never reports back is replaced after another cooldown
+	time.Sleep(30 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("second probe was refused: %v", err)
+	}
+	b.record(nil)
+	for i := 0; i < 2; i++ {
+		if err := b.allow(); err != nil {
+			t.Fatalf("request %d after a successful probe: %v", i+1, err)
+		}
+	}
+}
+
+func TestCircuitBreakerStopsCheckpointRetries(t *testing.T) {
+	oldRetry, oldBatch := retryDelay, batchDelay
+	retryDelay, batchDelay = 0, 0
+	defer func() { retryDelay, batchDelay = oldRetry, oldBatch }()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "upstream down", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	client.Breaker = NewCircuitBreaker(2, time.Minute)
+
+	_, err := client.FetchCheckpointRange(0, 500, 10)
+	var unhealthy *EndpointUnhealthyError
+	if !errors.As(err, &unhealthy) {
+		t.Fatalf("got %v, want EndpointUnhealthyError", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("endpoint got %d calls, want 2", n)
+	}
+}
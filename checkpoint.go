@@ -1,317 +1,775 @@
-package main
+package suitrace
 
 import (
-	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
-	"flag"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"net/http"
-	"os"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
-const (
-	rpcURL = "https://rpc.mainnet.sui.io" // Sui mainnet RPC
+// Largest page sui_getCheckpoints will return
+const MaxCheckpointPageSize = 100
+
+var (
+	retryDelay = 2 * time.Second        // Wait before retrying a failed batch
+	batchDelay = 200 * time.Millisecond // Pause between batches
 )
 
+// A checkpoint as written to JSON output. Keys use the camelCase names of the
+// Sui RPC, and u64 values are encoded as strings like the RPC does, so output
+// can be read back with the same parser as a sui_getCheckpoint response.
 type CheckpointData struct {
-	Digest           string
-	SequenceNumber   int64
-	TimestampMs      int64
-	ValidatorSignature string
-	TransactionDigests []string
-	NetworkTotalTransactions int64
-	EventRoot        string
+	Digest                   string   `json:"digest"`
+	PreviousDigest           string   `json:"previousDigest,omitempty"` // Empty for the genesis checkpoint
+	SequenceNumber           int64    `json:"sequenceNumber,string"`
+	Epoch                    int64    `json:"epoch,string"`
+	TimestampMs              int64    `json:"timestampMs,string"`
+	ValidatorSignature       string   `json:"validatorSignature"`
+	TransactionDigests       []string `json:"transactions"`
+	NetworkTotalTransactions int64    `json:"networkTotalTransactions,string"`
+	EventRoot                string   `json:"eventRoot"`
+
+	// Gas costs of the epoch so far, as of this checkpoint. Nil when the
+	// node did not report them.
+	EpochRollingGasCostSummary *GasCostSummary `json:"epochRollingGasCostSummary,omitempty"`
+}
+
+// Gas costs in MIST, summed over the transactions of an epoch
+type GasCostSummary struct {
+	ComputationCost         int64 `json:"computationCost,string"`
+	StorageCost             int64 `json:"storageCost,string"`
+	StorageRebate           int64 `json:"storageRebate,string"`
+	NonRefundableStorageFee int64 `json:"nonRefundableStorageFee,string"`
+}
+
+// Function to fetch checkpoints within a range over JSON-RPC
+func (c *Client) FetchCheckpointRange(startCheckpoint, endCheckpoint int, maxBatchSize int) ([]CheckpointData, error) {
+	return FetchCheckpointRange(context.Background(), c, startCheckpoint, endCheckpoint, maxBatchSize)
+}
+
+// Options for a checkpoint range fetch
+type CheckpointRangeOptions struct {
+	BatchSize int  // Checkpoints per batch, or the largest batch when Adaptive
+	Adaptive  bool // Start small and grow or shrink the batch with latency and errors
+	Sample    int  // Only fetch every Sample-th checkpoint from the start, one per request; 0 or 1 fetches them all
+}
+
+// Fetch checkpoints within a range from any backend
+func FetchCheckpointRange(ctx context.Context, b Backend, startCheckpoint, endCheckpoint int, maxBatchSize int) ([]CheckpointData, error) {
+	return FetchCheckpointRangeWithOptions(ctx, b, startCheckpoint, endCheckpoint, CheckpointRangeOptions{BatchSize: maxBatchSize})
+}
+
+// Fetch checkpoints within a range from any backend, with batch sizing options
+func FetchCheckpointRangeWithOptions(ctx context.Context, b Backend, startCheckpoint, endCheckpoint int, opts CheckpointRangeOptions) ([]CheckpointData, error) {
+	checkpoints, _, err := fetchCheckpointRange(ctx, b, startCheckpoint, endCheckpoint, opts, false)
+	return checkpoints, err
+}
+
+// A checkpoint that could not be fetched in a best-effort range fetch
+type FailedCheckpoint struct {
+	Sequence int64
+	Err      error
+}
+
+func (f FailedCheckpoint) String() string {
+	return fmt.Sprintf("checkpoint %d: %v", f.Sequence, f.Err)
+}
+
+// Fetch checkpoints within a range like FetchCheckpointRangeWithOptions, but
+// skip a checkpoint that still fails after its retries, or fails with an
+// error that is not retryable, and carry on with the next one. The skipped
+// checkpoints are returned with the ones fetched. The error is only set
+// when the range is invalid or ctx ends.
+func FetchCheckpointRangeBestEffort(ctx context.Context, b Backend, startCheckpoint, endCheckpoint int, opts CheckpointRangeOptions) ([]CheckpointData, []FailedCheckpoint, error) {
+	return fetchCheckpointRange(ctx, b, startCheckpoint, endCheckpoint, opts, true)
 }
 
-// Function to fetch checkpoints within a range
-func FetchCheckpointRange(startCheckpoint, endCheckpoint int, maxBatchSize int) ([]CheckpointData, error) {
+func fetchCheckpointRange(ctx context.Context, b Backend, startCheckpoint, endCheckpoint int, opts CheckpointRangeOptions, bestEffort bool) ([]CheckpointData, []FailedCheckpoint, error) {
 	allCheckpoints := []CheckpointData{}
+	failed := []FailedCheckpoint{}
 	totalFetched := 0
 	maxRetries := 3
 	retryCount := 0
-	
-	// If no end checkpoint is specified, get the latest checkpoint first
-	if endCheckpoint <= 0 {
-		latestCheckpoint, err := FetchLatestCheckpoint()
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch latest checkpoint: %v", err)
-		}
-		endCheckpoint = int(latestCheckpoint.SequenceNumber)
-		fmt.Printf("Latest checkpoint is %d\n", endCheckpoint)
+
+	plan, err := PlanCheckpointRange(ctx, b, startCheckpoint, endCheckpoint, opts.BatchSize)
+	if err != nil {
+		return nil, nil, err
 	}
-	
-	// Validate range
-	if startCheckpoint < 0 {
-		return nil, fmt.Errorf("start checkpoint must be >= 0")
+	startCheckpoint, endCheckpoint = plan.Start, plan.End
+	batchSize := plan.BatchSize
+
+	// Sampling fetches single checkpoints and then skips ahead
+	step := 1
+	var tuner *batchTuner
+	if opts.Sample > 1 {
+		step, batchSize = opts.Sample, 1
+	} else if opts.Adaptive {
+		tuner = newBatchTuner(plan.BatchSize)
+		batchSize = tuner.size
 	}
-	if startCheckpoint > endCheckpoint {
-		return nil, fmt.Errorf("start checkpoint must be <= end checkpoint")
-	}
-	
-	fmt.Printf("Fetching checkpoints from %d to %d\n", startCheckpoint, endCheckpoint)
-	
-	// Process in batches
-	for currentStart := startCheckpoint; currentStart <= endCheckpoint; currentStart += maxBatchSize {
-		currentEnd := currentStart + maxBatchSize - 1
+
+	if step > 1 {
+		fmt.Printf("Fetching 1 in %d checkpoints from %d to %d\n", step, startCheckpoint, endCheckpoint)
+	} else {
+		fmt.Printf("Fetching checkpoints from %d to %d\n", startCheckpoint, endCheckpoint)
+	}
+
+	// Process in batches. currentStart only advances past checkpoints that were
+	// actually fetched, so a failed batch resumes from the first missing sequence.
+	for currentStart := startCheckpoint; currentStart <= endCheckpoint; {
+		currentEnd := currentStart + batchSize - 1
 		if currentEnd > endCheckpoint {
 			currentEnd = endCheckpoint
 		}
-		
+
 		fmt.Printf("Fetching batch from %d to %d...\n", currentStart, currentEnd)
-		
-		checkpoints, err := FetchCheckpointBatch(currentStart, currentEnd)
+
+		batchStart := time.Now()
+		checkpoints, err := b.GetCheckpoints(ctx, int64(currentStart), int64(currentEnd))
+		if tuner != nil {
+			latency := time.Since(batchStart)
+			if err != nil {
+				tuner.failure()
+			} else {
+				tuner.success(latency)
+			}
+			if tuner.size != batchSize {
+				debugPrint(b, "Adaptive batch size %d -> %d (last batch took %s)", batchSize, tuner.size, latency)
+				batchSize = tuner.size
+			}
+		}
+
+		// Keep whatever the batch fetched before it failed, and move on to the
+		// next checkpoint wanted after the last one fetched
+		allCheckpoints = append(allCheckpoints, checkpoints...)
+		totalFetched += len(checkpoints)
+		if len(checkpoints) > 0 {
+			currentStart += len(checkpoints) - 1 + step
+		}
+
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, fmt.Errorf("stopped at checkpoint %d: %w", currentStart, ctx.Err())
+			}
+
+			// Progress resets the retry budget for the next missing checkpoint
+			if len(checkpoints) > 0 {
+				retryCount = 0
+			}
 			retryCount++
-			
-			if retryCount > maxRetries {
-				return nil, fmt.Errorf("failed to fetch checkpoints after %d retries: %v", maxRetries, err)
+
+			var giveUp error
+			if !IsTransient(err) {
+				giveUp = fmt.Errorf("giving up on checkpoint %d, the error is not retryable: %w", currentStart, err)
+			} else if retryCount > maxRetries {
+				giveUp = fmt.Errorf("failed to fetch checkpoint %d after %d retries: %w", currentStart, maxRetries, err)
+			}
+			if giveUp != nil {
+				if !bestEffort {
+					return nil, nil, giveUp
+				}
+				fmt.Printf("Skipping checkpoint %d: %v\n", currentStart, err)
+				failed = append(failed, FailedCheckpoint{Sequence: int64(currentStart), Err: err})
+				currentStart += step
+				retryCount = 0
+				continue
+			}
+
+			fmt.Printf("Error fetching checkpoints: %v\nRetry attempt %d of %d, resuming from %d\n", err, retryCount, maxRetries, currentStart)
+			if err := sleepContext(ctx, retryDelay); err != nil {
+				return nil, nil, fmt.Errorf("stopped before retrying checkpoint %d: %w", currentStart, err)
 			}
-			
-			fmt.Printf("Error fetching checkpoints: %v\nRetry attempt %d of %d\n", err, retryCount, maxRetries)
-			currentStart -= maxBatchSize // Retry this batch
-			time.Sleep(2 * time.Second)  // Wait before retry
 			continue
 		}
-		
+
 		retryCount = 0
-		allCheckpoints = append(allCheckpoints, checkpoints...)
-		totalFetched += len(checkpoints)
 		fmt.Printf("Fetched %d checkpoints so far...\n", totalFetched)
-		
+
 		// Don't overwhelm the API
-		if currentStart+maxBatchSize <= endCheckpoint {
-			time.Sleep(200 * time.Millisecond)
+		if currentStart <= endCheckpoint {
+			if err := sleepContext(ctx, batchDelay); err != nil {
+				return nil, nil, fmt.Errorf("stopped before checkpoint %d: %w", currentStart, err)
+			}
 		}
 	}
-	
-	return allCheckpoints, nil
+
+	return allCheckpoints, failed, nil
+}
+
+// A validated checkpoint range fetch with the latest checkpoint resolved
+type CheckpointRangePlan struct {
+	Start     int
+	End       int
+	BatchSize int // Checkpoints per sui_getCheckpoints page, capped at MaxCheckpointPageSize
+	Requests  int // Estimated number of sui_getCheckpoints calls, without retries
+}
+
+// Validate a checkpoint range over JSON-RPC
+func (c *Client) PlanCheckpointRange(startCheckpoint, endCheckpoint int, maxBatchSize int) (CheckpointRangePlan, error) {
+	return PlanCheckpointRange(context.Background(), c, startCheckpoint, endCheckpoint, maxBatchSize)
+}
+
+// Validate a checkpoint range and resolve an end of 0 or less to the latest
+// checkpoint. That lookup is the only call it makes to the backend.
+func PlanCheckpointRange(ctx context.Context, b Backend, startCheckpoint, endCheckpoint int, maxBatchSize int) (CheckpointRangePlan, error) {
+	// If no end checkpoint is specified, get the latest checkpoint first
+	if endCheckpoint <= 0 {
+		latest, err := b.LatestSequenceNumber(ctx)
+		if err != nil {
+			return CheckpointRangePlan{}, fmt.Errorf("failed to fetch latest checkpoint: %w", err)
+		}
+		endCheckpoint = int(latest)
+		fmt.Printf("Latest checkpoint is %d\n", endCheckpoint)
+	}
+
+	// Validate range
+	if startCheckpoint < 0 {
+		return CheckpointRangePlan{}, fmt.Errorf("start checkpoint must be >= 0")
+	}
+	if startCheckpoint > endCheckpoint {
+		return CheckpointRangePlan{}, fmt.Errorf("start checkpoint must be <= end checkpoint")
+	}
+	if maxBatchSize < 1 {
+		return CheckpointRangePlan{}, fmt.Errorf("batch size must be >= 1")
+	}
+
+	// Each batch is a single sui_getCheckpoints page
+	if maxBatchSize > MaxCheckpointPageSize {
+		maxBatchSize = MaxCheckpointPageSize
+	}
+
+	count := endCheckpoint - startCheckpoint + 1
+	return CheckpointRangePlan{
+		Start:     startCheckpoint,
+		End:       endCheckpoint,
+		BatchSize: maxBatchSize,
+		Requests:  (count + maxBatchSize - 1) / maxBatchSize,
+	}, nil
+}
+
+// Totals for a checkpoint range
+type CheckpointRangeCount struct {
+	Start        int
+	End          int
+	Checkpoints  int64
+	Transactions int64
+}
+
+// Count the checkpoints and transactions in a range over JSON-RPC
+func (c *Client) CountCheckpointRange(startCheckpoint, endCheckpoint int) (CheckpointRangeCount, error) {
+	return CountCheckpointRange(context.Background(), c, startCheckpoint, endCheckpoint)
+}
+
+// Count the checkpoints and transactions in a range without fetching it.
+// Transactions are the difference in networkTotalTransactions between the
+// last checkpoint and the one before the first, so at most two checkpoints
+// are fetched.
+func CountCheckpointRange(ctx context.Context, b Backend, startCheckpoint, endCheckpoint int) (CheckpointRangeCount, error) {
+	plan, err := PlanCheckpointRange(ctx, b, startCheckpoint, endCheckpoint, MaxCheckpointPageSize)
+	if err != nil {
+		return CheckpointRangeCount{}, err
+	}
+
+	last, err := b.GetCheckpoint(ctx, int64(plan.End))
+	if err != nil {
+		return CheckpointRangeCount{}, fmt.Errorf("failed to fetch checkpoint %d: %w", plan.End, err)
+	}
+
+	var before int64
+	if plan.Start > 0 {
+		previous, err := b.GetCheckpoint(ctx, int64(plan.Start-1))
+		if err != nil {
+			return CheckpointRangeCount{}, fmt.Errorf("failed to fetch checkpoint %d: %w", plan.Start-1, err)
+		}
+		before = previous.NetworkTotalTransactions
+	}
+
+	return CheckpointRangeCount{
+		Start:        plan.Start,
+		End:          plan.End,
+		Checkpoints:  int64(plan.End - plan.Start + 1),
+		Transactions: last.NetworkTotalTransactions - before,
+	}, nil
 }
 
 // Fetch latest checkpoint to determine the current chain height
-func FetchLatestCheckpoint() (*CheckpointData, error) {
+func (c *Client) FetchLatestCheckpoint() (*CheckpointData, error) {
+	sequenceNumber, err := c.FetchLatestSequenceNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	// Now get the actual checkpoint data
+	checkpoint, err := c.FetchCheckpoint(sequenceNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkpoint, nil
+}
+
+// Fetch the sequence number of the latest checkpoint
+func (c *Client) FetchLatestSequenceNumber() (int64, error) {
 	payload := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      1,
 		"method":  "sui_getLatestCheckpointSequenceNumber",
 		"params":  []interface{}{},
 	}
-	
+
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %v", err)
-	}
-	
-	resp, err := http.Post(rpcURL, "application/json", bytes.NewReader(payloadBytes))
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-	
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
+		return 0, fmt.Errorf("failed to marshal payload: %w", err)
 	}
-	
+
 	var result struct {
-		Result string                 `json:"result"`
+		Result interface{}            `json:"result"`
 		Error  map[string]interface{} `json:"error"`
 	}
-	
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+
+	if err := c.call(payloadBytes, &result); err != nil {
+		return 0, err
 	}
-	
+
 	// Check for API errors
 	if result.Error != nil {
-		return nil, fmt.Errorf("API error: %v", result.Error)
+		return 0, parseRPCError(result.Error)
 	}
-	
+
 	// Convert sequence number to int
-	sequenceNumber, err := strconv.ParseInt(result.Result, 10, 64)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse sequence number: %v", err)
-	}
-	
-	// Now get the actual checkpoint data
-	checkpoint, err := FetchCheckpoint(sequenceNumber)
+	sequenceNumber, err := parseU64(result.Result)
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("failed to parse sequence number: %w", err)
 	}
-	
-	return checkpoint, nil
+
+	return int64(sequenceNumber), nil
 }
 
 // Fetch a batch of checkpoints
-func FetchCheckpointBatch(start, end int) ([]CheckpointData, error) {
+func (c *Client) FetchCheckpointBatch(start, end int) ([]CheckpointData, error) {
 	checkpoints := []CheckpointData{}
-	
+
 	for seq := start; seq <= end; seq++ {
-		checkpoint, err := FetchCheckpoint(int64(seq))
+		checkpoint, err := c.FetchCheckpoint(int64(seq))
 		if err != nil {
 			return checkpoints, err
 		}
 		checkpoints = append(checkpoints, *checkpoint)
 	}
-	
+
 	return checkpoints, nil
 }
 
 // Fetch a single checkpoint by sequence number
-func FetchCheckpoint(sequenceNumber int64) (*CheckpointData, error) {
+func (c *Client) FetchCheckpoint(sequenceNumber int64) (*CheckpointData, error) {
 	payload := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      1,
 		"method":  "sui_getCheckpoint",
 		"params":  []interface{}{strconv.FormatInt(sequenceNumber, 10)},
 	}
-	
+
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %v", err)
-	}
-	
-	resp, err := http.Post(rpcURL, "application/json", bytes.NewReader(payloadBytes))
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
-	defer resp.Body.Close()
-	
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
-	}
-	
+
 	var result struct {
 		Result map[string]interface{} `json:"result"`
 		Error  map[string]interface{} `json:"error"`
 	}
-	
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+
+	if err := c.call(payloadBytes, &result); err != nil {
+		return nil, err
 	}
-	
+
 	// Check for API errors
 	if result.Error != nil {
-		return nil, fmt.Errorf("API error: %v", result.Error)
+		return nil, parseRPCError(result.Error)
+	}
+
+	checkpoint := parseCheckpoint(result.Result)
+	if err := validateCheckpoint(result.Result, checkpoint, sequenceNumber); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return nil, err
 	}
-	
+
+	return &checkpoint, nil
+}
+
+// Check that a parsed checkpoint has a digest and a sequence number, and
+// that the sequence is the one requested (pass -1 to skip that check). A
+// response missing them would otherwise become a blank row in the output.
+func validateCheckpoint(raw map[string]interface{}, checkpoint CheckpointData, requested int64) error {
+	if checkpoint.Digest == "" {
+		return &MalformedCheckpointError{SequenceNumber: requested, Reason: "missing digest"}
+	}
+
+	if _, err := parseU64(raw["sequenceNumber"]); err != nil {
+		return &MalformedCheckpointError{SequenceNumber: requested, Reason: fmt.Sprintf("bad sequenceNumber: %v", err)}
+	}
+
+	if requested >= 0 && checkpoint.SequenceNumber != requested {
+		return &MalformedCheckpointError{SequenceNumber: requested, Reason: fmt.Sprintf("got sequence number %d", checkpoint.SequenceNumber)}
+	}
+
+	return nil
+}
+
+// Extract checkpoint data from a decoded RPC checkpoint object
+func parseCheckpoint(raw map[string]interface{}) CheckpointData {
 	// Extract checkpoint data
-	checkpoint := &CheckpointData{}
-	
+	checkpoint := CheckpointData{}
+
 	// Extract basic fields
-	if digest, ok := result.Result["digest"].(string); ok {
+	if digest, ok := raw["digest"].(string); ok {
 		checkpoint.Digest = digest
 	}
-	
-	if seqStr, ok := result.Result["sequenceNumber"].(string); ok {
-		seq, err := strconv.ParseInt(seqStr, 10, 64)
-		if err == nil {
-			checkpoint.SequenceNumber = seq
-		}
+
+	if previousDigest, ok := raw["previousDigest"].(string); ok {
+		checkpoint.PreviousDigest = previousDigest
 	}
-	
-	if timestampStr, ok := result.Result["timestampMs"].(string); ok {
+
+	if seq, err := parseU64(raw["sequenceNumber"]); err == nil {
+		checkpoint.SequenceNumber = int64(seq)
+	}
+
+	if epoch, err := parseU64(raw["epoch"]); err == nil {
+		checkpoint.Epoch = int64(epoch)
+	}
+
+	if timestampStr, ok := raw["timestampMs"].(string); ok {
 		timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
 		if err == nil {
 			checkpoint.TimestampMs = timestamp
 		}
 	}
-	
-	if networkTotalTransactionsStr, ok := result.Result["networkTotalTransactions"].(string); ok {
+
+	if networkTotalTransactionsStr, ok := raw["networkTotalTransactions"].(string); ok {
 		networkTotal, err := strconv.ParseInt(networkTotalTransactionsStr, 10, 64)
 		if err == nil {
 			checkpoint.NetworkTotalTransactions = networkTotal
 		}
 	}
-	
-	if validatorSignature, ok := result.Result["validatorSignature"].(string); ok {
+
+	if validatorSignature, ok := raw["validatorSignature"].(string); ok {
 		checkpoint.ValidatorSignature = validatorSignature
 	}
-	
-	if eventRoot, ok := result.Result["eventRoot"].(string); ok {
+
+	if eventRoot, ok := raw["eventRoot"].(string); ok {
 		checkpoint.EventRoot = eventRoot
 	}
-	
+
 	// Extract transaction digests
-	if transactions, ok := result.Result["transactions"].([]interface{}); ok {
+	if transactions, ok := raw["transactions"].([]interface{}); ok {
 		for _, tx := range transactions {
 			if txStr, ok := tx.(string); ok {
 				checkpoint.TransactionDigests = append(checkpoint.TransactionDigests, txStr)
 			}
 		}
 	}
-	
-	return checkpoint, nil
+
+	if summary, ok := raw["epochRollingGasCostSummary"].(map[string]interface{}); ok {
+		checkpoint.EpochRollingGasCostSummary = parseGasCostSummary(summary)
+	}
+
+	return checkpoint
 }
 
-// Save checkpoints to CSV
-func SaveCheckpointsToCSV(checkpoints []CheckpointData, filename string) error {
-	file, err := os.Create(filename)
+// Read a gas cost summary whose amounts are decimal strings (or numbers).
+// Amounts that are missing or do not fit an int64 are left at zero.
+func parseGasCostSummary(raw map[string]interface{}) *GasCostSummary {
+	amount := func(key string) int64 {
+		n, err := parseU64(raw[key])
+		if err != nil || n > math.MaxInt64 {
+			return 0
+		}
+		return int64(n)
+	}
+	return &GasCostSummary{
+		ComputationCost:         amount("computationCost"),
+		StorageCost:             amount("storageCost"),
+		StorageRebate:           amount("storageRebate"),
+		NonRefundableStorageFee: amount("nonRefundableStorageFee"),
+	}
+}
+
+// Fetch up to limit checkpoints after cursor with sui_getCheckpoints. An empty
+// cursor starts from the beginning (or the chain head when descending). Returns
+// the page, the cursor for the next page, and whether more pages exist.
+func (c *Client) FetchCheckpointsPaged(cursor string, limit int, descending bool) ([]CheckpointData, string, bool, error) {
+	var cursorParam interface{}
+	if cursor != "" {
+		cursorParam = cursor
+	}
+
+	payload := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "sui_getCheckpoints",
+		"params":  []interface{}{cursorParam, limit, descending},
+	}
+
+	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to create CSV file: %v", err)
+		return nil, "", false, fmt.Errorf("failed to marshal payload: %w", err)
 	}
-	defer file.Close()
-	
+
+	var result struct {
+		Result struct {
+			Data        []map[string]interface{} `json:"data"`
+			NextCursor  interface{}              `json:"nextCursor"`
+			HasNextPage bool                     `json:"hasNextPage"`
+		} `json:"result"`
+		Error map[string]interface{} `json:"error"`
+	}
+
+	if err := c.call(payloadBytes, &result); err != nil {
+		return nil, "", false, err
+	}
+
+	// Check for API errors
+	if result.Error != nil {
+		return nil, "", false, parseRPCError(result.Error)
+	}
+
+	checkpoints := make([]CheckpointData, 0, len(result.Result.Data))
+	for _, raw := range result.Result.Data {
+		checkpoint := parseCheckpoint(raw)
+		if err := validateCheckpoint(raw, checkpoint, -1); err != nil {
+			fmt.Printf("Warning: %v in page after cursor %q\n", err, cursor)
+			return nil, "", false, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	nextCursor := ""
+	if next, err := parseU64(result.Result.NextCursor); err == nil {
+		nextCursor = strconv.FormatUint(next, 10)
+	}
+
+	return checkpoints, nextCursor, result.Result.HasNextPage, nil
+}
+
+// Fetch a contiguous run of checkpoints starting at start and ending no later
+// than end, using one sui_getCheckpoints page. Sequences missing from the page
+// are filled with per-sequence fetches. The result is always contiguous from
+// start, so on error it holds whatever was fetched before the failure.
+func (c *Client) fetchCheckpointSpan(start, end int) ([]CheckpointData, error) {
+	cursor := ""
+	if start > 0 {
+		cursor = strconv.Itoa(start - 1)
+	}
+
+	limit := end - start + 1
+	if limit > MaxCheckpointPageSize {
+		limit = MaxCheckpointPageSize
+	}
+
+	page, _, _, err := c.FetchCheckpointsPaged(cursor, limit, false)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints := []CheckpointData{}
+	next := int64(start)
+	for _, checkpoint := range page {
+		if checkpoint.SequenceNumber < next {
+			continue
+		}
+		if checkpoint.SequenceNumber > int64(end) {
+			break
+		}
+
+		// Fill any gap the page skipped over
+		for ; next < checkpoint.SequenceNumber; next++ {
+			c.DebugPrint("Filling gap at checkpoint %d", next)
+			missing, err := c.FetchCheckpoint(next)
+			if err != nil {
+				return checkpoints, err
+			}
+			checkpoints = append(checkpoints, *missing)
+		}
+
+		checkpoints = append(checkpoints, checkpoint)
+		next++
+	}
+
+	// An empty page means nothing usable came back, try the sequence directly
+	if len(checkpoints) == 0 {
+		checkpoint, err := c.FetchCheckpoint(int64(start))
+		if err != nil {
+			return checkpoints, err
+		}
+		checkpoints = append(checkpoints, *checkpoint)
+	}
+
+	return checkpoints, nil
+}
+
+// Save checkpoints to CSV, returning the files written
+func SaveCheckpointsToCSV(checkpoints []CheckpointData, filename string, opts WriteOptions) ([]string, error) {
+	fields, err := checkpointOutputFields(opts, defaultCheckpointCSVFields)
+	if err != nil {
+		return nil, err
+	}
+
+	return saveShards(checkpoints, filename, opts, func(checkpoints []CheckpointData, filename string) error {
+		return writeCheckpointsCSV(checkpoints, fields, filename, opts)
+	})
+}
+
+func writeCheckpointsCSV(checkpoints []CheckpointData, fields []checkpointField, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.discard()
+
 	writer := csv.NewWriter(file)
-	defer writer.Flush()
-	
+
 	// Write header
-	headers := []string{
-		"Digest", 
-		"SequenceNumber", 
-		"TimestampMs", 
-		"TransactionCount", 
-		"NetworkTotalTransactions",
-		"EventRoot",
-	}
-	
+	headers := make([]string, len(fields))
+	for i, field := range fields {
+		headers[i] = field.header
+	}
+
 	if err := writer.Write(headers); err != nil {
-		return fmt.Errorf("failed to write CSV header: %v", err)
+		return fmt.Errorf("failed to write CSV header: %w", err)
 	}
-	
+
 	// Write data
 	for _, checkpoint := range checkpoints {
-		record := []string{
-			checkpoint.Digest,
-			strconv.FormatInt(checkpoint.SequenceNumber, 10),
-			strconv.FormatInt(checkpoint.TimestampMs, 10),
-			strconv.Itoa(len(checkpoint.TransactionDigests)),
-			strconv.FormatInt(checkpoint.NetworkTotalTransactions, 10),
-			checkpoint.EventRoot,
+		record := make([]string, len(fields))
+		for i, field := range fields {
+			record[i] = field.csv(checkpoint)
 		}
-		
+
 		if err := writer.Write(record); err != nil {
-			return fmt.Errorf("failed to write record to CSV: %v", err)
+			return fmt.Errorf("failed to write record to CSV: %w", err)
 		}
 	}
-	
-	return nil
-}
 
-// Save detailed checkpoint data to JSON
-func SaveCheckpointsToJSON(checkpoints []CheckpointData, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create JSON file: %v", err)
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV file: %w", err)
 	}
-	defer file.Close()
-	
-	data, err := json.MarshalIndent(checkpoints, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal checkpoint data: %v", err)
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close CSV file: %w", err)
 	}
-	
-	_, err = file.Write(data)
-	if err != nil {
-		return fmt.Errorf("failed to write JSON data: %v", err)
+
+	return saveMetadataSidecar(filename, opts)
+}
+
+// Save detailed checkpoint data to JSON, returning the files written
+func SaveCheckpointsToJSON(checkpoints []CheckpointData, filename string, opts WriteOptions) ([]string, error) {
+	// Without a field selection the struct is written as is
+	var fields []checkpointField
+	if len(opts.Fields) > 0 || opts.HumanTime {
+		var err error
+		if fields, err = checkpointOutputFields(opts, defaultCheckpointJSONFields); err != nil {
+			return nil, err
+		}
+	}
+
+	return saveShards(checkpoints, filename, opts, func(checkpoints []CheckpointData, filename string) error {
+		file, err := createOutputFile(filename, opts)
+		if err != nil {
+			return fmt.Errorf("failed to create JSON file: %w", err)
+		}
+		defer file.discard()
+
+		if len(fields) > 0 {
+			err = writeJSONArray(file, projectCheckpoints(checkpoints, fields), opts)
+		} else {
+			err = writeJSONArray(file, checkpoints, opts)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write JSON data: %w", err)
+		}
+
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to close JSON file: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// A row of a checkpoint Parquet export. Append new columns at the end so
+// existing tables keep reading older files.
+type checkpointParquetRow struct {
+	Digest                   string   `parquet:"digest"`
+	PreviousDigest           *string  `parquet:"previousDigest,optional"`
+	SequenceNumber           int64    `parquet:"sequenceNumber"`
+	TimestampMs              int64    `parquet:"timestampMs,timestamp(millisecond)"`
+	ValidatorSignature       string   `parquet:"validatorSignature"`
+	Transactions             []string `parquet:"transactions,list"`
+	NetworkTotalTransactions int64    `parquet:"networkTotalTransactions"`
+	EventRoot                string   `parquet:"eventRoot"`
+	Epoch                    int64    `parquet:"epoch"`
+	ComputationCost          *int64   `parquet:"computationCost,optional"`
+	StorageCost              *int64   `parquet:"storageCost,optional"`
+	StorageRebate            *int64   `parquet:"storageRebate,optional"`
+	NonRefundableStorageFee  *int64   `parquet:"nonRefundableStorageFee,optional"`
+}
+
+// Save checkpoints to Parquet with a fixed, typed schema, returning the files
+// written. Fields and HumanTime do not apply.
+func SaveCheckpointsToParquet(checkpoints []CheckpointData, filename string, opts WriteOptions) ([]string, error) {
+	return saveShards(checkpoints, filename, opts, func(checkpoints []CheckpointData, filename string) error {
+		rows := make([]checkpointParquetRow, len(checkpoints))
+		for i, cp := range checkpoints {
+			rows[i] = checkpointParquetRow{
+				Digest:                   cp.Digest,
+				PreviousDigest:           nullIfEmpty(cp.PreviousDigest),
+				SequenceNumber:           cp.SequenceNumber,
+				TimestampMs:              cp.TimestampMs,
+				ValidatorSignature:       cp.ValidatorSignature,
+				Transactions:             cp.TransactionDigests,
+				NetworkTotalTransactions: cp.NetworkTotalTransactions,
+				EventRoot:                cp.EventRoot,
+				Epoch:                    cp.Epoch,
+			}
+			if gas := cp.EpochRollingGasCostSummary; gas != nil {
+				rows[i].ComputationCost = &gas.ComputationCost
+				rows[i].StorageCost = &gas.StorageCost
+				rows[i].StorageRebate = &gas.StorageRebate
+				rows[i].NonRefundableStorageFee = &gas.NonRefundableStorageFee
+			}
+		}
+		return saveParquetFile(filename, rows, opts)
+	})
+}
+
+// Orders accepted by SortCheckpoints
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+	SortNone       = "none" // Keep fetch order
+)
+
+// Sort checkpoints in place by sequence number in order: asc, desc or none
+func SortCheckpoints(checkpoints []CheckpointData, order string) error {
+	switch order {
+	case SortAscending:
+		sort.SliceStable(checkpoints, func(i, j int) bool {
+			return checkpoints[i].SequenceNumber < checkpoints[j].SequenceNumber
+		})
+	case SortDescending:
+		sort.SliceStable(checkpoints, func(i, j int) bool {
+			return checkpoints[i].SequenceNumber > checkpoints[j].SequenceNumber
+		})
+	case SortNone:
+	default:
+		return fmt.Errorf("unknown sort order %q (use asc, desc or none)", order)
 	}
-	
 	return nil
 }
 
@@ -319,84 +777,46 @@ func ParseCheckpointRange(rangeStr string) (int, int, error) {
 	if rangeStr == "" {
 		return 0, 0, fmt.Errorf("checkpoint range is required")
 	}
-	
+
 	parts := strings.Split(rangeStr, "-")
 	if len(parts) != 2 {
 		return 0, 0, fmt.Errorf("invalid range format, expected 'start-end'")
 	}
-	
+
 	start, err := strconv.Atoi(parts[0])
 	if err != nil {
-		return 0, 0, fmt.Errorf("invalid start checkpoint: %v", err)
+		return 0, 0, fmt.Errorf("invalid start checkpoint: %w", err)
 	}
-	
+
 	end, err := strconv.Atoi(parts[1])
 	if err != nil {
-		return 0, 0, fmt.Errorf("invalid end checkpoint: %v", err)
+		return 0, 0, fmt.Errorf("invalid end checkpoint: %w", err)
 	}
-	
+
 	return start, end, nil
 }
 
-func main() {
-	// CLI flags
-	checkpointRange := flag.String("range", "", "Checkpoint range (e.g., 1000-2000), use '0-0' for latest only")
-	startCheckpoint := flag.Int("start", -1, "Starting checkpoint number")
-	endCheckpoint := flag.Int("end", -1, "Ending checkpoint number (0 for latest)")
-	batchSize := flag.Int("batch", 10, "Number of checkpoints per batch")
-	outputFile := flag.String("output", "checkpoints.csv", "Output filename")
-	outputFormat := flag.String("format", "csv", "Output format (csv or json)")
-	flag.Parse()
-	
-	var start, end int
-	var err error
-	
-	// Parse parameters
-	if *checkpointRange != "" {
-		start, end, err = ParseCheckpointRange(*checkpointRange)
-		if err != nil {
-			log.Fatalf("Error parsing checkpoint range: %v", err)
+// Check that checkpoints, sorted by sequence number, form an unbroken chain:
+// sequence numbers are consecutive and each checkpoint's PreviousDigest is
+// the digest of the one before it. Returns a *ChainBreakError at the first break.
+func VerifyChain(checkpoints []CheckpointData) error {
+	for i := 1; i < len(checkpoints); i++ {
+		prev, cur := checkpoints[i-1], checkpoints[i]
+
+		if cur.SequenceNumber != prev.SequenceNumber+1 {
+			return &ChainBreakError{
+				SequenceNumber: cur.SequenceNumber,
+				Reason:         fmt.Sprintf("follows checkpoint %d, expected %d", prev.SequenceNumber, prev.SequenceNumber+1),
+			}
+		}
+
+		if cur.PreviousDigest != prev.Digest {
+			return &ChainBreakError{
+				SequenceNumber: cur.SequenceNumber,
+				Reason:         fmt.Sprintf("previousDigest %q does not match digest %q of checkpoint %d", cur.PreviousDigest, prev.Digest, prev.SequenceNumber),
+			}
 		}
-	} else {
-		start = *startCheckpoint
-		end = *endCheckpoint
-	}
-	
-	if start < 0 {
-		log.Fatalf("Starting checkpoint must be specified")
-	}
-	
-	startTime := time.Now()
-	fmt.Println("Starting checkpoint fetching...")
-	
-	// Fetch checkpoints
-	checkpoints, err :=FetchCheckpointRange(start, end, *batchSize)
-	if err != nil {
-		log.Fatalf("Failed to fetch checkpoints: %v", err)
-	}
-	
-	elapsedTime := time.Since(startTime)
-	
-	if len(checkpoints) == 0 {
-		fmt.Println("No checkpoints fetched!")
-		return
-	}
-	
-	fmt.Printf("Fetched a total of %d checkpoints in %s\n", len(checkpoints), elapsedTime)
-	fmt.Printf("Saving checkpoints to %s file...\n", *outputFormat)
-	
-	// Save to output file
-	if *outputFormat == "csv" {
-		err = SaveCheckpointsToCSV(checkpoints, *outputFile)
-	} else if *outputFormat == "json" {
-		err = SaveCheckpointsToJSON(checkpoints, *outputFile)
-	} else {
-		log.Fatalf("Unsupported output format: %s", *outputFormat)
-	}
-	
-	if err != nil {
-		log.Fatalf("Failed to save checkpoints: %v", err)
 	}
-	
-	fmt.Printf("Done! %d checkpoints saved to %s 🎉\n", len(checkpoints), *outputFile)
-}
\ No newline at end of file
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package suitrace
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A selectable checkpoint output field: its name in -fields and JSON, its
+// CSV header, how to render it, and how to read a CSV cell back
+type checkpointField struct {
+	name   string
+	header string
+	csv    func(CheckpointData) string
+	json   func(CheckpointData) interface{}
+	parse  func(*CheckpointData, string) error
+}
+
+var checkpointFields = []checkpointField{
+	{
+		name:   "digest",
+		header: "Digest",
+		csv:    func(cp CheckpointData) string { return cp.Digest },
+		json:   func(cp CheckpointData) interface{} { return cp.Digest },
+		parse:  func(cp *CheckpointData, s string) error { cp.Digest = s; return nil },
+	},
+	{
+		name:   "previousDigest",
+		header: "PreviousDigest",
+		csv:    func(cp CheckpointData) string { return cp.PreviousDigest },
+		json:   func(cp CheckpointData) interface{} { return cp.PreviousDigest },
+		parse:  func(cp *CheckpointData, s string) error { cp.PreviousDigest = s; return nil },
+	},
+	{
+		name:   "sequenceNumber",
+		header: "SequenceNumber",
+		csv:    func(cp CheckpointData) string { return strconv.FormatInt(cp.SequenceNumber, 10) },
+		json:   func(cp CheckpointData) interface{} { return strconv.FormatInt(cp.SequenceNumber, 10) },
+		parse: func(cp *CheckpointData, s string) (err error) {
+			cp.SequenceNumber, err = strconv.ParseInt(s, 10, 64)
+			return err
+		},
+	},
+	{
+		name:   "epoch",
+		header: "Epoch",
+		csv:    func(cp CheckpointData) string { return strconv.FormatInt(cp.Epoch, 10) },
+		json:   func(cp CheckpointData) interface{} { return strconv.FormatInt(cp.Epoch, 10) },
+		parse: func(cp *CheckpointData, s string) (err error) {
+			cp.Epoch, err = strconv.ParseInt(s, 10, 64)
+			return err
+		},
+	},
+	{
+		name:   "timestampMs",
+		header: "TimestampMs",
+		csv:    func(cp CheckpointData) string { return strconv.FormatInt(cp.TimestampMs, 10) },
+		json:   func(cp CheckpointData) interface{} { return strconv.FormatInt(cp.TimestampMs, 10) },
+		parse: func(cp *CheckpointData, s string) (err error) {
+			cp.TimestampMs, err = strconv.ParseInt(s, 10, 64)
+			return err
+		},
+	},
+	{
+		name:   "timestamp",
+		header: "Timestamp",
+		csv:    func(cp CheckpointData) string { return FormatMillis(cp.TimestampMs) },
+		json:   func(cp CheckpointData) interface{} { return FormatMillis(cp.TimestampMs) },
+		parse: func(cp *CheckpointData, s string) error {
+			// Only fills in TimestampMs when that column is missing
+			if s == "" || cp.TimestampMs != 0 {
+				return nil
+			}
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return err
+			}
+			cp.TimestampMs = t.UnixMilli()
+			return nil
+		},
+	},
+	{
+		name:   "validatorSignature",
+		header: "ValidatorSignature",
+		csv:    func(cp CheckpointData) string { return cp.ValidatorSignature },
+		json:   func(cp CheckpointData) interface{} { return cp.ValidatorSignature },
+		parse:  func(cp *CheckpointData, s string) error { cp.ValidatorSignature = s; return nil },
+	},
+	{
+		name:   "transactions",
+		header: "Transactions",
+		csv:    func(cp CheckpointData) string { return strings.Join(cp.TransactionDigests, " ") },
+		json:   func(cp CheckpointData) interface{} { return cp.TransactionDigests },
+		parse: func(cp *CheckpointData, s string) error {
+			cp.TransactionDigests = strings.Fields(s)
+			return nil
+		},
+	},
+	{
+		name:   "transactionCount",
+		header: "TransactionCount",
+		csv:    func(cp CheckpointData) string { return strconv.Itoa(len(cp.TransactionDigests)) },
+		json:   func(cp CheckpointData) interface{} { return len(cp.TransactionDigests) },
+		parse: func(cp *CheckpointData, s string) error {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return err
+			}
+			// The digests are not in the file; keep the count as placeholders
+			if cp.TransactionDigests == nil {
+				cp.TransactionDigests = make([]string, n)
+			}
+			return nil
+		},
+	},
+	{
+		name:   "networkTotalTransactions",
+		header: "NetworkTotalTransactions",
+		csv:    func(cp CheckpointData) string { return strconv.FormatInt(cp.NetworkTotalTransactions, 10) },
+		json:   func(cp CheckpointData) interface{} { return strconv.FormatInt(cp.NetworkTotalTransactions, 10) },
+		parse: func(cp *CheckpointData, s string) (err error) {
+			cp.NetworkTotalTransactions, err = strconv.ParseInt(s, 10, 64)
+			return err
+		},
+	},
+	{
+		name:   "eventRoot",
+		header: "EventRoot",
+		csv:    func(cp CheckpointData) string { return cp.EventRoot },
+		json:   func(cp CheckpointData) interface{} { return cp.EventRoot },
+		parse:  func(cp *CheckpointData, s string) error { cp.EventRoot = s; return nil },
+	},
+	{
+		name:   "epochRollingGasCostSummary",
+		header: "EpochRollingGasCostSummary",
+		csv: func(cp CheckpointData) string {
+			if cp.EpochRollingGasCostSummary == nil {
+				return ""
+			}
+			data, _ := json.Marshal(cp.EpochRollingGasCostSummary)
+			return string(data)
+		},
+		json: func(cp CheckpointData) interface{} { return cp.EpochRollingGasCostSummary },
+		parse: func(cp *CheckpointData, s string) error {
+			if s == "" {
+				return nil
+			}
+			return json.Unmarshal([]byte(s), &cp.EpochRollingGasCostSummary)
+		},
+	},
+	gasCostField("computationCost", "ComputationCost", func(g *GasCostSummary) *int64 { return &g.ComputationCost }),
+	gasCostField("storageCost", "StorageCost", func(g *GasCostSummary) *int64 { return &g.StorageCost }),
+	gasCostField("storageRebate", "StorageRebate", func(g *GasCostSummary) *int64 { return &g.StorageRebate }),
+	gasCostField("nonRefundableStorageFee", "NonRefundableStorageFee", func(g *GasCostSummary) *int64 { return &g.NonRefundableStorageFee }),
+}
+
+// A column holding one amount of the epoch's rolling gas cost summary,
+// empty when the checkpoint has none
+func gasCostField(name, header string, amount func(*GasCostSummary) *int64) checkpointField {
+	return checkpointField{
+		name:   name,
+		header: header,
+		csv: func(cp CheckpointData) string {
+			if cp.EpochRollingGasCostSummary == nil {
+				return ""
+			}
+			return strconv.FormatInt(*amount(cp.EpochRollingGasCostSummary), 10)
+		},
+		json: func(cp CheckpointData) interface{} {
+			if cp.EpochRollingGasCostSummary == nil {
+				return nil
+			}
+			return strconv.FormatInt(*amount(cp.EpochRollingGasCostSummary), 10)
+		},
+		parse: func(cp *CheckpointData, s string) error {
+			if s == "" {
+				return nil
+			}
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return err
+			}
+			if cp.EpochRollingGasCostSummary == nil {
+				cp.EpochRollingGasCostSummary = &GasCostSummary{}
+			}
+			*amount(cp.EpochRollingGasCostSummary) = n
+			return nil
+		},
+	}
+}
+
+// CSV columns written when no fields are selected
+var defaultCheckpointCSVFields = []string{
+	"digest",
+	"sequenceNumber",
+	"timestampMs",
+	"transactionCount",
+	"networkTotalTransactions",
+	"eventRoot",
+	"epoch",
+	"computationCost",
+	"storageCost",
+	"storageRebate",
+	"nonRefundableStorageFee",
+}
+
+// JSON keys of CheckpointData, used when -human-time needs an explicit field list
+var defaultCheckpointJSONFields = []string{
+	"digest",
+	"previousDigest",
+	"sequenceNumber",
+	"epoch",
+	"timestampMs",
+	"validatorSignature",
+	"transactions",
+	"networkTotalTransactions",
+	"eventRoot",
+	"epochRollingGasCostSummary",
+}
+
+// Fields to write: the selected ones, or the defaults plus a human-readable
+// timestamp when requested
+func checkpointOutputFields(opts WriteOptions, defaults []string) ([]checkpointField, error) {
+	if len(opts.Fields) > 0 {
+		return selectCheckpointFields(opts.Fields)
+	}
+	if opts.HumanTime {
+		return selectCheckpointFields(append(append([]string{}, defaults...), "timestamp"))
+	}
+	return selectCheckpointFields(defaults)
+}
+
+// Names accepted by WriteOptions.Fields for checkpoint output
+func CheckpointFieldNames() []string {
+	names := make([]string, len(checkpointFields))
+	for i, field := range checkpointFields {
+		names[i] = field.name
+	}
+	return names
+}
+
+// Check that every name is a known checkpoint field
+func ValidateCheckpointFields(names []string) error {
+	_, err := selectCheckpointFields(names)
+	return err
+}
+
+// Look up the named fields, in the order given
+func selectCheckpointFields(names []string) ([]checkpointField, error) {
+	byName := make(map[string]checkpointField, len(checkpointFields))
+	for _, field := range checkpointFields {
+		byName[field.name] = field
+	}
+
+	selected := make([]checkpointField, 0, len(names))
+	for _, name := range names {
+		field, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown checkpoint field %q (known fields: %s)", name, strings.Join(CheckpointFieldNames(), ", "))
+		}
+		selected = append(selected, field)
+	}
+	return selected, nil
+}
+
+// Render checkpoints as JSON objects holding only the selected fields, in order
+func projectCheckpoints(checkpoints []CheckpointData, fields []checkpointField) []orderedObject {
+	projected := make([]orderedObject, len(checkpoints))
+	for i, checkpoint := range checkpoints {
+		obj := orderedObject{}
+		for _, field := range fields {
+			obj.keys = append(obj.keys, field.name)
+			obj.values = append(obj.values, field.json(checkpoint))
+		}
+		projected[i] = obj
+	}
+	return projected
+}
@@ -0,0 +1,59 @@
+package suitrace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Progress of an incremental checkpoint export, kept between runs
+type CheckpointState struct {
+	LastSequenceNumber int64 `json:"lastSequenceNumber,string"` // Highest checkpoint written so far
+}
+
+// Read the state file. A missing file returns ok == false and no error, which
+// means this is the first run.
+func ReadCheckpointState(filename string) (state CheckpointState, ok bool, err error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return CheckpointState{}, false, nil
+	}
+	if err != nil {
+		return CheckpointState{}, false, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return CheckpointState{}, false, fmt.Errorf("failed to parse state file %s: %w", filename, err)
+	}
+	return state, true, nil
+}
+
+// Write the state file. It is written to a temporary file and renamed into
+// place, so an interrupted run never leaves a half-written state behind.
+func WriteCheckpointState(filename string, state CheckpointState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close state file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package suitrace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointStateRoundTrip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.json")
+
+	if _, ok, err := ReadCheckpointState(filename); ok || err != nil {
+		t.Fatalf("missing file: ok = %v, err = %v, want first run", ok, err)
+	}
+
+	for _, seq := range []int64{120000000, 120000500} {
+		if err := WriteCheckpointState(filename, CheckpointState{LastSequenceNumber: seq}); err != nil {
+			t.Fatalf("WriteCheckpointState failed: %v", err)
+		}
+		state, ok, err := ReadCheckpointState(filename)
+		if err != nil || !ok || state.LastSequenceNumber != seq {
+			t.Fatalf("read back %+v, %v, %v, want %d", state, ok, err, seq)
+		}
+	}
+
+	// Only the state file is left behind
+	entries, _ := os.ReadDir(filepath.Dir(filename))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want just the state file", len(entries))
+	}
+
+	if err := os.WriteFile(filename, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadCheckpointState(filename); err == nil {
+		t.Error("expected an error for a corrupt state file")
+	}
+}
@@ -0,0 +1,558 @@
+package suitrace
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchCheckpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    mockResponse
+		want    CheckpointData
+		wantErr string
+	}{
+		{
+			name: "recorded checkpoint",
+			resp: fixture(t, "checkpoint.json"),
+			want: CheckpointData{
+				Digest:                   "9nHkFAmUN3dUr3vbnwPGJw7jF8wjD7X6p9vB6NfWKuoa",
+				PreviousDigest:           "4xaJ8vJQjF4xG2n3cX9m9XhxPTX6XBt4sZdfwd7vX1pr",
+				SequenceNumber:           120000000,
+				Epoch:                    512,
+				TimestampMs:              1734562800123,
+				ValidatorSignature:       "qkGx3H6Bc7qJbz7VSuEXG1y3lf1p0fZ5C1kGQcd8vJyzv0K3Qy2tX3A7ZQ8v5m1T",
+				NetworkTotalTransactions: 3184735510,
+				EpochRollingGasCostSummary: &GasCostSummary{
+					ComputationCost:         481250000000,
+					StorageCost:             1288924000000,
+					StorageRebate:           1107281656000,
+					NonRefundableStorageFee: 11184663192,
+				},
+				TransactionDigests: []string{
+					"6Uw2x5rJ6C1pS9wWJ3hHkcb5mXYeZB1z8DzqT4tYg7rN",
+					"FzLKBmvNK4m2Zr5qJ1v3xhQzR8cXGy6o5dEwPb9aTfUs",
+				},
+			},
+		},
+		{
+			name:    "API error",
+			resp:    fixture(t, "rpc_error.json"),
+			wantErr: "API error",
+		},
+		{
+			name:    "malformed JSON",
+			resp:    mockResponse{Raw: `{"jsonrpc":"2.0","result":{"digest":`},
+			wantErr: "failed to unmarshal response",
+		},
+		{
+			name:    "missing sequence number",
+			resp:    mockResponse{Result: map[string]interface{}{"digest": "abc"}},
+			wantErr: "malformed checkpoint 120000000",
+		},
+		{
+			name:    "missing digest",
+			resp:    mockResponse{Result: map[string]interface{}{"sequenceNumber": "120000000"}},
+			wantErr: "missing digest",
+		},
+		{
+			name:    "wrong sequence number",
+			resp:    mockResponse{Result: map[string]interface{}{"digest": "abc", "sequenceNumber": "7"}},
+			wantErr: "got sequence number 7",
+		},
+		{
+			name: "only required fields",
+			resp: mockResponse{Result: map[string]interface{}{"digest": "abc", "sequenceNumber": "120000000"}},
+			want: CheckpointData{Digest: "abc", SequenceNumber: 120000000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotParams []interface{}
+			client := newTestClient(t, map[string]mockHandler{
+				"sui_getCheckpoint": func(params []interface{}) mockResponse {
+					gotParams = params
+					return tt.resp
+				},
+			})
+
+			got, err := client.FetchCheckpoint(120000000)
+			if len(gotParams) != 1 || gotParams[0] != "120000000" {
+				t.Errorf("params = %v, want [\"120000000\"]", gotParams)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Digest != tt.want.Digest ||
+				got.PreviousDigest != tt.want.PreviousDigest ||
+				got.SequenceNumber != tt.want.SequenceNumber ||
+				got.TimestampMs != tt.want.TimestampMs ||
+				got.ValidatorSignature != tt.want.ValidatorSignature ||
+				got.NetworkTotalTransactions != tt.want.NetworkTotalTransactions ||
+				got.Epoch != tt.want.Epoch {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+			if !reflect.DeepEqual(got.EpochRollingGasCostSummary, tt.want.EpochRollingGasCostSummary) {
+				t.Errorf("EpochRollingGasCostSummary = %+v, want %+v", got.EpochRollingGasCostSummary, tt.want.EpochRollingGasCostSummary)
+			}
+			if strings.Join(got.TransactionDigests, ",") != strings.Join(tt.want.TransactionDigests, ",") {
+				t.Errorf("TransactionDigests = %v, want %v", got.TransactionDigests, tt.want.TransactionDigests)
+			}
+		})
+	}
+}
+
+// Serve sui_getCheckpoints pages over sequences 0..head, leaving out any in skip
+func checkpointPages(head int, skip map[int]bool, calls *int) mockHandler {
+	return func(params []interface{}) mockResponse {
+		*calls++
+		next := 0
+		if cursor, ok := params[0].(string); ok {
+			n, _ := strconv.Atoi(cursor)
+			next = n + 1
+		}
+		limit := int(params[1].(float64))
+
+		data := []interface{}{}
+		last := next
+		for seq := next; seq < next+limit && seq <= head; seq++ {
+			last = seq
+			if skip[seq] {
+				continue
+			}
+			data = append(data, map[string]interface{}{
+				"digest":         "digest-" + strconv.Itoa(seq),
+				"sequenceNumber": strconv.Itoa(seq),
+			})
+		}
+		return mockResponse{Result: map[string]interface{}{
+			"data":        data,
+			"nextCursor":  strconv.Itoa(last),
+			"hasNextPage": last < head,
+		}}
+	}
+}
+
+func TestFetchCheckpointRangeResumesAfterMidBatchFailure(t *testing.T) {
+	oldRetry, oldBatch := retryDelay, batchDelay
+	retryDelay, batchDelay = 0, 0
+	defer func() { retryDelay, batchDelay = oldRetry, oldBatch }()
+
+	// The page leaves a gap at 12, and filling it fails exactly once
+	const failAt = 12
+	failed := false
+	pageCalls := 0
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getCheckpoints": checkpointPages(100, map[int]bool{failAt: true}, &pageCalls),
+		"sui_getCheckpoint": func(params []interface{}) mockResponse {
+			seq := params[0].(string)
+			if seq == strconv.Itoa(failAt) && !failed {
+				failed = true
+				return mockResponse{Error: map[string]interface{}{"code": -32000, "message": "transient"}}
+			}
+			return mockResponse{Result: map[string]interface{}{
+				"digest":         "digest-" + seq,
+				"sequenceNumber": seq,
+			}}
+		},
+	})
+
+	checkpoints, err := client.FetchCheckpointRange(0, 24, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !failed {
+		t.Fatal("injected failure was never triggered")
+	}
+	if len(checkpoints) != 25 {
+		t.Fatalf("got %d checkpoints, want 25", len(checkpoints))
+	}
+	for i, cp := range checkpoints {
+		if cp.SequenceNumber != int64(i) {
+			t.Fatalf("checkpoints[%d].SequenceNumber = %d, want %d (duplicate or gap)", i, cp.SequenceNumber, i)
+		}
+	}
+}
+
+func TestFetchCheckpointRangeUsesPages(t *testing.T) {
+	oldBatch := batchDelay
+	batchDelay = 0
+	defer func() { batchDelay = oldBatch }()
+
+	pageCalls := 0
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getCheckpoints": checkpointPages(1000, nil, &pageCalls),
+	})
+
+	checkpoints, err := client.FetchCheckpointRange(50, 349, 500)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(checkpoints) != 300 || checkpoints[0].SequenceNumber != 50 || checkpoints[299].SequenceNumber != 349 {
+		t.Fatalf("got %d checkpoints from %d", len(checkpoints), checkpoints[0].SequenceNumber)
+	}
+	// Batch size is capped at the server page size
+	if pageCalls != 3 {
+		t.Errorf("sui_getCheckpoints called %d times, want 3", pageCalls)
+	}
+}
+
+func TestFetchCheckpointsPaged(t *testing.T) {
+	var gotParams []interface{}
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getCheckpoints": func(params []interface{}) mockResponse {
+			gotParams = params
+			return mockResponse{Result: map[string]interface{}{
+				"data": []interface{}{
+					map[string]interface{}{"digest": "a", "sequenceNumber": "8"},
+					map[string]interface{}{"digest": "b", "sequenceNumber": "7"},
+				},
+				"nextCursor":  "7",
+				"hasNextPage": true,
+			}}
+		},
+	})
+
+	checkpoints, next, hasNext, err := client.FetchCheckpointsPaged("9", 2, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotParams[0] != "9" || gotParams[1] != float64(2) || gotParams[2] != true {
+		t.Errorf("params = %v, want [\"9\" 2 true]", gotParams)
+	}
+	if len(checkpoints) != 2 || checkpoints[0].SequenceNumber != 8 || checkpoints[1].Digest != "b" {
+		t.Errorf("checkpoints = %+v", checkpoints)
+	}
+	if next != "7" || !hasNext {
+		t.Errorf("next = %q, hasNext = %v", next, hasNext)
+	}
+
+	if _, _, _, err := client.FetchCheckpointsPaged("", 0, false); err != nil {
+		t.Errorf("unexpected error for empty cursor: %v", err)
+	}
+	if gotParams[0] != nil {
+		t.Errorf("empty cursor should be sent as null, got %v", gotParams[0])
+	}
+}
+
+func TestFetchCheckpointRangeRetriesOnlyTransientErrors(t *testing.T) {
+	oldRetry, oldBatch := retryDelay, batchDelay
+	retryDelay, batchDelay = 0, 0
+	defer func() { retryDelay, batchDelay = oldRetry, oldBatch }()
+
+	tests := []struct {
+		name      string
+		failure   mockResponse
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "rate limited",
+			failure:   mockResponse{Status: 429, Raw: "Too Many Requests"},
+			wantCalls: 2,
+		},
+		{
+			name:      "server error",
+			failure:   mockResponse{Status: 503, Raw: "unavailable"},
+			wantCalls: 2,
+		},
+		{
+			name:      "invalid params",
+			failure:   fixture(t, "rpc_error.json"),
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "bad request",
+			failure:   mockResponse{Status: 400, Raw: "bad request"},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			pages := checkpointPages(100, nil, new(int))
+			client := newTestClient(t, map[string]mockHandler{
+				"sui_getCheckpoints": func(params []interface{}) mockResponse {
+					calls++
+					if calls == 1 {
+						return tt.failure
+					}
+					return pages(params)
+				},
+			})
+
+			checkpoints, err := client.FetchCheckpointRange(0, 4, 10)
+			if calls != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "not retryable") {
+					t.Fatalf("err = %v, want a non-retryable error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(checkpoints) != 5 {
+				t.Errorf("got %d checkpoints, want 5", len(checkpoints))
+			}
+		})
+	}
+}
+
+// Backend serving checkpoints 0-100 except those in failing, which always
+// fail with their error; methods it does not override panic
+type flakyCheckpointBackend struct {
+	Backend
+	failing map[int64]error
+}
+
+func (b *flakyCheckpointBackend) LatestSequenceNumber(ctx context.Context) (int64, error) {
+	return 100, nil
+}
+
+func (b *flakyCheckpointBackend) GetCheckpoints(ctx context.Context, start, end int64) ([]CheckpointData, error) {
+	checkpoints := []CheckpointData{}
+	for seq := start; seq <= end; seq++ {
+		if err := b.failing[seq]; err != nil {
+			return checkpoints, err
+		}
+		checkpoints = append(checkpoints, CheckpointData{SequenceNumber: seq})
+	}
+	return checkpoints, nil
+}
+
+func TestFetchCheckpointRangeBestEffort(t *testing.T) {
+	oldRetry, oldBatch := retryDelay, batchDelay
+	retryDelay, batchDelay = 0, 0
+	defer func() { retryDelay, batchDelay = oldRetry, oldBatch }()
+
+	b := &flakyCheckpointBackend{failing: map[int64]error{
+		2: &HTTPError{StatusCode: 503},
+		4: &HTTPError{StatusCode: 400},
+	}}
+	opts := CheckpointRangeOptions{BatchSize: 10}
+
+	// Fail-fast stops at the first checkpoint that keeps failing
+	if _, err := FetchCheckpointRangeWithOptions(context.Background(), b, 0, 6, opts); err == nil {
+		t.Fatal("expected fail-fast to return an error")
+	}
+
+	checkpoints, failed, err := FetchCheckpointRangeBestEffort(context.Background(), b, 0, 6, opts)
+	if err != nil {
+		t.Fatalf("FetchCheckpointRangeBestEffort: %v", err)
+	}
+
+	var got []int64
+	for _, checkpoint := range checkpoints {
+		got = append(got, checkpoint.SequenceNumber)
+	}
+	if want := []int64{0, 1, 3, 5, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fetched %v, want %v", got, want)
+	}
+	if len(failed) != 2 || failed[0].Sequence != 2 || failed[1].Sequence != 4 {
+		t.Fatalf("failed = %v, want checkpoints 2 and 4", failed)
+	}
+	var httpErr *HTTPError
+	if !errors.As(failed[1].Err, &httpErr) || httpErr.StatusCode != 400 {
+		t.Errorf("failed[1].Err = %v, want the HTTP 400", failed[1].Err)
+	}
+}
+
+func TestFetchCheckpointRangeSample(t *testing.T) {
+	oldRetry, oldBatch := retryDelay, batchDelay
+	retryDelay, batchDelay = 0, 0
+	defer func() { retryDelay, batchDelay = oldRetry, oldBatch }()
+
+	b := &flakyCheckpointBackend{failing: map[int64]error{
+		30: &HTTPError{StatusCode: 400},
+		31: &HTTPError{StatusCode: 400}, // Not sampled, so never asked for
+	}}
+	checkpoints, failed, err := FetchCheckpointRangeBestEffort(context.Background(), b, 5, 0, CheckpointRangeOptions{BatchSize: 100, Sample: 25})
+	if err != nil {
+		t.Fatalf("FetchCheckpointRangeBestEffort: %v", err)
+	}
+
+	var got []int64
+	for _, checkpoint := range checkpoints {
+		got = append(got, checkpoint.SequenceNumber)
+	}
+	if want := []int64{5, 55, 80}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fetched %v, want %v", got, want)
+	}
+	if len(failed) != 1 || failed[0].Sequence != 30 {
+		t.Errorf("failed = %v, want checkpoint 30", failed)
+	}
+}
+
+func TestFetchCheckpointRangeStopsDuringRetryBackoff(t *testing.T) {
+	oldRetry := retryDelay
+	retryDelay = time.Minute
+	defer func() { retryDelay = oldRetry }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getLatestCheckpointSequenceNumber": respond(mockResponse{Result: "100"}),
+		"sui_getCheckpoints": func(params []interface{}) mockResponse {
+			// Cancel once the fetch has failed and is about to back off
+			time.AfterFunc(50*time.Millisecond, cancel)
+			return mockResponse{Status: 503, Raw: "unavailable"}
+		},
+	})
+
+	start := time.Now()
+	_, err := FetchCheckpointRange(ctx, client, 0, 4, 10)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %s to stop, want it to return promptly", elapsed)
+	}
+}
+
+func TestPlanCheckpointRange(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getLatestCheckpointSequenceNumber": respond(mockResponse{Result: "250"}),
+	})
+
+	plan, err := client.PlanCheckpointRange(10, 0, 500)
+	if err != nil {
+		t.Fatalf("PlanCheckpointRange: %v", err)
+	}
+	want := CheckpointRangePlan{Start: 10, End: 250, BatchSize: MaxCheckpointPageSize, Requests: 3}
+	if plan != want {
+		t.Errorf("got %+v, want %+v", plan, want)
+	}
+
+	if _, err := client.PlanCheckpointRange(300, 0, 10); err == nil {
+		t.Error("expected an error for a start past the latest checkpoint")
+	}
+	if _, err := client.PlanCheckpointRange(1, 5, 0); err == nil {
+		t.Error("expected an error for a zero batch size")
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	chain := []CheckpointData{
+		{SequenceNumber: 10, Digest: "a", PreviousDigest: "z"},
+		{SequenceNumber: 11, Digest: "b", PreviousDigest: "a"},
+		{SequenceNumber: 12, Digest: "c", PreviousDigest: "b"},
+	}
+	if err := VerifyChain(chain); err != nil {
+		t.Fatalf("unexpected error for an unbroken chain: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func([]CheckpointData)
+		wantSeq int64
+	}{
+		{name: "digest mismatch", mutate: func(c []CheckpointData) { c[2].PreviousDigest = "x" }, wantSeq: 12},
+		{name: "gap", mutate: func(c []CheckpointData) { c[1].SequenceNumber = 13 }, wantSeq: 13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broken := append([]CheckpointData{}, chain...)
+			tt.mutate(broken)
+
+			var breakErr *ChainBreakError
+			if err := VerifyChain(broken); !errors.As(err, &breakErr) {
+				t.Fatalf("got %v, want a ChainBreakError", err)
+			}
+			if breakErr.SequenceNumber != tt.wantSeq {
+				t.Errorf("break reported at %d, want %d", breakErr.SequenceNumber, tt.wantSeq)
+			}
+		})
+	}
+}
+
+func TestCountCheckpointRange(t *testing.T) {
+	var fetched []string
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getLatestCheckpointSequenceNumber": respond(mockResponse{Result: "50"}),
+		"sui_getCheckpoint": func(params []interface{}) mockResponse {
+			seq, _ := strconv.Atoi(params[0].(string))
+			fetched = append(fetched, params[0].(string))
+			return mockResponse{Result: map[string]interface{}{
+				"digest":                   "digest-" + params[0].(string),
+				"sequenceNumber":           params[0],
+				"networkTotalTransactions": strconv.Itoa(seq * 3),
+			}}
+		},
+	})
+
+	tests := []struct {
+		start, end int
+		want       CheckpointRangeCount
+		fetched    string
+	}{
+		{10, 20, CheckpointRangeCount{Start: 10, End: 20, Checkpoints: 11, Transactions: 33}, "20,9"},
+		{0, 4, CheckpointRangeCount{Start: 0, End: 4, Checkpoints: 5, Transactions: 12}, "4"},
+		{45, 0, CheckpointRangeCount{Start: 45, End: 50, Checkpoints: 6, Transactions: 18}, "50,44"},
+	}
+	for _, tt := range tests {
+		fetched = nil
+		got, err := client.CountCheckpointRange(tt.start, tt.end)
+		if err != nil {
+			t.Fatalf("CountCheckpointRange(%d, %d): %v", tt.start, tt.end, err)
+		}
+		if got != tt.want {
+			t.Errorf("CountCheckpointRange(%d, %d) = %+v, want %+v", tt.start, tt.end, got, tt.want)
+		}
+		if strings.Join(fetched, ",") != tt.fetched {
+			t.Errorf("fetched checkpoints %v, want %s", fetched, tt.fetched)
+		}
+	}
+}
+
+func TestSortCheckpoints(t *testing.T) {
+	sequences := func(checkpoints []CheckpointData) []int64 {
+		seqs := []int64{}
+		for _, c := range checkpoints {
+			seqs = append(seqs, c.SequenceNumber)
+		}
+		return seqs
+	}
+
+	tests := []struct {
+		order string
+		want  []int64
+	}{
+		{SortAscending, []int64{1, 2, 3, 7}},
+		{SortDescending, []int64{7, 3, 2, 1}},
+		{SortNone, []int64{3, 1, 7, 2}},
+	}
+	for _, tt := range tests {
+		checkpoints := []CheckpointData{{SequenceNumber: 3}, {SequenceNumber: 1}, {SequenceNumber: 7}, {SequenceNumber: 2}}
+		if err := SortCheckpoints(checkpoints, tt.order); err != nil {
+			t.Fatalf("SortCheckpoints(%s): %v", tt.order, err)
+		}
+		if got := sequences(checkpoints); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SortCheckpoints(%s) = %v, want %v", tt.order, got, tt.want)
+		}
+	}
+
+	if err := SortCheckpoints(nil, "newest"); err == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
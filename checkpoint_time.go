@@ -0,0 +1,105 @@
+package suitrace
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Resolve a wall-clock window to checkpoints over JSON-RPC
+func (c *Client) CheckpointRangeForTime(after, before time.Time) (int64, int64, error) {
+	return CheckpointRangeForTime(context.Background(), c, after, before)
+}
+
+// Resolve a wall-clock window to the checkpoints inside it: the first
+// checkpoint at or after after, through the last checkpoint before before.
+// A zero time leaves that side unbounded. Checkpoint timestamps increase with
+// the sequence number, so each bound is found by binary search.
+func CheckpointRangeForTime(ctx context.Context, b Backend, after, before time.Time) (int64, int64, error) {
+	latest, err := b.LatestSequenceNumber(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch latest checkpoint: %w", err)
+	}
+
+	// Both searches share the checkpoints they fetch
+	timestamps := map[int64]int64{}
+
+	start := int64(0)
+	if !after.IsZero() {
+		start, err = searchCheckpointTime(ctx, b, after, 0, latest, timestamps)
+		if err != nil {
+			return 0, 0, err
+		}
+		if start > latest {
+			return 0, 0, fmt.Errorf("no checkpoints at or after %s", after.Format(time.RFC3339))
+		}
+	}
+
+	end := latest
+	if !before.IsZero() {
+		first, err := searchCheckpointTime(ctx, b, before, start, latest, timestamps)
+		if err != nil {
+			return 0, 0, err
+		}
+		end = first - 1
+	}
+
+	if end < start {
+		return 0, 0, fmt.Errorf("no checkpoints between %s and %s", after.Format(time.RFC3339), before.Format(time.RFC3339))
+	}
+
+	return start, end, nil
+}
+
+// Find the first checkpoint at or after t over JSON-RPC
+func (c *Client) FindCheckpointByTime(t time.Time) (int64, error) {
+	return FindCheckpointByTime(context.Background(), c, t)
+}
+
+// Return the first checkpoint whose timestamp is at or after t. The search
+// makes about log2(latest)+2 RPC calls.
+func FindCheckpointByTime(ctx context.Context, b Backend, t time.Time) (int64, error) {
+	latest, err := b.LatestSequenceNumber(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch latest checkpoint: %w", err)
+	}
+
+	seq, err := searchCheckpointTime(ctx, b, t, 0, latest, map[int64]int64{})
+	if err != nil {
+		return 0, err
+	}
+	if seq > latest {
+		return 0, fmt.Errorf("no checkpoint at or after %s", t.Format(time.RFC3339))
+	}
+
+	return seq, nil
+}
+
+// Find the first checkpoint in [lo, hi] whose timestamp is at or after t, or
+// hi+1 when every checkpoint in the span is older. Fetched timestamps are
+// kept in timestamps so later searches can reuse them.
+func searchCheckpointTime(ctx context.Context, b Backend, t time.Time, lo, hi int64, timestamps map[int64]int64) (int64, error) {
+	target := t.UnixMilli()
+
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+
+		timestamp, ok := timestamps[mid]
+		if !ok {
+			checkpoint, err := b.GetCheckpoint(ctx, mid)
+			if err != nil {
+				return 0, fmt.Errorf("failed to fetch checkpoint %d: %w", mid, err)
+			}
+			timestamp = checkpoint.TimestampMs
+			timestamps[mid] = timestamp
+		}
+
+		if timestamp >= target {
+			hi = mid - 1
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	return lo, nil
+}
@@ -0,0 +1,113 @@
+package suitrace
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Serve checkpoints 0..head where checkpoint n was created at base + n seconds
+func timedCheckpoints(head int64, base time.Time) map[string]mockHandler {
+	return map[string]mockHandler{
+		"sui_getLatestCheckpointSequenceNumber": respond(mockResponse{Result: strconv.FormatInt(head, 10)}),
+		"sui_getCheckpoint": func(params []interface{}) mockResponse {
+			seq, _ := strconv.ParseInt(params[0].(string), 10, 64)
+			return mockResponse{Result: map[string]interface{}{
+				"digest":         "digest-" + strconv.FormatInt(seq, 10),
+				"sequenceNumber": strconv.FormatInt(seq, 10),
+				"timestampMs":    strconv.FormatInt(base.Add(time.Duration(seq)*time.Second).UnixMilli(), 10),
+			}}
+		},
+	}
+}
+
+func TestCheckpointRangeForTime(t *testing.T) {
+	base := time.Date(2024, 12, 18, 12, 0, 0, 0, time.UTC)
+	client := newTestClient(t, timedCheckpoints(1000, base))
+
+	tests := []struct {
+		name      string
+		after     time.Time
+		before    time.Time
+		wantStart int64
+		wantEnd   int64
+		wantErr   bool
+	}{
+		{name: "window", after: base.Add(100 * time.Second), before: base.Add(200 * time.Second), wantStart: 100, wantEnd: 199},
+		{name: "between checkpoints", after: base.Add(100500 * time.Millisecond), before: base.Add(200500 * time.Millisecond), wantStart: 101, wantEnd: 200},
+		{name: "open end", after: base.Add(990 * time.Second), wantStart: 990, wantEnd: 1000},
+		{name: "open start", before: base.Add(5 * time.Second), wantStart: 0, wantEnd: 4},
+		{name: "after the head", after: base.Add(2000 * time.Second), wantErr: true},
+		{name: "empty window", after: base.Add(10500 * time.Millisecond), before: base.Add(10700 * time.Millisecond), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := client.CheckpointRangeForTime(tt.after, tt.before)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got range %d-%d, want an error", start, end)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("got %d-%d, want %d-%d", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestFindCheckpointByTime(t *testing.T) {
+	base := time.Date(2024, 12, 18, 12, 0, 0, 0, time.UTC)
+	const head = 1 << 20
+
+	// Checkpoints come in groups of four sharing a timestamp, so timestamps are
+	// monotonic but not strictly increasing
+	calls := 0
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getLatestCheckpointSequenceNumber": respond(mockResponse{Result: strconv.Itoa(head)}),
+		"sui_getCheckpoint": func(params []interface{}) mockResponse {
+			calls++
+			seq, _ := strconv.ParseInt(params[0].(string), 10, 64)
+			return mockResponse{Result: map[string]interface{}{
+				"digest":         "digest-" + strconv.FormatInt(seq, 10),
+				"sequenceNumber": strconv.FormatInt(seq, 10),
+				"timestampMs":    strconv.FormatInt(base.Add(time.Duration(seq/4)*time.Second).UnixMilli(), 10),
+			}}
+		},
+	})
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want int64
+	}{
+		{name: "genesis", at: base.Add(-time.Hour), want: 0},
+		{name: "first of a group", at: base.Add(1000 * time.Second), want: 4000},
+		{name: "between groups", at: base.Add(1000500 * time.Millisecond), want: 4004},
+		{name: "head group", at: base.Add(head / 4 * time.Second), want: head},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			got, err := client.FindCheckpointByTime(tt.at)
+			if err != nil {
+				t.Fatalf("FindCheckpointByTime: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+			if calls > 21 {
+				t.Errorf("made %d sui_getCheckpoint calls, want at most log2(head)+1", calls)
+			}
+		})
+	}
+
+	if _, err := client.FindCheckpointByTime(base.Add(time.Duration(head) * time.Second)); err == nil {
+		t.Error("expected an error for a time after the head")
+	}
+}
@@ -0,0 +1,459 @@
+// Package suitrace fetches checkpoints, object histories and events from a
+// Sui JSON-RPC node. The suitrace command in cmd/suitrace wraps it as a CLI.
+package suitrace
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultRPCURL  = "https://rpc.mainnet.sui.io" // Sui mainnet RPC
+	DefaultTimeout = 30 * time.Second
+)
+
+// Client holds the endpoint and HTTP transport used for all RPC calls
+type Client struct {
+	URL          string
+	HTTPClient   *http.Client
+	PollInterval time.Duration   // How often FollowCheckpoints polls for new checkpoints
+	WSURL        string          // WebSocket endpoint for subscriptions, derived from URL when empty
+	Debug        bool            // Print requests and responses
+	RawDir       string          // Save every raw RPC response body in this directory when set
+	ReplayDir    string          // Answer RPC calls from responses saved by RawDir instead of the network
+	Limiter      *rate.Limiter   // Every request waits for a token when set; share one to cap several clients
+	APIKey       string          // Sent as "Authorization: Bearer <APIKey>" when set
+	Header       http.Header     // Extra headers sent with every request, e.g. a provider's API key header
+	Proxy        *url.URL        // Proxy for HTTP and WebSocket traffic, set with SetProxy; nil uses HTTP_PROXY and friends
+	Tracer       *Tracer         // Records per-request timing phases when set
+	Breaker      *CircuitBreaker // Fails requests fast while the endpoint looks down when set; share one across clients
+	HostLimit    *HostLimiter    // Caps the requests in flight to each host when set; share one across clients
+
+	MaxResponseBytes    int64 // Fail with ResponseTooLargeError on longer response bodies; 0 means no limit
+	TransactionPageSize int   // Digests per page when listing an object's transactions; 0 uses DefaultTransactionPageSize
+
+	ChainID string // The endpoint's chain identifier, cached by GetChainIdentifier; set it to skip the lookup
+
+	// Successful responses by endpoint, method and params, answering repeated
+	// requests without the network when set. Safe to share across clients.
+	Cache *ResponseCache
+
+	// Transaction timestamps seen in responses, looked up before asking the
+	// node for one; share it only between clients of the same network. Nil
+	// disables caching.
+	Timestamps *TimestampCache
+
+	ctx context.Context // Context for requests, set by withContext
+}
+
+// Create a client for the given RPC endpoint
+func NewClient(url string) *Client {
+	return &Client{
+		URL:        url,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+		Timestamps: NewTimestampCache(),
+	}
+}
+
+// Shallow copy of the client whose requests are bound to ctx
+func (c *Client) withContext(ctx context.Context) *Client {
+	bound := *c
+	bound.ctx = ctx
+	return &bound
+}
+
+// Wait for d, or until ctx is done, in which case ctx's error is returned
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Parse an http, https, socks5 or socks5h proxy URL
+func ParseProxyURL(s string) (*url.URL, error) {
+	proxy, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", s, err)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https, socks5 or socks5h", s)
+	}
+	if proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", s)
+	}
+	return proxy, nil
+}
+
+// A copy of the default transport that sends everything through proxy
+func proxyTransport(proxy *url.URL) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+	return transport
+}
+
+// Route RPC requests and WebSocket subscriptions through the proxy at
+// proxyURL instead of the one named by the environment
+func (c *Client) SetProxy(proxyURL string) error {
+	proxy, err := ParseProxyURL(proxyURL)
+	if err != nil {
+		return err
+	}
+	c.Proxy = proxy
+	c.HTTPClient.Transport = proxyTransport(proxy)
+	return nil
+}
+
+// Add the User-Agent and the configured credentials to h. Credential values
+// are never printed, not even with Debug.
+func (c *Client) setAuthHeaders(h http.Header) {
+	setAuthHeaders(h, c.APIKey, c.Header)
+}
+
+func setAuthHeaders(h http.Header, apiKey string, extra http.Header) {
+	h.Set("User-Agent", GeneratedBy())
+	if apiKey != "" {
+		h.Set("Authorization", "Bearer "+apiKey)
+	}
+	for name, values := range extra {
+		h.Del(name)
+		for _, value := range values {
+			h.Add(name, value)
+		}
+	}
+}
+
+// Parse a "Name: value" header, as given to the -auth-header flag
+func ParseHeader(s string) (string, string, error) {
+	name, value, ok := strings.Cut(s, ":")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("invalid header %q, want \"Name: value\"", s)
+	}
+	return name, value, nil
+}
+
+// Wait for a request token, or return at once without a limiter. Gives up
+// when ctx is cancelled.
+func waitLimiter(ctx context.Context, limiter *rate.Limiter) error {
+	if limiter == nil {
+		return nil
+	}
+	if err := limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("rate limiter: %w", err)
+	}
+	return nil
+}
+
+// Create a limiter allowing rps requests per second, for Client.Limiter and
+// GraphQLClient.Limiter
+func NewRateLimiter(rps float64) *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(rps), 1)
+}
+
+// Helper function to print debug info
+func (c *Client) DebugPrint(format string, a ...interface{}) {
+	if c.Debug {
+		fmt.Printf("[DEBUG] "+format+"\n", a...)
+	}
+}
+
+// Times a response that is not valid JSON is requested again before the
+// call fails
+const malformedResponseRetries = 2
+
+// Send a JSON-RPC payload and decode the response into v, requesting it
+// again when the body arrives truncated or otherwise malformed
+func (c *Client) call(payload []byte, v interface{}) error {
+	if body, ok := c.Cache.get(c.URL, payload); ok {
+		c.DebugPrint("Answered from the response cache: %s", payload)
+		return c.decodeResponse(bytes.NewReader(body), v)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.send(payload, v)
+		var malformed *MalformedResponseError
+		if !errors.As(err, &malformed) || attempt >= malformedResponseRetries {
+			return err
+		}
+		c.DebugPrint("Malformed response, retrying (%d of %d): %v", attempt+1, malformedResponseRetries, err)
+
+		// Drop whatever the failed decode filled in
+		reflect.ValueOf(v).Elem().SetZero()
+	}
+}
+
+func (c *Client) send(payload []byte, v interface{}) error {
+	resp, err := c.post(payload)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer drainAndClose(resp.Body)
+
+	if c.Cache == nil {
+		return c.decodeResponse(resp.Body, v)
+	}
+
+	// Keep the whole body to cache it once it decodes
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		var tooLarge *ResponseTooLargeError
+		if errors.As(err, &tooLarge) {
+			return err
+		}
+		// Cut short like a body that fails to decode, and retried the same way
+		return &MalformedResponseError{Body: string(body[:min(len(body), 200)]), Err: err}
+	}
+	if err := c.decodeResponse(bytes.NewReader(body), v); err != nil {
+		return err
+	}
+	if err := c.Cache.put(c.URL, payload, body); err != nil {
+		c.DebugPrint("Warning: %v", err)
+	}
+	return nil
+}
+
+// Send a JSON-RPC payload to the configured endpoint
+func (c *Client) post(payload []byte) (*http.Response, error) {
+	if c.ReplayDir != "" {
+		return c.replayResponse(payload)
+	}
+
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := c.Breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	if err := waitLimiter(ctx, c.Limiter); err != nil {
+		return nil, err
+	}
+
+	release, err := c.HostLimit.acquire(ctx, c.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	reqCtx := ctx
+	var timer *requestTimer
+	if c.Tracer != nil {
+		reqCtx, timer = c.Tracer.start(ctx)
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, c.URL, bytes.NewReader(payload))
+	if err != nil {
+		release()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuthHeaders(req.Header)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		release()
+		// Our own cancellation says nothing about the endpoint
+		if ctx.Err() == nil {
+			c.Breaker.record(err)
+		}
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+
+	if timer != nil {
+		resp.Body = c.Tracer.traceBody(resp.Body, timer, func(trace RequestTrace) {
+			c.DebugPrint("Timing: %s", trace)
+		})
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer drainAndClose(resp.Body)
+		preview, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		httpErr := &HTTPError{StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(preview))}
+		c.Breaker.record(httpErr)
+		return nil, httpErr
+	}
+	c.Breaker.record(nil)
+
+	if c.MaxResponseBytes > 0 {
+		resp.Body = limitBody(resp.Body, c.MaxResponseBytes)
+	}
+
+	if c.RawDir == "" {
+		return resp, nil
+	}
+
+	// Keep the exact bytes the node sent before anything parses them
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := c.saveRawResponse(payload, body); err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return resp, nil
+}
+
+// A response body that fails with ResponseTooLargeError once more than limit
+// bytes have been read
+type limitedBody struct {
+	io.Reader
+	body  io.Closer
+	read  int64
+	limit int64
+}
+
+func limitBody(body io.ReadCloser, limit int64) io.ReadCloser {
+	// Read one byte past the limit to tell "exactly limit" from "too long"
+	return &limitedBody{Reader: io.LimitReader(body, limit+1), body: body, limit: limit}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return n - int(b.read-b.limit), &ResponseTooLargeError{Limit: b.limit}
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// Most of a response body that is read and discarded before closing it, so
+// the connection can be reused. Anything longer is cheaper to drop.
+const maxDrainBytes = 64 << 10
+
+// Drain what is left of a response body and close it. Decoding stops at the
+// end of the JSON value, or early on an error, leaving bytes unread.
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, maxDrainBytes))
+	body.Close()
+}
+
+// Decode a JSON-RPC response as it streams in, keeping numbers as
+// json.Number
+func (c *Client) decodeResponse(body io.Reader, v interface{}) error {
+	return decodeJSONBody(body, v, c.Debug, c.DebugPrint)
+}
+
+// Decode a response body as it streams in. Only the start of the body is
+// kept, to explain a decoding failure, unless debug output prints it.
+func decodeJSONBody(body io.Reader, v interface{}, debug bool, debugPrint func(string, ...interface{})) error {
+	var seen bytes.Buffer
+	if debug {
+		body = io.TeeReader(body, &seen)
+	} else {
+		body = io.TeeReader(body, &prefixWriter{buf: &seen, limit: 200})
+	}
+
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+	err := decoder.Decode(v)
+
+	preview := seen.String()
+	if len(preview) > 200 {
+		preview = preview[:200] + "..."
+	}
+	if debug {
+		debugPrint("Received response: %s", preview)
+	}
+
+	if err != nil {
+		var tooLarge *ResponseTooLargeError
+		if errors.As(err, &tooLarge) {
+			return err
+		}
+		return &MalformedResponseError{Body: preview, Err: err}
+	}
+	return nil
+}
+
+// A writer that keeps the first limit bytes written to it and drops the rest
+type prefixWriter struct {
+	buf   *bytes.Buffer
+	limit int
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	if room := w.limit - w.buf.Len(); room > 0 {
+		w.buf.Write(p[:min(room, len(p))])
+	}
+	return len(p), nil
+}
+
+// Name of the file a raw response is saved to: the RPC method plus a hash of
+// its params, so the same request always maps to the same file
+func rawResponseFilename(payload []byte) string {
+	var req struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	json.Unmarshal(payload, &req)
+	if req.Method == "" {
+		req.Method = "unknown"
+	}
+
+	sum := sha256.Sum256(req.Params)
+	return fmt.Sprintf("%s-%s.json", req.Method, hex.EncodeToString(sum[:8]))
+}
+
+// Write a raw response body to RawDir
+func (c *Client) saveRawResponse(payload, body []byte) error {
+	if err := os.MkdirAll(c.RawDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create raw response directory: %w", err)
+	}
+
+	filename := filepath.Join(c.RawDir, rawResponseFilename(payload))
+	if err := os.WriteFile(filename, body, 0o644); err != nil {
+		return fmt.Errorf("failed to save raw response: %w", err)
+	}
+
+	c.DebugPrint("Saved raw response to %s", filename)
+	return nil
+}
+
+// Serve a request from a response previously saved to ReplayDir
+func (c *Client) replayResponse(payload []byte) (*http.Response, error) {
+	filename := filepath.Join(c.ReplayDir, rawResponseFilename(payload))
+	body, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("no saved response for request %s: %w", payload, err)
+	}
+
+	c.DebugPrint("Replayed response from %s", filename)
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	suitrace "github.com/VeerChaurasia/SuiTrace"
+)
+
+func runBalance(client *suitrace.Client, args []string) {
+	fs := flag.NewFlagSet("balance", flag.ExitOnError)
+	owner := fs.String("owner", "", "Address whose coin balances to print")
+	coinType := fs.String("coin-type", "", "Only print the balance of this coin type (e.g. 0x2::sui::SUI)")
+	fs.Parse(args)
+
+	if *owner == "" {
+		fmt.Println("Error: -owner is required")
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var balances []suitrace.Balance
+	if *coinType != "" {
+		balance, err := client.GetBalance(*owner, *coinType)
+		if err != nil {
+			fatalRPC("Failed to fetch balance", err)
+		}
+		balances = append(balances, *balance)
+	} else {
+		var err error
+		balances, err = client.GetAllBalances(*owner)
+		if err != nil {
+			fatalRPC("Failed to fetch balances", err)
+		}
+	}
+
+	if len(balances) == 0 {
+		fmt.Println("No coins found!")
+		return
+	}
+
+	for _, balance := range balances {
+		fmt.Printf("%s  %s  (%d coin objects)\n", balance.CoinType, balance.TotalBalance, balance.CoinObjectCount)
+	}
+}
@@ -0,0 +1,546 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	suitrace "github.com/VeerChaurasia/SuiTrace"
+)
+
+// backend is the RPC or GraphQL source, endpoint its URL for display
+func runCheckpoint(backend suitrace.Backend, endpoint string, args []string) {
+	fs := flag.NewFlagSet("checkpoint", flag.ExitOnError)
+	checkpointRange := fs.String("range", "", "Checkpoint range (e.g., 1000-2000), use '0-0' for latest only, or - to read one range per line from stdin")
+	startCheckpoint := fs.Int("start", -1, "Starting checkpoint number")
+	endCheckpoint := fs.Int("end", -1, "Ending checkpoint number (0 for latest)")
+	after := fs.String("after", "", "Start at the first checkpoint at or after this RFC3339 time (instead of -range/-start)")
+	before := fs.String("before", "", "End at the last checkpoint before this RFC3339 time (instead of -range/-end)")
+	batchSize := fs.Int("batch", suitrace.MaxCheckpointPageSize, "Number of checkpoints per batch (one sui_getCheckpoints call, max 100)")
+	bestEffort := fs.Bool("best-effort", false, "Skip checkpoints that still fail after their retries instead of stopping, then list them and exit non-zero")
+	sample := fs.Int("sample", 0, "Only fetch every Nth checkpoint of the range (e.g. 1000 for 1 in 1000), one request each, for coarse time series (0 for all)")
+	adaptiveBatch := fs.Bool("adaptive-batch", false, "Start with small batches and tune the size to latency and errors, up to -batch")
+	outputFile := fs.String("output", "checkpoints.csv", "Output filename")
+	outputFormat := fs.String("format", "csv", "Output format (csv, json or parquet)")
+	fieldList := fs.String("fields", "", "Comma-separated checkpoint fields to write, in order (e.g. digest,sequenceNumber,timestampMs)")
+	humanTime := fs.Bool("human-time", false, "Add an RFC3339 Timestamp column next to TimestampMs, in the -timezone zone (UTC by default)")
+	sortOrder := fs.String("sort", suitrace.SortAscending, "Order of the written checkpoints by sequence number: asc, desc or none (fetch order, fastest)")
+	compact := fs.Bool("compact", false, "Write JSON without indentation")
+	canonical := fs.Bool("canonical", false, "Write JSON with every object's keys sorted, for storing exports in git and diffing runs")
+	gzipOutput := fs.Bool("gzip", false, "Gzip-compress the output (implied by a .gz filename)")
+	maxFileRows := fs.Int("max-file-rows", 0, "Roll over to a new numbered output file after this many rows (0 for a single file)")
+	expandTransactions := fs.Bool("expand-transactions", false, "Write one row per transaction, with its checkpoint's sequence number and timestamp, instead of one per checkpoint")
+	maxTxPerCheckpoint := fs.Int("max-tx-per-checkpoint", 0, "With -expand-transactions, keep at most this many transactions of each checkpoint (0 for all)")
+	skipLarge := fs.Bool("skip-large-checkpoints", false, "With -max-tx-per-checkpoint, skip checkpoints over the limit entirely instead of keeping their first transactions")
+	txDetails := fs.Bool("tx-details", false, "With -expand-transactions, also fetch each transaction's sender, status and gas used (RPC backend only)")
+	excludeSystem := fs.Bool("exclude-system", false, "With -expand-transactions, drop system transactions (consensus commit prologues, epoch changes, ...); implies -tx-details")
+	byEpoch := fs.Bool("by-epoch", false, "Write one row per epoch, with its checkpoint range, transaction count and gas costs, instead of one per checkpoint (csv or json)")
+	count := fs.Bool("count", false, "Only print how many checkpoints and transactions the range holds, without fetching or saving it")
+	stats := fs.Bool("stats", false, "Print throughput statistics for the fetched checkpoints: transactions per checkpoint, checkpoint intervals and TPS")
+	statsOutput := fs.String("stats-output", "", "Also write the -stats statistics as JSON to this file (implies -stats)")
+	verify := fs.Bool("verify", false, "Check that the fetched checkpoints form an unbroken previousDigest chain")
+	checkSignatures := fs.Bool("check-signatures", false, "Check that each checkpoint's validatorSignature decodes to a well-formed BLS12-381 signature (structure only, not verified against the committee)")
+	dryRun := fs.Bool("dry-run", false, "Validate flags, resolve the range and print the fetch plan without fetching")
+	follow := fs.Bool("follow", false, "After the range, stream new checkpoints to stdout as JSON lines until interrupted")
+	pollInterval := fs.Duration("poll-interval", suitrace.DefaultPollInterval, "How often to poll for new checkpoints with -follow")
+	stateFile := fs.String("state-file", "", "Record the last checkpoint written here, and without -start resume from the one after it")
+	fs.Parse(args)
+
+	var start, end int
+	var err error
+	var ranges [][2]int // Set when several ranges are read from stdin
+
+	// Parse parameters
+	if *checkpointRange == "-" {
+		if *after != "" || *before != "" || *startCheckpoint >= 0 || *endCheckpoint >= 0 {
+			log.Fatalf("-range - cannot be combined with -after, -before, -start or -end")
+		}
+		if *follow || *stateFile != "" {
+			log.Fatalf("-follow and -state-file do not apply to ranges read from stdin")
+		}
+		if ranges, err = readCheckpointRanges(os.Stdin); err != nil {
+			log.Fatalf("Failed to read checkpoint ranges: %v", err)
+		}
+		start, end = ranges[0][0], ranges[0][1]
+	} else if *after != "" || *before != "" {
+		if *checkpointRange != "" || *startCheckpoint >= 0 || *endCheckpoint >= 0 {
+			log.Fatalf("-after/-before cannot be combined with -range, -start or -end")
+		}
+		start, end = resolveTimeRange(backend, *after, *before)
+	} else if *checkpointRange != "" {
+		start, end, err = suitrace.ParseCheckpointRange(*checkpointRange)
+		if err != nil {
+			log.Fatalf("Error parsing checkpoint range: %v", err)
+		}
+	} else if *stateFile != "" && *startCheckpoint < 0 {
+		start, end = resumeFromState(backend, *stateFile, *endCheckpoint)
+	} else {
+		start = *startCheckpoint
+		end = *endCheckpoint
+	}
+
+	if start < 0 {
+		log.Fatalf("Starting checkpoint must be specified")
+	}
+	if ranges == nil {
+		ranges = [][2]int{{start, end}}
+	}
+
+	if *outputFormat != "csv" && *outputFormat != "json" && *outputFormat != "parquet" {
+		log.Fatalf("Unsupported output format: %s", *outputFormat)
+	}
+
+	if *outputFormat == "parquet" && (*fieldList != "" || *humanTime || *gzipOutput) {
+		log.Fatalf("-fields, -human-time and -gzip do not apply to -format=parquet")
+	}
+
+	if *sample < 0 {
+		log.Fatalf("-sample must be >= 0")
+	}
+	if *sample > 1 && (*verify || *follow || *byEpoch || *count || *adaptiveBatch || *stateFile != "") {
+		log.Fatalf("-sample cannot be combined with -verify, -follow, -by-epoch, -count, -adaptive-batch or -state-file")
+	}
+
+	if err := suitrace.SortCheckpoints(nil, *sortOrder); err != nil {
+		log.Fatalf("Invalid -sort: %v", err)
+	}
+
+	if (*txDetails || *excludeSystem) && !*expandTransactions {
+		log.Fatalf("-tx-details and -exclude-system require -expand-transactions")
+	}
+	if *maxTxPerCheckpoint < 0 {
+		log.Fatalf("-max-tx-per-checkpoint must be >= 0")
+	}
+	if (*maxTxPerCheckpoint > 0 || *skipLarge) && !*expandTransactions {
+		log.Fatalf("-max-tx-per-checkpoint and -skip-large-checkpoints require -expand-transactions")
+	}
+	if *skipLarge && *maxTxPerCheckpoint == 0 {
+		log.Fatalf("-skip-large-checkpoints requires -max-tx-per-checkpoint")
+	}
+	if *expandTransactions && *fieldList != "" {
+		log.Fatalf("-fields does not apply to -expand-transactions")
+	}
+	if *byEpoch && (*expandTransactions || *fieldList != "" || *outputFormat == "parquet") {
+		log.Fatalf("-by-epoch cannot be combined with -expand-transactions, -fields or -format=parquet")
+	}
+	var detailClient *suitrace.Client
+	if *txDetails || *excludeSystem {
+		client, ok := backend.(*suitrace.Client)
+		if !ok {
+			log.Fatalf("-tx-details and -exclude-system are only supported with -backend=rpc")
+		}
+		detailClient = client
+	}
+
+	var fields []string
+	if *fieldList != "" {
+		for _, field := range strings.Split(*fieldList, ",") {
+			fields = append(fields, strings.TrimSpace(field))
+		}
+		if err := suitrace.ValidateCheckpointFields(fields); err != nil {
+			log.Fatalf("Invalid -fields: %v", err)
+		}
+	}
+
+	if *dryRun {
+		fmt.Println("Dry run, nothing will be fetched")
+		fmt.Printf("  Endpoint:    %s\n", endpoint)
+		for _, r := range ranges {
+			plan, err := suitrace.PlanCheckpointRange(context.Background(), backend, r[0], r[1], *batchSize)
+			if err != nil {
+				fatalRPC("Invalid checkpoint range", err)
+			}
+
+			fmt.Printf("  Checkpoints: %d to %d (%d checkpoints)\n", plan.Start, plan.End, plan.End-plan.Start+1)
+			if *sample > 1 {
+				samples := (plan.End-plan.Start) / *sample + 1
+				fmt.Printf("  Sampling:    1 in %d, %d checkpoints\n", *sample, samples)
+				fmt.Printf("  Requests:    about %d sui_getCheckpoints calls\n", samples)
+			} else if *adaptiveBatch {
+				fmt.Printf("  Batch size:  adaptive, up to %d\n", plan.BatchSize)
+				fmt.Printf("  Requests:    at least %d sui_getCheckpoints calls\n", plan.Requests)
+			} else {
+				fmt.Printf("  Batch size:  %d\n", plan.BatchSize)
+				fmt.Printf("  Requests:    about %d sui_getCheckpoints calls\n", plan.Requests)
+			}
+		}
+		fmt.Printf("  Output:      %s (%s)\n", *outputFile, *outputFormat)
+		return
+	}
+
+	if *count {
+		countCheckpoints(backend, ranges)
+		return
+	}
+
+	startTime := time.Now()
+	fmt.Println("Starting checkpoint fetching...")
+
+	// Fetch checkpoints, keeping each range apart for -verify
+	var checkpoints []suitrace.CheckpointData
+	var segments [][]suitrace.CheckpointData
+	var failed []suitrace.FailedCheckpoint // Skipped with -best-effort
+	for _, r := range ranges {
+		if len(ranges) > 1 {
+			fmt.Printf("Fetching range %d-%d\n", r[0], r[1])
+		}
+		rangeOpts := suitrace.CheckpointRangeOptions{BatchSize: *batchSize, Adaptive: *adaptiveBatch, Sample: *sample}
+		var segment []suitrace.CheckpointData
+		if *bestEffort {
+			var segmentFailed []suitrace.FailedCheckpoint
+			segment, segmentFailed, err = suitrace.FetchCheckpointRangeBestEffort(context.Background(), backend, r[0], r[1], rangeOpts)
+			failed = append(failed, segmentFailed...)
+		} else {
+			segment, err = suitrace.FetchCheckpointRangeWithOptions(context.Background(), backend, r[0], r[1], rangeOpts)
+		}
+		if err != nil {
+			fatalRPC("Failed to fetch checkpoints", err)
+		}
+		segments = append(segments, segment)
+		checkpoints = append(checkpoints, segment...)
+	}
+
+	elapsedTime := time.Since(startTime)
+
+	if len(checkpoints) == 0 {
+		fmt.Println("No checkpoints fetched!")
+		exitOnFailedCheckpoints(failed)
+		return
+	}
+
+	fmt.Printf("Fetched a total of %d checkpoints in %s\n", len(checkpoints), elapsedTime)
+
+	// -state-file and -follow continue from the last checkpoint fetched,
+	// whatever order the output is in
+	last := checkpoints[len(checkpoints)-1].SequenceNumber
+	suitrace.SortCheckpoints(checkpoints, *sortOrder) // Validated above
+
+	opts := suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, MaxFileRows: *maxFileRows, Gzip: *gzipOutput, Fields: fields, HumanTime: *humanTime}
+	opts.Metadata = metadataFor(checkpointRangeMetadata(segments, *sample))
+	if *expandTransactions {
+		expandOpts := suitrace.ExpandOptions{MaxPerCheckpoint: *maxTxPerCheckpoint, SkipLarge: *skipLarge}
+		saveTransactions(detailClient, checkpoints, expandOpts, *excludeSystem, *outputFile, *outputFormat, opts)
+	} else if *byEpoch {
+		saveEpochs(checkpoints, *outputFile, *outputFormat, opts)
+	} else {
+		saveCheckpoints(checkpoints, *outputFile, *outputFormat, opts)
+	}
+
+	if *stats || *statsOutput != "" {
+		printCheckpointStats(checkpoints, *statsOutput, suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, Metadata: opts.Metadata})
+	}
+
+	// The output has gaps, so leave -verify, -state-file and -follow for a
+	// complete run
+	exitOnFailedCheckpoints(failed)
+
+	if *verify {
+		for _, segment := range segments {
+			if len(segment) == 0 {
+				continue
+			}
+			if err := suitrace.VerifyChain(segment); err != nil {
+				log.Fatalf("Chain verification FAILED: %v", err)
+			}
+			fmt.Printf("Chain verified: %d-%d is unbroken\n", segment[0].SequenceNumber, segment[len(segment)-1].SequenceNumber)
+		}
+	}
+
+	if *checkSignatures {
+		errs := suitrace.CheckValidatorSignatures(checkpoints)
+		for _, err := range errs {
+			fmt.Printf("Malformed signature: %v\n", err)
+		}
+		if len(errs) > 0 {
+			log.Fatalf("Signature check FAILED: %d of %d checkpoints have a malformed validator signature", len(errs), len(checkpoints))
+		}
+		fmt.Printf("Signatures checked: all %d are well-formed %s signatures\n", len(checkpoints), suitrace.SignatureSchemeBLS12381)
+	}
+
+	if *stateFile != "" {
+		if err := suitrace.WriteCheckpointState(*stateFile, suitrace.CheckpointState{LastSequenceNumber: last}); err != nil {
+			log.Fatalf("Failed to update state file: %v", err)
+		}
+		fmt.Printf("Recorded checkpoint %d in %s\n", last, *stateFile)
+	}
+
+	if *follow {
+		followCheckpoints(backend, last+1, *pollInterval)
+	}
+}
+
+// List the checkpoints -best-effort skipped and exit non-zero, if there are any
+func exitOnFailedCheckpoints(failed []suitrace.FailedCheckpoint) {
+	if len(failed) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\n%d checkpoints could not be fetched:\n", len(failed))
+	for _, f := range failed {
+		fmt.Fprintf(os.Stderr, "  %s\n", f)
+	}
+	os.Exit(1)
+}
+
+// Print the number of checkpoints and transactions in each range and overall
+func countCheckpoints(backend suitrace.Backend, ranges [][2]int) {
+	var checkpoints, transactions int64
+	for _, r := range ranges {
+		counts, err := suitrace.CountCheckpointRange(context.Background(), backend, r[0], r[1])
+		if err != nil {
+			fatalRPC("Failed to count checkpoints", err)
+		}
+		fmt.Printf("Checkpoints %d-%d: %d checkpoints, %d transactions\n", counts.Start, counts.End, counts.Checkpoints, counts.Transactions)
+		checkpoints += counts.Checkpoints
+		transactions += counts.Transactions
+	}
+
+	if len(ranges) > 1 {
+		fmt.Printf("Total: %d checkpoints, %d transactions\n", checkpoints, transactions)
+	}
+}
+
+// Print throughput statistics for checkpoints, and save them as JSON to
+// outputFile when set
+func printCheckpointStats(checkpoints []suitrace.CheckpointData, outputFile string, opts suitrace.WriteOptions) {
+	stats := suitrace.ComputeCheckpointStats(checkpoints)
+
+	fmt.Println("\nNetwork statistics:")
+	stats.WriteSummary(os.Stdout)
+
+	if outputFile != "" {
+		if err := suitrace.SaveCheckpointStatsToJSON(stats, outputFile, opts); err != nil {
+			log.Fatalf("Failed to save statistics: %v", err)
+		}
+		fmt.Printf("Statistics saved to %s\n", outputFile)
+	}
+}
+
+func saveCheckpoints(checkpoints []suitrace.CheckpointData, filename, format string, opts suitrace.WriteOptions) {
+	fmt.Printf("Saving checkpoints to %s file...\n", format)
+
+	var files []string
+	var err error
+	if format == "csv" {
+		files, err = suitrace.SaveCheckpointsToCSV(checkpoints, filename, opts)
+	} else if format == "json" {
+		files, err = suitrace.SaveCheckpointsToJSON(checkpoints, filename, opts)
+	} else if format == "parquet" {
+		files, err = suitrace.SaveCheckpointsToParquet(checkpoints, filename, opts)
+	} else {
+		log.Fatalf("Unsupported output format: %s", format)
+	}
+
+	if err != nil {
+		log.Fatalf("Failed to save checkpoints: %v", err)
+	}
+
+	fmt.Printf("Done! %d checkpoints saved to %s 🎉\n", len(checkpoints), strings.Join(files, ", "))
+}
+
+// Write one row per transaction of checkpoints, with details looked up
+// through detailClient when it is not nil. excludeSystem drops system
+// transactions once their details are known.
+func saveTransactions(detailClient *suitrace.Client, checkpoints []suitrace.CheckpointData, expandOpts suitrace.ExpandOptions, excludeSystem bool, filename, format string, opts suitrace.WriteOptions) {
+	txs, summary := suitrace.ExpandTransactionsWithOptions(checkpoints, expandOpts)
+	for _, seq := range summary.LimitedCheckpoints {
+		if expandOpts.SkipLarge {
+			fmt.Printf("Warning: skipping checkpoint %d, it has more than %d transactions\n", seq, expandOpts.MaxPerCheckpoint)
+		} else {
+			fmt.Printf("Warning: keeping only the first %d transactions of checkpoint %d\n", expandOpts.MaxPerCheckpoint, seq)
+		}
+	}
+	fmt.Printf("Expanded %d checkpoints into %d transactions\n", len(checkpoints), len(txs))
+	if len(summary.LimitedCheckpoints) > 0 {
+		fmt.Printf("Skipped %d transactions from %d checkpoints over -max-tx-per-checkpoint=%d\n",
+			summary.TransactionsSkipped, len(summary.LimitedCheckpoints), expandOpts.MaxPerCheckpoint)
+	}
+
+	if detailClient != nil {
+		startTime := time.Now()
+		fmt.Println("Fetching transaction details...")
+		if err := detailClient.FetchTransactionDetails(txs); err != nil {
+			fatalRPC("Failed to fetch transaction details", err)
+		}
+		fmt.Printf("Fetched transaction details in %s\n", time.Since(startTime))
+	}
+
+	if excludeSystem {
+		var excluded int
+		txs, excluded = suitrace.ExcludeSystemTransactions(txs)
+		fmt.Printf("Excluded %d system transactions\n", excluded)
+	}
+
+	fmt.Printf("Saving transactions to %s file...\n", format)
+
+	var files []string
+	var err error
+	if format == "csv" {
+		files, err = suitrace.SaveTransactionsToCSV(txs, filename, opts)
+	} else if format == "json" {
+		files, err = suitrace.SaveTransactionsToJSON(txs, filename, opts)
+	} else if format == "parquet" {
+		files, err = suitrace.SaveTransactionsToParquet(txs, filename, opts)
+	} else {
+		log.Fatalf("Unsupported output format: %s", format)
+	}
+
+	if err != nil {
+		log.Fatalf("Failed to save transactions: %v", err)
+	}
+
+	fmt.Printf("Done! %d transactions saved to %s 🎉\n", len(txs), strings.Join(files, ", "))
+}
+
+// Write one row per epoch of checkpoints
+func saveEpochs(checkpoints []suitrace.CheckpointData, filename, format string, opts suitrace.WriteOptions) {
+	sorted := append([]suitrace.CheckpointData(nil), checkpoints...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].SequenceNumber < sorted[j].SequenceNumber })
+	epochs := suitrace.AggregateEpochs(sorted)
+	fmt.Printf("Grouped %d checkpoints into %d epochs\n", len(checkpoints), len(epochs))
+
+	fmt.Printf("Saving epochs to %s file...\n", format)
+
+	var err error
+	if format == "csv" {
+		err = suitrace.SaveEpochsToCSV(epochs, filename, opts)
+	} else if format == "json" {
+		err = suitrace.SaveEpochsToJSON(epochs, filename, opts)
+	} else {
+		log.Fatalf("Unsupported output format for -by-epoch: %s", format)
+	}
+
+	if err != nil {
+		log.Fatalf("Failed to save epochs: %v", err)
+	}
+
+	fmt.Printf("Done! %d epochs saved to %s 🎉\n", len(epochs), filename)
+}
+
+// Read checkpoint ranges in -range syntax, one per line. Blank lines and
+// lines starting with # are skipped.
+func readCheckpointRanges(r io.Reader) ([][2]int, error) {
+	var ranges [][2]int
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		start, end, err := suitrace.ParseCheckpointRange(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("no checkpoint ranges given")
+	}
+	return ranges, nil
+}
+
+// Resume after the checkpoint recorded in the state file, up to end or the
+// latest checkpoint. Exits when there is nothing new to fetch.
+func resumeFromState(backend suitrace.Backend, stateFile string, end int) (int, int) {
+	state, ok, err := suitrace.ReadCheckpointState(stateFile)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if !ok {
+		log.Fatalf("State file %s does not exist yet; pass -start (or -range) for the first run", stateFile)
+	}
+
+	start := int(state.LastSequenceNumber) + 1
+	if end <= 0 {
+		latest, err := backend.LatestSequenceNumber(context.Background())
+		if err != nil {
+			fatalRPC("Failed to fetch latest checkpoint", err)
+		}
+		end = int(latest)
+	}
+
+	if start > end {
+		fmt.Printf("Already up to date: checkpoint %d is the latest\n", state.LastSequenceNumber)
+		os.Exit(0)
+	}
+
+	fmt.Printf("Resuming after checkpoint %d from %s\n", state.LastSequenceNumber, stateFile)
+	return start, end
+}
+
+// Resolve -after/-before to a checkpoint sequence range
+func resolveTimeRange(backend suitrace.Backend, after, before string) (int, int) {
+	var afterTime, beforeTime time.Time
+	var err error
+
+	if after != "" {
+		if afterTime, err = time.Parse(time.RFC3339, after); err != nil {
+			log.Fatalf("Invalid -after time: %v", err)
+		}
+	}
+	if before != "" {
+		if beforeTime, err = time.Parse(time.RFC3339, before); err != nil {
+			log.Fatalf("Invalid -before time: %v", err)
+		}
+	}
+
+	fmt.Println("Searching for checkpoints in the time window...")
+	start, end, err := suitrace.CheckpointRangeForTime(context.Background(), backend, afterTime, beforeTime)
+	if err != nil {
+		fatalRPC("Failed to resolve time window", err)
+	}
+
+	fmt.Printf("Resolved time window to checkpoints %d-%d\n", start, end)
+	return int(start), int(end)
+}
+
+// Stream new checkpoints as JSON lines until interrupted
+func followCheckpoints(backend suitrace.Backend, startSeq int64, interval time.Duration) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	checkpoints, err := suitrace.FollowCheckpoints(ctx, backend, startSeq, interval)
+	if err != nil {
+		fatalRPC("Failed to follow checkpoints", err)
+	}
+
+	fmt.Printf("Following new checkpoints from %d (Ctrl-C to stop)...\n", startSeq)
+
+	encoder := json.NewEncoder(os.Stdout)
+	for checkpoint := range checkpoints {
+		if err := encoder.Encode(checkpoint); err != nil {
+			log.Fatalf("Failed to write checkpoint: %v", err)
+		}
+	}
+}
+
+// The checkpoint ranges fetched, with "latest" resolved, and the sampling
+// interval of a sampled fetch, for export metadata
+func checkpointRangeMetadata(segments [][]suitrace.CheckpointData, sample int) map[string]interface{} {
+	ranges := [][2]int64{}
+	for _, segment := range segments {
+		if len(segment) > 0 {
+			ranges = append(ranges, [2]int64{segment[0].SequenceNumber, segment[len(segment)-1].SequenceNumber})
+		}
+	}
+	metadata := map[string]interface{}{"ranges": ranges}
+	if len(ranges) == 1 {
+		metadata = map[string]interface{}{"start": ranges[0][0], "end": ranges[0][1]}
+	}
+	if sample > 1 {
+		metadata["sample"] = sample
+	}
+	return metadata
+}
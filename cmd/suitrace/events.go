@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	suitrace "github.com/VeerChaurasia/SuiTrace"
+)
+
+func runEvents(client *suitrace.Client, args []string) {
+	fs := flag.NewFlagSet("events", flag.ExitOnError)
+	limit := fs.Int("limit", 200, "Number of events to fetch (0 for no limit)")
+	descending := fs.Bool("descending", false, "Fetch the newest events first, so -limit keeps the most recent ones")
+	pageSize := fs.Int("page-size", suitrace.EventPageSize, fmt.Sprintf("Events requested per suix_queryEvents call, up to %d; lowered automatically to the node's maximum", suitrace.MaxEventPageSize))
+	filename := fs.String("filename", "events.csv", "Output filename")
+	outputFormat := fs.String("format", "csv", "Output format (csv or parquet)")
+	var eventTypes stringList
+	fs.Var(&eventTypes, "event-type", "Only fetch events of this Move event type (e.g. 0x3::validator::StakingRequestEvent); repeat or comma-separate for any of several")
+	where := fs.String("where", "", "Fetch events matching this filter expression, e.g. 'package=0x2 AND (type=0x2::coin::* OR sender=0xabc)' (instead of -event-type)")
+	filterFile := fs.String("filter-file", "", "Fetch events matching the suix_queryEvents filter JSON in this file, e.g. {\"Any\": [...]} (instead of -event-type)")
+	flatten := fs.Bool("flatten", false, "Expand parsedJson into parsed.<field> columns (requires -event-type)")
+	noDedup := fs.Bool("no-dedup", false, "Keep duplicate events repeated across pages or retries")
+	gzipOutput := fs.Bool("gzip", false, "Gzip-compress the CSV (implied by a .gz filename)")
+	maxFileRows := fs.Int("max-file-rows", 0, "Roll over to a new numbered output file after this many rows (0 for a single file)")
+	dryRun := fs.Bool("dry-run", false, "Validate flags and print the backfill plan without fetching")
+	count := fs.Bool("count", false, "Only count the matching events and print the total, without saving them")
+	inputFile := fs.String("input", "", "Re-process events from this JSON or JSON Lines file instead of fetching them")
+	follow := fs.Bool("follow", false, "After the backfill, stream new events to stdout as JSON lines until interrupted")
+	excludeSystem := fs.Bool("exclude-system", false, "Drop events emitted by system transactions (epoch changes, ...), looking up each transaction's kind")
+	fs.Parse(args)
+
+	filter := suitrace.EventTypesFilter(eventTypes)
+	if *where != "" {
+		if len(eventTypes) > 0 || *filterFile != "" || *inputFile != "" {
+			log.Fatalf("-where cannot be combined with -event-type, -filter-file or -input")
+		}
+		var err error
+		if filter, err = suitrace.ParseEventExpression(*where); err != nil {
+			log.Fatalf("Invalid -where: %v", err)
+		}
+	}
+	if *filterFile != "" {
+		if len(eventTypes) > 0 || *inputFile != "" {
+			log.Fatalf("-filter-file cannot be combined with -event-type or -input")
+		}
+		data, err := os.ReadFile(*filterFile)
+		if err != nil {
+			log.Fatalf("Failed to read -filter-file: %v", err)
+		}
+		if filter, err = suitrace.ParseEventFilter(data); err != nil {
+			log.Fatalf("Invalid -filter-file: %v", err)
+		}
+	}
+
+	if *flatten && len(eventTypes) == 0 {
+		log.Fatalf("-flatten requires -event-type")
+	}
+
+	if *limit < 0 {
+		log.Fatalf("-limit must be >= 0")
+	}
+
+	if err := suitrace.ValidateEventPageSize(*pageSize); err != nil {
+		log.Fatalf("Invalid -page-size: %v", err)
+	}
+
+	if *outputFormat != "csv" && *outputFormat != "parquet" {
+		log.Fatalf("Unsupported output format: %s", *outputFormat)
+	}
+
+	if *outputFormat == "parquet" && (*flatten || *gzipOutput) {
+		log.Fatalf("-flatten and -gzip do not apply to -format=parquet")
+	}
+
+	if *count && (*dryRun || *follow) {
+		log.Fatalf("-count cannot be combined with -dry-run or -follow")
+	}
+
+	if *excludeSystem && (*count || *inputFile != "") {
+		log.Fatalf("-exclude-system cannot be combined with -count or -input")
+	}
+
+	if *inputFile != "" {
+		if *dryRun || *follow || *descending {
+			log.Fatalf("-dry-run, -follow and -descending do not apply to -input")
+		}
+		if *count {
+			events, _, err := suitrace.LoadEventsJSON(*inputFile)
+			if err != nil {
+				log.Fatalf("Failed to read events: %v", err)
+			}
+			events = suitrace.FilterEvents(events, eventTypes, suitrace.EventBackfillOptions{Limit: *limit, NoDedup: *noDedup})
+			fmt.Printf("%d events in %s match\n", len(events), *inputFile)
+			return
+		}
+		// The events come from the file, not the endpoint
+		meta := metadataFor(map[string]interface{}{"input": *inputFile, "eventTypes": []string(eventTypes), "limit": *limit})
+		if meta != nil {
+			meta.RPCURL = ""
+		}
+		processEventFile(*inputFile, eventTypes, *filename, *outputFormat, *flatten, suitrace.EventBackfillOptions{
+			Limit:   *limit,
+			NoDedup: *noDedup,
+		}, suitrace.WriteOptions{MaxFileRows: *maxFileRows, Gzip: *gzipOutput, Metadata: meta})
+		return
+	}
+
+	if *dryRun {
+		fmt.Println("Dry run, nothing will be fetched")
+		fmt.Printf("  Endpoint: %s\n", client.URL)
+		fmt.Printf("  Filter:   %v\n", filter)
+		if *descending {
+			fmt.Println("  Order:    newest first")
+		} else {
+			fmt.Println("  Order:    oldest first")
+		}
+		if *limit > 0 {
+			fmt.Printf("  Limit:    %d events (about %d suix_queryEvents calls)\n", *limit, (*limit+*pageSize-1) / *pageSize)
+		} else {
+			fmt.Printf("  Limit:    none, pages of %d until the cursor is exhausted\n", *pageSize)
+		}
+		fmt.Printf("  Output:   %s (%s)\n", *filename, *outputFormat)
+		return
+	}
+
+	if *count {
+		countEvents(client, filter, suitrace.EventBackfillOptions{
+			Limit:      *limit,
+			NoDedup:    *noDedup,
+			PageSize:   *pageSize,
+			Descending: *descending,
+		})
+		return
+	}
+
+	fmt.Println("Starting event backfill...")
+
+	startTime := time.Now()
+
+	allEvents, err := client.BackfillEvents(filter, suitrace.EventBackfillOptions{
+		Limit:      *limit,
+		NoDedup:    *noDedup,
+		PageSize:   *pageSize,
+		Descending: *descending,
+	})
+	if err != nil {
+		fatalRPC("Failed to fetch events", err)
+	}
+
+	if *excludeSystem && len(allEvents) > 0 {
+		var excluded int
+		allEvents, excluded, err = client.ExcludeSystemEvents(allEvents)
+		if err != nil {
+			fatalRPC("Failed to look up transaction kinds", err)
+		}
+		fmt.Printf("Excluded %d events from system transactions\n", excluded)
+	}
+
+	elapsedTime := time.Since(startTime)
+
+	if len(allEvents) == 0 {
+		fmt.Println("No events fetched!")
+	} else {
+		meta := metadataFor(map[string]interface{}{"filter": filter, "limit": *limit, "descending": *descending})
+		saveEvents(allEvents, elapsedTime, *filename, *outputFormat, *flatten, suitrace.WriteOptions{MaxFileRows: *maxFileRows, Gzip: *gzipOutput, Metadata: meta})
+	}
+
+	if *follow {
+		followEvents(client, filter)
+	}
+}
+
+// Page through the matching events and print how many there are
+func countEvents(client *suitrace.Client, filter map[string]interface{}, opts suitrace.EventBackfillOptions) {
+	fmt.Println("Counting events...")
+	startTime := time.Now()
+
+	n, err := client.CountEvents(filter, opts)
+	if err != nil {
+		fatalRPC("Failed to count events", err)
+	}
+
+	fmt.Printf("%d events match %v (counted in %s)\n", n, filter, time.Since(startTime))
+	if opts.Limit > 0 && n >= opts.Limit {
+		fmt.Printf("Stopped at -limit=%d; pass -limit=0 to count them all\n", opts.Limit)
+	}
+}
+
+// Load events from a file and run them through the same filtering and
+// output as a backfill
+func processEventFile(inputFile string, eventTypes []string, filename, format string, flatten bool, filterOpts suitrace.EventBackfillOptions, opts suitrace.WriteOptions) {
+	startTime := time.Now()
+	fmt.Printf("Reading events from %s...\n", inputFile)
+
+	events, skipped, err := suitrace.LoadEventsJSON(inputFile)
+	if err != nil {
+		log.Fatalf("Failed to read events: %v", err)
+	}
+	for _, lineErr := range skipped {
+		fmt.Printf("Warning: skipping malformed %v\n", lineErr)
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("Skipped %d malformed lines\n", len(skipped))
+	}
+
+	events = suitrace.FilterEvents(events, eventTypes, filterOpts)
+	if len(events) == 0 {
+		fmt.Println("No events matched!")
+		return
+	}
+
+	saveEvents(events, time.Since(startTime), filename, format, flatten, opts)
+}
+
+func saveEvents(allEvents []map[string]interface{}, elapsedTime time.Duration, filename, format string, flatten bool, opts suitrace.WriteOptions) {
+	fmt.Printf("Fetched a total of %d events in %s\n", len(allEvents), elapsedTime)
+
+	if flatten {
+		flattened, ok := suitrace.FlattenParsedJSON(allEvents)
+		if ok {
+			allEvents = flattened
+		} else {
+			fmt.Println("Warning: events have different parsedJson shapes, keeping parsedJson as a single column")
+		}
+	}
+
+	fmt.Printf("Saving events to %s file...\n", format)
+
+	var files []string
+	var err error
+	if format == "parquet" {
+		files, err = suitrace.SaveEventsToParquet(allEvents, filename, opts)
+	} else {
+		files, err = suitrace.SaveEventsToCSV(allEvents, filename, opts)
+	}
+	if err != nil {
+		log.Fatalf("Failed to save events: %v", err)
+	}
+
+	fmt.Printf("Done! %d events saved to %s 🎉\n", len(allEvents), strings.Join(files, ", "))
+}
+
+// Stream live events as JSON lines until interrupted
+func followEvents(client *suitrace.Client, filter map[string]interface{}) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	events, err := client.SubscribeEvents(ctx, filter)
+	if err != nil {
+		fatalRPC("Failed to subscribe to events", err)
+	}
+
+	fmt.Println("Following live events (Ctrl-C to stop)...")
+
+	encoder := json.NewEncoder(os.Stdout)
+	for event := range events {
+		if err := encoder.Encode(event); err != nil {
+			log.Fatalf("Failed to write event: %v", err)
+		}
+	}
+}
+
+// A flag that can be repeated, each value also split on commas
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
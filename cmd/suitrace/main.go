@@ -0,0 +1,282 @@
+// Command suitrace fetches historical checkpoints, object histories and
+// events from a Sui JSON-RPC node.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	suitrace "github.com/VeerChaurasia/SuiTrace"
+)
+
+const usage = `Usage: suitrace [global flags] <command> [command flags]
+
+Commands:
+  checkpoint  Fetch checkpoints in a sequence range
+  object      Trace the version history of an object
+  events      Backfill events to CSV
+  balance     Print the coin balances of an address
+  tx          Export transaction blocks by digest
+
+Run 'suitrace <command> -h' for command flags.
+
+Global flags:
+`
+
+func main() {
+	rpcURL := flag.String("rpc", suitrace.DefaultRPCURL, "Sui JSON-RPC endpoint")
+	rpcURLB := flag.String("rpc-b", "", "Second Sui JSON-RPC endpoint; the object command compares the object's history on both (on another network, such as testnet against mainnet, also pass -allow-mixed-chains)")
+	flag.BoolVar(&allowMixedChains, "allow-mixed-chains", false, "Let -rpc and -rpc-b serve different networks instead of refusing to compare them")
+	wsURL := flag.String("ws", "", "Sui WebSocket endpoint for live subscriptions (derived from -rpc when empty)")
+	debug := flag.Bool("debug", false, "Print RPC requests and responses")
+	trace := flag.Bool("trace", false, "Time the DNS, connect, TLS, server and body phases of every request (printed per request with -debug) and print p50/p95 at the end")
+	saveRaw := flag.String("save-raw", "", "Save every raw RPC response body to this directory for auditing")
+	replay := flag.String("replay", "", "Answer RPC calls from responses saved with -save-raw in this directory, without network access")
+	backend := flag.String("backend", "rpc", "Data source: rpc (JSON-RPC) or graphql (checkpoint command only)")
+	graphqlURL := flag.String("graphql", suitrace.DefaultGraphQLURL, "Sui GraphQL endpoint used with -backend=graphql")
+	timeout := flag.Duration("timeout", suitrace.DefaultTimeout, "HTTP timeout per RPC request")
+	apiKey := flag.String("api-key", "", "API key sent as \"Authorization: Bearer <key>\" with every request")
+	authHeader := flag.String("auth-header", "", "Custom header sent with every request, as \"Name: value\" (e.g. \"x-api-key: <key>\")")
+	proxy := flag.String("proxy", "", "Send RPC and WebSocket traffic through this http, https or socks5 proxy URL (default from HTTP_PROXY/HTTPS_PROXY)")
+	maxResponseBytes := flag.Int64("max-response-bytes", 0, "Fail any response body larger than this many bytes (0 for no limit)")
+	failureThreshold := flag.Int("failure-threshold", 0, "Fail fast once this many consecutive requests fail, instead of retrying each item (0 to always retry)")
+	breakerCooldown := flag.Duration("breaker-cooldown", suitrace.DefaultBreakerCooldown, "How long to fail fast after -failure-threshold trips before trying the endpoint again")
+	rps := flag.Float64("rps", 0, "Cap outbound requests per second across all workers (0 for no cap)")
+	perHostConcurrency := flag.Int("per-host-concurrency", 0, "Cap the requests in flight to any one host, across -rpc, -rpc-b and -graphql (0 for no cap)")
+	cacheSize := flag.Int("cache-size", 0, "Keep up to this many RPC responses in memory and answer repeated requests from them (0 for no cache)")
+	cacheDir := flag.String("cache-dir", "", "Keep immutable RPC responses (old checkpoints, transactions, past object versions) in this directory and reuse them in later runs")
+	cacheTTL := flag.Duration("cache-ttl", 0, "With -cache-size, also cache responses that can change, such as an object's current state, for this long (0 caches only immutable ones)")
+	chainID := flag.String("chain-id", "", "Chain identifier of -rpc (e.g. 35834a8a for mainnet); skips asking the endpoint with sui_getChainIdentifier")
+	noMetadata := flag.Bool("no-metadata", false, "Write bare JSON arrays and no .meta.json sidecars, for tools that can't handle the metadata wrapper")
+	showVersion := flag.Bool("version", false, "Print the version, commit and Go version of this build and exit")
+	timezone := flag.String("timezone", "UTC", "Time zone of human-readable timestamps, as an IANA name such as Europe/Berlin, or Local")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(suitrace.VersionString())
+		return
+	}
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	location, err := time.LoadLocation(*timezone)
+	if err != nil {
+		log.Fatalf("Invalid -timezone: %v", err)
+	}
+	suitrace.OutputLocation = location
+
+	client := suitrace.NewClient(*rpcURL)
+	client.WSURL = *wsURL
+	client.Debug = *debug
+	client.RawDir = *saveRaw
+	client.ReplayDir = *replay
+	client.HTTPClient.Timeout = *timeout
+	client.APIKey = *apiKey
+	client.ChainID = *chainID
+	if *trace {
+		client.Tracer = suitrace.NewTracer()
+	}
+	if *maxResponseBytes < 0 {
+		log.Fatalf("-max-response-bytes must be >= 0")
+	}
+	client.MaxResponseBytes = *maxResponseBytes
+	if *proxy != "" {
+		if err := client.SetProxy(*proxy); err != nil {
+			log.Fatalf("Invalid -proxy: %v", err)
+		}
+	}
+	if *authHeader != "" {
+		name, value, err := suitrace.ParseHeader(*authHeader)
+		if err != nil {
+			log.Fatalf("Invalid -auth-header: %v", err)
+		}
+		client.Header = http.Header{}
+		client.Header.Set(name, value)
+	}
+	if *failureThreshold < 0 {
+		log.Fatalf("-failure-threshold must be >= 0")
+	}
+	if *failureThreshold > 0 {
+		client.Breaker = suitrace.NewCircuitBreaker(*failureThreshold, *breakerCooldown)
+	}
+	if *cacheSize < 0 || *cacheTTL < 0 {
+		log.Fatalf("-cache-size and -cache-ttl must be >= 0")
+	}
+	if *cacheSize > 0 || *cacheDir != "" {
+		client.Cache = suitrace.NewResponseCache(*cacheSize, *cacheTTL)
+		client.Cache.Dir = *cacheDir
+	}
+	if *cacheDir != "" {
+		if err := os.MkdirAll(*cacheDir, 0o755); err != nil {
+			log.Fatalf("Invalid -cache-dir: %v", err)
+		}
+	}
+	if *rps < 0 {
+		log.Fatalf("-rps must be >= 0")
+	}
+	if *rps > 0 {
+		client.Limiter = suitrace.NewRateLimiter(*rps)
+	}
+	if *perHostConcurrency < 0 {
+		log.Fatalf("-per-host-concurrency must be >= 0")
+	}
+	if *perHostConcurrency > 0 {
+		client.HostLimit = suitrace.NewHostLimiter(*perHostConcurrency)
+	}
+
+	// The comparison client shares every setting except the endpoint, the
+	// raw response directories and the cached chain identifier and
+	// timestamps, which are per-endpoint
+	var compareClient *suitrace.Client
+	if *rpcURLB != "" {
+		clientB := *client
+		clientB.URL = *rpcURLB
+		clientB.WSURL, clientB.RawDir, clientB.ReplayDir = "", "", ""
+		if client.Breaker != nil {
+			clientB.Breaker = suitrace.NewCircuitBreaker(*failureThreshold, *breakerCooldown)
+		}
+		clientB.ChainID, clientB.Timestamps = "", suitrace.NewTimestampCache()
+		compareClient = &clientB
+	}
+
+	var source suitrace.Backend = client
+	endpoint := client.URL
+	switch *backend {
+	case "rpc":
+	case "graphql":
+		gql := suitrace.NewGraphQLClient(*graphqlURL)
+		gql.Debug = *debug
+		gql.HTTPClient.Timeout = *timeout
+		gql.Limiter = client.Limiter
+		gql.Breaker = client.Breaker
+		gql.HostLimit = client.HostLimit
+		gql.Tracer = client.Tracer
+		gql.APIKey, gql.Header = client.APIKey, client.Header
+		gql.MaxResponseBytes = client.MaxResponseBytes
+		if *proxy != "" {
+			gql.SetProxy(*proxy) // Already validated for the RPC client
+		}
+		source, endpoint = gql, gql.URL
+	default:
+		log.Fatalf("Unknown backend %q (use rpc or graphql)", *backend)
+	}
+
+	if !*noMetadata {
+		exportMetadata = &suitrace.ExportMetadata{RPCURL: endpoint}
+		if *backend == "rpc" {
+			networkClient = client
+		}
+	}
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+	if *backend == "graphql" && command != "checkpoint" {
+		log.Fatalf("The %s command does not support -backend=graphql yet", command)
+	}
+	if compareClient != nil && command != "object" {
+		log.Fatalf("-rpc-b only applies to the object command")
+	}
+
+	switch command {
+	case "checkpoint":
+		runCheckpoint(source, endpoint, args)
+	case "object":
+		runObject(client, compareClient, args)
+	case "events":
+		runEvents(client, args)
+	case "balance":
+		runBalance(client, args)
+	case "tx":
+		runTx(client, args)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", command)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if client.Tracer != nil {
+		fmt.Println()
+		client.Tracer.WriteSummary(os.Stdout)
+	}
+}
+
+// Provenance recorded with exports, or nil with -no-metadata
+var exportMetadata *suitrace.ExportMetadata
+
+// Whether -rpc and -rpc-b may serve different chains
+var allowMixedChains bool
+
+// Client whose network labels exportMetadata, looked up on first use so
+// commands that write nothing make no extra request
+var networkClient *suitrace.Client
+
+// The export metadata of a command that requested rng
+func metadataFor(rng map[string]interface{}) *suitrace.ExportMetadata {
+	if exportMetadata == nil {
+		return nil
+	}
+	if networkClient != nil {
+		network, err := networkClient.DetectNetwork()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not detect the network for export metadata: %v\n", err)
+		}
+		exportMetadata.Network, exportMetadata.ChainID = network, networkClient.ChainID
+		networkClient = nil
+	}
+	meta := *exportMetadata
+	meta.Range = rng
+	return &meta
+}
+
+// Print the chain identifier of each configured endpoint and exit when they
+// serve different networks, unless -allow-mixed-chains asks to compare
+// across networks. An endpoint that cannot be checked only warns.
+func checkEndpointChains(clients ...*suitrace.Client) {
+	endpoints, err := suitrace.CheckChainIdentifiers(clients...)
+	for _, ep := range endpoints {
+		fmt.Printf("Chain ID of %s: %s (%s)\n", ep.URL, ep.ChainID, suitrace.NetworkName(ep.ChainID, ep.URL))
+	}
+
+	var mismatch *suitrace.ChainMismatchError
+	switch {
+	case errors.As(err, &mismatch) && !allowMixedChains:
+		log.Fatalf("%v (pass -allow-mixed-chains to compare them anyway)", mismatch)
+	case errors.As(err, &mismatch):
+		fmt.Printf("Warning: %v\n", mismatch)
+	case err != nil:
+		fmt.Printf("Warning: could not check that the endpoints serve the same network: %v\n", err)
+	}
+}
+
+// Exit with err, spelling out the code, message and data of RPC errors
+func fatalRPC(what string, err error) {
+	// Report the endpoint's state, not the item that happened to hit it
+	var unhealthy *suitrace.EndpointUnhealthyError
+	if errors.As(err, &unhealthy) {
+		log.Fatalf("%s: %v", what, unhealthy)
+	}
+
+	var rpcErr *suitrace.RPCError
+	if !errors.As(err, &rpcErr) {
+		log.Fatalf("%s: %v", what, err)
+	}
+
+	msg := fmt.Sprintf("%s: the RPC node returned error %d: %s", what, rpcErr.Code, rpcErr.Message)
+	if rpcErr.Data != nil {
+		data, _ := json.MarshalIndent(rpcErr.Data, "  ", "  ")
+		msg += fmt.Sprintf("\n  data: %s", data)
+	}
+	log.Fatal(msg)
+}
@@ -0,0 +1,723 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	suitrace "github.com/VeerChaurasia/SuiTrace"
+)
+
+// compareClient, when not nil, is a second endpoint whose history of the
+// object is compared against client's
+func runObject(client, compareClient *suitrace.Client, args []string) {
+	fs := flag.NewFlagSet("object", flag.ExitOnError)
+	objectID := fs.String("object", "", "Object ID to track, or - to trace every ID read from stdin (one per line)")
+	objectList := fs.String("objects", "", "Comma-separated object IDs whose current state to fetch")
+	objectsFile := fs.String("objects-file", "", "File of newline-delimited object IDs whose current state to fetch (- for stdin)")
+	outputDir := fs.String("output-dir", "", "With -objects, -objects-file or -object -, write one JSON file per object to this directory")
+	outputFile := fs.String("output", "", "Output JSON file (optional)")
+	dotFile := fs.String("dot", "", "Write the object's ownership transfers as a Graphviz DOT graph to this file")
+	compact := fs.Bool("compact", false, "Write JSON without indentation")
+	canonical := fs.Bool("canonical", false, "Write JSON with every object's keys sorted, for storing exports in git and diffing runs")
+	gzipOutput := fs.Bool("gzip", false, "Gzip-compress the JSON output (implied by a .gz filename)")
+	humanTime := fs.Bool("human-time", false, "Add RFC3339 firstSeenTime/lastSeenTime next to the raw millis in JSON output, in the -timezone zone (UTC by default)")
+	flattenOwner := fs.Bool("flatten-owner", false, "Add ownerType and ownerAddress fields next to each object state's owner map in JSON output")
+	verbose := fs.Bool("verbose", false, "Print detailed information")
+	withBalances := fs.Bool("with-balances", false, "Attach each transaction's coin balance changes to the object states")
+	withEvents := fs.Bool("with-events", false, "Attach events that reference the object to the state of the transaction that emitted them")
+	dynamicFields := fs.Bool("dynamic-fields", false, "List the object's dynamic fields after the summary")
+	ownershipTree := fs.Bool("ownership-tree", false, "Print the object's ownership tree (top owner and nested dynamic object fields) as JSON instead of its history")
+	depth := fs.Int("depth", suitrace.DefaultOwnershipDepth, "Maximum depth below the top owner for -ownership-tree")
+	watch := fs.Bool("watch", false, "After fetching the history, poll the object and print what changed each time its version advances, until Ctrl-C")
+	pollInterval := fs.Duration("poll-interval", suitrace.DefaultPollInterval, "How often to poll the object with -watch")
+	webhookURL := fs.String("webhook-url", "", "With -watch, POST a JSON description of each new version to this URL")
+	typePattern := fs.String("type", "", "Only trace objects whose Move type matches this glob or prefix; without -object, enumerate objects of this type")
+	owner := fs.String("owner", "", "List the current state of every object owned by this address")
+	resume := fs.String("resume", "", "Update the history saved in this JSON file, fetching only transactions newer than its latest state, and save the merged history back (or to -output)")
+	resumeDigest := fs.String("resume-from-digest", "", "Only fetch transactions newer than this digest (default: the latest state's transaction in -resume)")
+	resumeVersion := fs.Uint64("resume-from-version", 0, "Only fetch states above this version (default: the latest version in -resume)")
+	withHistory := fs.Bool("with-history", false, "With -owner, fetch the full history of each owned object instead of its current state")
+	summaryOnly := fs.Bool("summary-only", false, "Only report the object's summary (ID, version/change/owner counts, first/last seen, current type and owner); -output saves it instead of the full history")
+	outputFormat := fs.String("format", "text", "Format of the -summary-only or -owners-report report: text or json (csv for -owners-report); with -output, text means json, and csv saves histories one row per state")
+	ownersReport := fs.Bool("owners-report", false, "Count the current owners of the -objects, -objects-file, or -type objects, most objects first")
+	maxTransactions := fs.Int("max-transactions", 0, "Process at most this many of the object's transactions, newest first, and mark the history truncated if there are more (0 for all)")
+	txPageSize := fs.Int("tx-page-size", suitrace.DefaultTransactionPageSize, "Transaction digests requested per page when listing the object's transactions")
+	fs.Parse(args)
+
+	if *outputFormat != "text" && *outputFormat != "json" && *outputFormat != "csv" {
+		log.Fatalf("Unsupported output format: %s", *outputFormat)
+	}
+	if *outputFormat == "csv" && !*ownersReport && (*outputFile == "" || *summaryOnly || *watch || *ownershipTree ||
+		*objectList != "" || *objectsFile != "" || (*owner != "" && !*withHistory)) {
+		log.Fatalf("-format=csv only applies to -owners-report, or to object histories saved with -output")
+	}
+	if *outputFormat == "json" && !*summaryOnly && !*ownersReport {
+		log.Fatalf("-format=json requires -summary-only or -owners-report; use -output for the full history")
+	}
+	if *summaryOnly && (*watch || *dotFile != "" || *verbose || *dynamicFields || *ownershipTree) {
+		log.Fatalf("-summary-only cannot be combined with -watch, -dot, -verbose, -dynamic-fields or -ownership-tree")
+	}
+	// Keep stdout clean for a JSON or CSV report
+	quiet := *outputFormat != "text" && *outputFile == ""
+
+	if *maxTransactions < 0 {
+		log.Fatalf("-max-transactions must be >= 0")
+	}
+	if *txPageSize <= 0 {
+		log.Fatalf("-tx-page-size must be > 0")
+	}
+	client.TransactionPageSize = *txPageSize
+
+	if *watch && (*objectID == "" || *objectID == "-" || *ownershipTree) {
+		log.Fatalf("-watch needs a single -object and cannot be used with -ownership-tree")
+	}
+	if *webhookURL != "" && !*watch {
+		log.Fatalf("-webhook-url only applies with -watch")
+	}
+	if *withHistory && *owner == "" {
+		log.Fatalf("-with-history only applies with -owner")
+	}
+
+	// Only now that the flags are known to be usable, so a typo does not
+	// cost two round trips first
+	if compareClient != nil {
+		checkEndpointChains(client, compareClient)
+	}
+
+	if *owner != "" {
+		if *objectID != "" || *objectList != "" || *objectsFile != "" {
+			log.Fatalf("-owner cannot be combined with -object, -objects or -objects-file")
+		}
+		fetchOwnedObjects(client, *owner, *typePattern, *withHistory, *outputFile, *outputDir, *outputFormat, suitrace.HistoryOptions{
+			WithBalances:    *withBalances,
+			WithEvents:      *withEvents,
+			MaxTransactions: *maxTransactions,
+		}, suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, Gzip: *gzipOutput, HumanTime: *humanTime, FlattenOwner: *flattenOwner,
+			Metadata: metadataFor(map[string]interface{}{"owner": *owner, "type": *typePattern})})
+		return
+	}
+
+	if *ownersReport {
+		var ids []string
+		var err error
+		switch {
+		case *objectList != "" || *objectsFile != "":
+			ids, err = readIDs(*objectList, *objectsFile)
+			if err != nil {
+				log.Fatalf("Failed to read object IDs: %v", err)
+			}
+		case *typePattern != "":
+			if !quiet {
+				fmt.Printf("Searching for objects of type: %s\n", *typePattern)
+			}
+			ids, err = client.FindObjectIDsByType(*typePattern, suitrace.DefaultTypeScanLimit)
+			if err != nil {
+				fatalRPC("Failed to find objects by type", err)
+			}
+		default:
+			log.Fatalf("-owners-report needs -objects, -objects-file or -type")
+		}
+		reportOwners(client, ids, *typePattern, *outputFormat, *outputFile, quiet, suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, Gzip: *gzipOutput,
+			Metadata: metadataFor(map[string]interface{}{"objects": ids, "type": *typePattern})})
+		return
+	}
+
+	if *objectList != "" || *objectsFile != "" {
+		ids, err := readIDs(*objectList, *objectsFile)
+		if err != nil {
+			log.Fatalf("Failed to read object IDs: %v", err)
+		}
+		fetchCurrentStates(client, ids, *outputFile, *outputDir, suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, Gzip: *gzipOutput, HumanTime: *humanTime, FlattenOwner: *flattenOwner,
+			Metadata: metadataFor(map[string]interface{}{"objects": ids})})
+		return
+	}
+
+	if *objectID == "" && *typePattern != "" {
+		traceObjectsByType(client, *typePattern, *outputFile, *outputFormat, suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, Gzip: *gzipOutput, HumanTime: *humanTime, FlattenOwner: *flattenOwner,
+			Metadata: metadataFor(map[string]interface{}{"type": *typePattern})})
+		return
+	}
+
+	if *objectID == "" {
+		fmt.Println("Error: Object ID is required")
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *objectID == "-" {
+		ids, err := readIDs("", "-")
+		if err != nil {
+			log.Fatalf("Failed to read object IDs: %v", err)
+		}
+		traceObjects(client, ids, *outputFile, *outputDir, *outputFormat, suitrace.HistoryOptions{
+			WithBalances:    *withBalances,
+			WithEvents:      *withEvents,
+			MaxTransactions: *maxTransactions,
+		}, suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, Gzip: *gzipOutput, HumanTime: *humanTime, FlattenOwner: *flattenOwner,
+			Metadata: metadataFor(map[string]interface{}{"objects": ids})})
+		return
+	}
+
+	normalizedID, err := suitrace.NormalizeSuiAddress(*objectID)
+	if err != nil {
+		log.Fatalf("Invalid object ID: %v", err)
+	}
+	*objectID = normalizedID
+
+	if *ownershipTree {
+		printOwnershipTree(client, *objectID, *depth, *outputFile, suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, Gzip: *gzipOutput,
+			Metadata: metadataFor(map[string]interface{}{"object": *objectID})})
+		return
+	}
+
+	historyOpts := suitrace.HistoryOptions{
+		WithBalances:    *withBalances,
+		WithEvents:      *withEvents,
+		AfterDigest:     *resumeDigest,
+		AfterVersion:    *resumeVersion,
+		MaxTransactions: *maxTransactions,
+	}
+
+	var saved *suitrace.ObjectHistory
+	if *resume != "" {
+		saved, err = suitrace.LoadObjectHistoryJSON(*resume)
+		if err != nil {
+			log.Fatalf("Failed to load -resume history: %v", err)
+		}
+		if len(saved.States) > 0 {
+			latest := saved.States[len(saved.States)-1]
+			if historyOpts.AfterDigest == "" && historyOpts.AfterVersion == 0 {
+				historyOpts.AfterDigest, historyOpts.AfterVersion = latest.PreviousTx, latest.Version
+			}
+		}
+		if *outputFile == "" {
+			*outputFile = *resume
+		}
+	}
+
+	startTime := time.Now()
+	if !quiet {
+		if historyOpts.AfterDigest != "" || historyOpts.AfterVersion > 0 {
+			fmt.Printf("Fetching history for object %s after version %d / transaction %s\n", *objectID, historyOpts.AfterVersion, historyOpts.AfterDigest)
+		} else {
+			fmt.Printf("Fetching history for object: %s\n", *objectID)
+		}
+	}
+
+	history, err := client.FetchObjectHistory(*objectID, historyOpts)
+	if err != nil {
+		fatalRPC("Failed to fetch object history", err)
+	}
+
+	if saved != nil {
+		before := len(saved.States)
+		if err := saved.Merge(history); err != nil {
+			log.Fatalf("Failed to merge with -resume history: %v", err)
+		}
+		if !quiet {
+			fmt.Printf("Merged %d new versions into %d saved ones\n", len(saved.States)-before, before)
+		}
+		history = saved
+	}
+
+	elapsedTime := time.Since(startTime)
+
+	if len(history.States) == 0 {
+		fmt.Println("No object history found!")
+		return
+	}
+
+	if len(suitrace.FilterStatesByType(history.States, *typePattern)) == 0 {
+		fmt.Printf("Object %s is of type %s, which does not match %s - skipping\n",
+			history.ID, history.States[len(history.States)-1].Type, *typePattern)
+		return
+	}
+
+	if *summaryOnly {
+		reportObjectSummary(history, *outputFormat, *outputFile, suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, Gzip: *gzipOutput, HumanTime: *humanTime,
+			Metadata: metadataFor(map[string]interface{}{"object": history.ID})})
+		return
+	}
+
+	fmt.Printf("Fetched %d versions in %s\n", len(history.States), elapsedTime)
+
+	// Print summary
+	suitrace.PrintObjectSummary(history)
+
+	if compareClient != nil {
+		compareHistory(compareClient, history, suitrace.HistoryOptions{
+			WithBalances:    *withBalances,
+			WithEvents:      *withEvents,
+			MaxTransactions: *maxTransactions,
+		})
+	}
+
+	if *dynamicFields {
+		printDynamicFields(client, history.ID)
+	}
+
+	// Save to JSON, or CSV with -format=csv, if output file is specified
+	if *outputFile != "" {
+		opts := suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, Gzip: *gzipOutput, HumanTime: *humanTime, FlattenOwner: *flattenOwner,
+			Metadata: metadataFor(map[string]interface{}{"object": history.ID})}
+		if *outputFormat == "csv" {
+			fmt.Printf("Saving history to CSV file: %s\n", *outputFile)
+			if err := suitrace.SaveObjectHistoryToCSV(history, *outputFile, opts); err != nil {
+				log.Fatalf("Failed to save history to CSV: %v", err)
+			}
+		} else {
+			fmt.Printf("Saving history to JSON file: %s\n", *outputFile)
+			if err := suitrace.SaveObjectHistoryToJSON(history, *outputFile, opts); err != nil {
+				log.Fatalf("Failed to save history to JSON: %v", err)
+			}
+		}
+		fmt.Printf("History saved successfully to %s\n", *outputFile)
+	}
+
+	if *dotFile != "" {
+		if err := suitrace.SaveOwnershipDOT(history, *dotFile); err != nil {
+			log.Fatalf("Failed to save DOT graph: %v", err)
+		}
+		fmt.Printf("Ownership graph saved to %s (render with: dot -Tsvg %s -o graph.svg)\n", *dotFile, *dotFile)
+	}
+
+	if *verbose && len(history.States) > 0 {
+		fmt.Println("\nDetailed state information:")
+		for i, state := range history.States {
+			fmt.Printf("\nState %d (Version %d):\n", i+1, state.Version)
+			fmt.Printf("  Digest: %s\n", state.Digest)
+			fmt.Printf("  Type: %s\n", state.Type)
+			fmt.Printf("  Previous Transaction: %s\n", state.PreviousTx)
+			if state.ChangeKind != "" {
+				fmt.Printf("  Change: %s\n", state.ChangeKind)
+			}
+
+			// Print owner details
+			if state.Owner != nil {
+				ownerBytes, _ := json.MarshalIndent(state.Owner, "  ", "  ")
+				fmt.Printf("  Owner: %s\n", string(ownerBytes))
+			}
+
+			for _, change := range state.BalanceChanges {
+				fmt.Printf("  Balance change: %s %s (owner %s)\n", change.Amount, change.CoinType, suitrace.GetOwnerKey(change.Owner))
+			}
+
+			for _, event := range state.Events {
+				fmt.Printf("  Event: %v\n", event["type"])
+			}
+		}
+	}
+
+	if *watch {
+		var webhook *suitrace.Webhook
+		if *webhookURL != "" {
+			webhook = suitrace.NewWebhook(*webhookURL)
+			webhook.HTTPClient.Timeout = client.HTTPClient.Timeout
+		}
+		watchObject(client, history, *pollInterval, webhook, *outputFile, suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, Gzip: *gzipOutput, HumanTime: *humanTime, FlattenOwner: *flattenOwner,
+			Metadata: metadataFor(map[string]interface{}{"object": history.ID})})
+	}
+}
+
+// Report only the summary of history: saved as JSON to outputFile when set,
+// otherwise printed to stdout as text or JSON
+func reportObjectSummary(history *suitrace.ObjectHistory, format, outputFile string, opts suitrace.WriteOptions) {
+	if outputFile != "" {
+		if err := suitrace.SaveObjectSummaryToJSON(history, outputFile, opts); err != nil {
+			log.Fatalf("Failed to save summary to JSON: %v", err)
+		}
+		fmt.Printf("Summary saved successfully to %s\n", outputFile)
+		return
+	}
+
+	if format == "json" {
+		if err := suitrace.WriteObjectSummaryJSON(os.Stdout, history, opts); err != nil {
+			log.Fatalf("Failed to write summary: %v", err)
+		}
+		return
+	}
+
+	summary := history.Summary()
+	fmt.Printf("Object ID: %s\n", summary.ID)
+	fmt.Printf("Number of versions: %d\n", summary.NumVersions)
+	fmt.Printf("Number of changes: %d\n", summary.NumChanges)
+	fmt.Printf("Number of owners: %d\n", summary.NumOwners)
+	if summary.FirstSeen > 0 {
+		fmt.Printf("First seen: %s\n", suitrace.FormatMillis(summary.FirstSeen))
+	}
+	if summary.LastSeen > 0 {
+		fmt.Printf("Last seen: %s\n", suitrace.FormatMillis(summary.LastSeen))
+	}
+	fmt.Printf("Current type: %s\n", summary.CurrentType)
+	fmt.Printf("Current owner: %s\n", suitrace.GetOwnerKey(summary.CurrentOwner))
+}
+
+// Poll the object until interrupted, printing each new version's changes,
+// notifying webhook when set, and rewriting outputFile, when set, with the
+// grown history
+func watchObject(client *suitrace.Client, history *suitrace.ObjectHistory, interval time.Duration, webhook *suitrace.Webhook, outputFile string, opts suitrace.WriteOptions) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client.PollInterval = interval
+	last := history.States[len(history.States)-1]
+	states, err := client.WatchObject(ctx, history.ID, last.Version)
+	if err != nil {
+		log.Fatalf("Failed to watch object: %v", err)
+	}
+
+	fmt.Printf("\nWatching %s for new versions after %d every %s (Ctrl-C to stop)...\n", history.ID, last.Version, interval)
+
+	for state := range states {
+		change := suitrace.NewObjectChangeNotification(last, state)
+
+		timestamp := "unknown time"
+		if change.Timestamp > 0 {
+			timestamp = suitrace.FormatMillis(change.Timestamp)
+		}
+		fmt.Printf("\nVersion %d -> %d at %s (tx %s)\n", change.OldVersion, change.NewVersion, timestamp, change.Transaction)
+
+		if state.Type != last.Type {
+			fmt.Printf("  type: %s -> %s\n", last.Type, state.Type)
+		}
+		if change.OwnerChanged {
+			fmt.Printf("  owner: %s -> %s\n", suitrace.GetOwnerKey(change.OldOwner), suitrace.GetOwnerKey(change.NewOwner))
+		}
+		for _, c := range change.Changes {
+			fmt.Printf("  %s\n", c)
+		}
+		if len(change.Changes) == 0 {
+			fmt.Println("  content unchanged")
+		}
+
+		if webhook != nil {
+			// A failed delivery is reported, but the watch goes on
+			if err := webhook.Send(ctx, change); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+
+		history.Append(state)
+		last = state
+
+		if outputFile != "" {
+			if err := suitrace.SaveObjectHistoryToJSON(history, outputFile, opts); err != nil {
+				log.Fatalf("Failed to save history to JSON: %v", err)
+			}
+		}
+	}
+
+	fmt.Printf("\nStopped watching after %d versions\n", len(history.States))
+}
+
+// Fetch the object's history from a second endpoint and print where it
+// differs from history
+func compareHistory(compareClient *suitrace.Client, history *suitrace.ObjectHistory, opts suitrace.HistoryOptions) {
+	fmt.Printf("\nFetching history for object %s from %s for comparison\n", history.ID, compareClient.URL)
+
+	historyB, err := compareClient.FetchObjectHistory(history.ID, opts)
+	if err != nil {
+		fatalRPC("Failed to fetch object history for comparison", err)
+	}
+
+	diffs := suitrace.CompareHistories(history, historyB)
+	if len(diffs) == 0 {
+		fmt.Printf("Histories match: %d versions on both endpoints\n", len(history.States))
+		return
+	}
+
+	fmt.Printf("Histories differ in %d places (A = -rpc, B = -rpc-b):\n", len(diffs))
+	for _, diff := range diffs {
+		fmt.Printf("  %s\n", diff)
+	}
+}
+
+// Build the ownership tree around objectID and write it to outputFile, or
+// stdout when empty
+func printOwnershipTree(client *suitrace.Client, objectID string, depth int, outputFile string, opts suitrace.WriteOptions) {
+	if depth < 0 {
+		log.Fatalf("-depth must be >= 0")
+	}
+
+	tree, err := client.BuildOwnershipTree(objectID, depth)
+	if err != nil {
+		fatalRPC("Failed to build ownership tree", err)
+	}
+
+	if outputFile == "" {
+		encoder := json.NewEncoder(os.Stdout)
+		if !opts.Compact {
+			encoder.SetIndent("", "  ")
+		}
+		if err := encoder.Encode(tree); err != nil {
+			log.Fatalf("Failed to write ownership tree: %v", err)
+		}
+		return
+	}
+
+	if err := suitrace.SaveOwnershipTreeToJSON(tree, outputFile, opts); err != nil {
+		log.Fatalf("Failed to save ownership tree: %v", err)
+	}
+	fmt.Printf("Ownership tree of %s saved to %s\n", tree.ObjectID, outputFile)
+}
+
+// Enumerate objects of a Move type and trace each one
+func traceObjectsByType(client *suitrace.Client, structType, outputFile, format string, opts suitrace.WriteOptions) {
+	startTime := time.Now()
+	fmt.Printf("Searching for objects of type: %s\n", structType)
+
+	histories, err := client.FetchObjectsByType(structType)
+	if err != nil {
+		fatalRPC("Failed to fetch objects by type", err)
+	}
+
+	if len(histories) == 0 {
+		fmt.Println("No objects of that type found!")
+		return
+	}
+
+	fmt.Printf("Fetched %d object histories in %s\n", len(histories), time.Since(startTime))
+
+	for _, history := range histories {
+		fmt.Println()
+		suitrace.PrintObjectSummary(history)
+	}
+
+	if outputFile != "" {
+		saveHistories(histories, outputFile, format, opts)
+	}
+}
+
+// Save histories to one file, as CSV for -format=csv and JSON otherwise
+func saveHistories(histories []*suitrace.ObjectHistory, outputFile, format string, opts suitrace.WriteOptions) {
+	if format == "csv" {
+		fmt.Printf("Saving histories to CSV file: %s\n", outputFile)
+		if err := suitrace.SaveObjectHistoriesToCSV(histories, outputFile, opts); err != nil {
+			log.Fatalf("Failed to save histories to CSV: %v", err)
+		}
+	} else {
+		fmt.Printf("Saving histories to JSON file: %s\n", outputFile)
+		if err := suitrace.SaveObjectHistoriesToJSON(histories, outputFile, opts); err != nil {
+			log.Fatalf("Failed to save histories to JSON: %v", err)
+		}
+	}
+	fmt.Printf("Histories saved successfully to %s\n", outputFile)
+}
+
+// Print the dynamic fields currently attached to an object
+func printDynamicFields(client *suitrace.Client, objectID string) {
+	fields, err := client.GetDynamicFields(objectID)
+	if err != nil {
+		fatalRPC("Failed to fetch dynamic fields", err)
+	}
+
+	fmt.Printf("\nDynamic fields: %d\n", len(fields))
+	for _, field := range fields {
+		fmt.Printf("  [%s] %s = %v -> %s (%s, version %d)\n",
+			field.Kind, field.Name.Type, field.Name.Value, field.ObjectID, field.ObjectType, field.Version)
+	}
+}
+
+// Collect object IDs or transaction digests from a comma-separated list and
+// a newline-delimited file. Blank lines and lines starting with # are ignored.
+func readIDs(list, filename string) ([]string, error) {
+	ids := []string{}
+	for _, id := range strings.Split(list, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	if filename != "" {
+		var data []byte
+		var err error
+		if filename == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(filename)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			ids = append(ids, line)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no IDs given")
+	}
+	return ids, nil
+}
+
+// Trace the history of each object in turn, saving it to outputDir when set
+// and all of them together to outputFile (see saveHistories). Objects that
+// fail are reported and skipped; the exit status is non-zero if any did.
+func traceObjects(client *suitrace.Client, ids []string, outputFile, outputDir, format string, historyOpts suitrace.HistoryOptions, opts suitrace.WriteOptions) {
+	var histories []*suitrace.ObjectHistory
+	failed := 0
+	for i, id := range ids {
+		fmt.Printf("\n[%d/%d] Fetching history for object: %s\n", i+1, len(ids), id)
+
+		history, err := client.FetchObjectHistory(id, historyOpts)
+		if err != nil {
+			fmt.Printf("Warning: skipping %s: %v\n", id, err)
+			failed++
+			continue
+		}
+
+		if len(history.States) == 0 {
+			fmt.Println("No object history found!")
+			continue
+		}
+		suitrace.PrintObjectSummary(history)
+		if outputFile != "" {
+			histories = append(histories, history)
+		}
+
+		if outputDir != "" {
+			filename, err := suitrace.SaveObjectHistoryToDir(history, outputDir, opts)
+			if err != nil {
+				log.Fatalf("Failed to save history: %v", err)
+			}
+			fmt.Printf("History saved to %s\n", filename)
+		}
+	}
+
+	if outputFile != "" {
+		fmt.Println()
+		saveHistories(histories, outputFile, format, opts)
+	}
+
+	fmt.Printf("\nTraced %d of %d objects\n", len(ids)-failed, len(ids))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// Fetch the current owners of ids, keeping objects whose type matches
+// typePattern, and report how many objects each owner holds. The report is
+// saved to outputFile when set (as CSV for -format=csv, JSON otherwise), or
+// printed in format.
+func reportOwners(client *suitrace.Client, ids []string, typePattern, format, outputFile string, quiet bool, opts suitrace.WriteOptions) {
+	if !quiet {
+		fmt.Printf("Fetching current owners of %d objects\n", len(ids))
+	}
+
+	states, err := client.MultiGetObjects(ids)
+	if err != nil {
+		fatalRPC("Failed to fetch objects", err)
+	}
+
+	matching := []*suitrace.ObjectState{}
+	for _, state := range states {
+		if suitrace.TypeMatches(state.Type, typePattern) {
+			matching = append(matching, state)
+		}
+	}
+	owners := suitrace.CountOwners(matching)
+
+	switch {
+	case outputFile != "" && format == "csv":
+		err = suitrace.SaveOwnerCountsToCSV(owners, outputFile, opts)
+	case outputFile != "":
+		err = suitrace.SaveOwnerCountsToJSON(owners, outputFile, opts)
+	case format == "csv":
+		err = suitrace.WriteOwnerCountsCSV(os.Stdout, owners)
+	case format == "json":
+		err = suitrace.WriteOwnerCountsJSON(os.Stdout, owners, opts)
+	default:
+		fmt.Printf("%d objects held by %d owners:\n", len(matching), len(owners))
+		for _, owner := range owners {
+			fmt.Printf("  %6d  %-9s  %s\n", owner.Count, owner.Kind, owner.Owner)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Failed to save owner report: %v", err)
+	}
+	if outputFile != "" {
+		fmt.Printf("Owner report of %d objects saved to %s\n", len(matching), outputFile)
+	}
+}
+
+// Fetch the current state of many objects and save them as one array or one file per object
+func fetchCurrentStates(client *suitrace.Client, ids []string, outputFile, outputDir string, opts suitrace.WriteOptions) {
+	startTime := time.Now()
+	fmt.Printf("Fetching current state of %d objects\n", len(ids))
+
+	states, err := client.MultiGetObjects(ids)
+	if err != nil {
+		fatalRPC("Failed to fetch objects", err)
+	}
+
+	fmt.Printf("Fetched %d objects in %s\n", len(states), time.Since(startTime))
+	saveStates(states, outputFile, outputDir, opts)
+}
+
+// List the objects owned by address, optionally restricted to a type, and
+// either save their current states or trace each one's full history
+func fetchOwnedObjects(client *suitrace.Client, address, typePattern string, withHistory bool, outputFile, outputDir, format string, historyOpts suitrace.HistoryOptions, opts suitrace.WriteOptions) {
+	startTime := time.Now()
+	fmt.Printf("Fetching objects owned by: %s\n", address)
+
+	owned, err := client.GetOwnedObjectsWithOptions(address, suitrace.OwnedObjectsOptions{
+		ShowContent: !withHistory,
+		Type:        typePattern,
+	})
+	if err != nil {
+		fatalRPC("Failed to fetch owned objects", err)
+	}
+
+	fmt.Printf("Found %d objects in %s\n", len(owned), time.Since(startTime))
+	if len(owned) == 0 {
+		return
+	}
+
+	if withHistory {
+		ids := make([]string, len(owned))
+		for i, state := range owned {
+			ids[i] = state.ObjectID
+		}
+		traceObjects(client, ids, outputFile, outputDir, format, historyOpts, opts)
+		return
+	}
+
+	states := make([]*suitrace.ObjectState, len(owned))
+	for i := range owned {
+		states[i] = &owned[i]
+	}
+	saveStates(states, outputFile, outputDir, opts)
+}
+
+// Save states as one array or one file per object, or list them on stdout
+func saveStates(states []*suitrace.ObjectState, outputFile, outputDir string, opts suitrace.WriteOptions) {
+	switch {
+	case outputDir != "":
+		files, err := suitrace.SaveObjectStatesToDir(states, outputDir, opts)
+		if err != nil {
+			log.Fatalf("Failed to save objects: %v", err)
+		}
+		fmt.Printf("Saved %d object files to %s\n", len(files), outputDir)
+	case outputFile != "":
+		if err := suitrace.SaveObjectStatesToJSON(states, outputFile, opts); err != nil {
+			log.Fatalf("Failed to save objects: %v", err)
+		}
+		fmt.Printf("Objects saved successfully to %s\n", outputFile)
+	default:
+		for _, state := range states {
+			fmt.Printf("%s  version %d  %s  owner %s\n", state.ObjectID, state.Version, state.Type, suitrace.GetOwnerKey(state.Owner))
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	suitrace "github.com/VeerChaurasia/SuiTrace"
+)
+
+func runTx(client *suitrace.Client, args []string) {
+	fs := flag.NewFlagSet("tx", flag.ExitOnError)
+	digestList := fs.String("digests", "", "Comma-separated transaction digests to fetch (digests may also be given as arguments)")
+	digestsFile := fs.String("digests-file", "", "File of newline-delimited transaction digests to fetch (- for stdin)")
+	show := fs.String("show", "input,effects", "Comma-separated parts of each transaction to fetch: input, rawInput, effects, events, objectChanges, balanceChanges")
+	outputFormat := fs.String("format", "json", "Output format (json or csv)")
+	outputFile := fs.String("output", "transactions.json", "Output filename")
+	compact := fs.Bool("compact", false, "Write JSON without indentation")
+	canonical := fs.Bool("canonical", false, "Write JSON with every object's keys sorted, for storing exports in git and diffing runs")
+	gzipOutput := fs.Bool("gzip", false, "Gzip-compress the output (implied by a .gz filename)")
+	humanTime := fs.Bool("human-time", false, "Add a Timestamp column next to TimestampMs in CSV output")
+	fromAddress := fs.String("from-address", "", "Instead of digests, query every transaction sent by this address")
+	toAddress := fs.String("to-address", "", "Instead of digests, query every transaction that sent objects to this address")
+	filterJSON := fs.String("filter", "", "Instead of digests, query transactions matching this suix_queryTransactionBlocks filter, as JSON (e.g. '{\"InputObject\":\"0x...\"}')")
+	limit := fs.Int("limit", 0, "With a query, stop after this many transactions (0 for all)")
+	descending := fs.Bool("descending", false, "With a query, fetch the newest transactions first")
+	fs.Parse(args)
+
+	filter, err := transactionFilter(*fromAddress, *toAddress, *filterJSON)
+	if err != nil {
+		log.Fatalf("Invalid transaction query: %v", err)
+	}
+	if *limit < 0 {
+		log.Fatalf("-limit must be >= 0")
+	}
+
+	if filter == nil && (*limit > 0 || *descending) {
+		log.Fatalf("-limit and -descending only apply with -from-address, -to-address or -filter")
+	}
+
+	var digests []string
+	if filter == nil {
+		list := strings.Join(append([]string{*digestList}, fs.Args()...), ",")
+		digests, err = readIDs(list, *digestsFile)
+		if err != nil {
+			log.Fatalf("Failed to read transaction digests: %v", err)
+		}
+	} else if *digestList != "" || *digestsFile != "" || fs.NArg() > 0 {
+		log.Fatalf("-from-address, -to-address and -filter cannot be combined with digests")
+	}
+
+	showOpts, err := suitrace.ParseTransactionBlockOptions(*show)
+	if err != nil {
+		log.Fatalf("Invalid -show: %v", err)
+	}
+
+	opts := suitrace.WriteOptions{Compact: *compact, Canonical: *canonical, Gzip: *gzipOutput, HumanTime: *humanTime}
+	if filter != nil {
+		opts.Metadata = metadataFor(map[string]interface{}{"filter": filter, "limit": *limit, "descending": *descending})
+	} else {
+		opts.Metadata = metadataFor(map[string]interface{}{"digests": digests})
+	}
+	var save func([]map[string]interface{}, string, suitrace.WriteOptions) ([]string, error)
+	switch *outputFormat {
+	case "json":
+		if *humanTime {
+			log.Fatalf("-human-time only applies to -format=csv")
+		}
+		save = suitrace.SaveTransactionBlocksToJSON
+	case "csv":
+		// The summary columns come from the input and effects
+		showOpts.ShowInput, showOpts.ShowEffects = true, true
+		save = suitrace.SaveTransactionBlocksToCSV
+	default:
+		log.Fatalf("Unsupported output format: %s", *outputFormat)
+	}
+
+	startTime := time.Now()
+	var blocks []map[string]interface{}
+	var fetchErr error
+	if filter != nil {
+		fmt.Printf("Querying transactions matching %v\n", filter)
+		blocks, fetchErr = client.QueryTransactionBlocks(filter, suitrace.TransactionQueryOptions{
+			Show:       showOpts,
+			Descending: *descending,
+			Limit:      *limit,
+		})
+	} else {
+		fmt.Printf("Fetching %d transactions\n", len(digests))
+		blocks, fetchErr = client.GetTransactionBlocks(digests, showOpts)
+	}
+	if fetchErr != nil {
+		if len(blocks) == 0 {
+			fatalRPC("Failed to fetch transactions", fetchErr)
+		}
+		fmt.Printf("Warning: %v\n", fetchErr)
+	}
+
+	fmt.Printf("Fetched %d transactions in %s\n", len(blocks), time.Since(startTime))
+
+	if _, err := save(blocks, *outputFile, opts); err != nil {
+		log.Fatalf("Failed to save transactions: %v", err)
+	}
+	fmt.Printf("Transactions saved successfully to %s\n", *outputFile)
+
+	if fetchErr != nil {
+		os.Exit(1)
+	}
+}
+
+// Build the transaction query filter from the -from-address, -to-address and
+// -filter flags, of which at most one may be set. Nil means no query.
+func transactionFilter(fromAddress, toAddress, filterJSON string) (map[string]interface{}, error) {
+	set := 0
+	for _, v := range []string{fromAddress, toAddress, filterJSON} {
+		if v != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("use only one of -from-address, -to-address and -filter")
+	}
+
+	var filter map[string]interface{}
+	switch {
+	case fromAddress != "":
+		address, err := suitrace.NormalizeSuiAddress(fromAddress)
+		if err != nil {
+			return nil, err
+		}
+		filter = map[string]interface{}{"FromAddress": address}
+	case toAddress != "":
+		address, err := suitrace.NormalizeSuiAddress(toAddress)
+		if err != nil {
+			return nil, err
+		}
+		filter = map[string]interface{}{"ToAddress": address}
+	case filterJSON != "":
+		if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+			return nil, fmt.Errorf("-filter is not a JSON object: %w", err)
+		}
+		if filter == nil {
+			return nil, fmt.Errorf("-filter must not be null")
+		}
+	default:
+		return nil, nil
+	}
+
+	return filter, suitrace.ValidateTransactionFilter(filter)
+}
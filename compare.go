@@ -0,0 +1,156 @@
+package suitrace
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// A difference between two histories of the same object at one version.
+// Field is "missing" when only one history has the version, otherwise
+// "type", "owner" or "content". A and B hold the two sides' values, nil
+// for the side without the version.
+type HistoryDiff struct {
+	Version uint64      `json:"version,string"`
+	Field   string      `json:"field"`
+	A       interface{} `json:"a"`
+	B       interface{} `json:"b"`
+}
+
+func (d HistoryDiff) String() string {
+	return fmt.Sprintf("version %d %s: %s != %s", d.Version, d.Field, diffValue(d.A), diffValue(d.B))
+}
+
+// Render a diff value compactly for printing
+func diffValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "<absent>"
+	case string:
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+// Compare two histories of an object version by version, e.g. fetched from
+// two networks, and return their differences in type, owner and content,
+// ordered by version
+func CompareHistories(a, b *ObjectHistory) []HistoryDiff {
+	statesA := statesByVersion(a)
+	statesB := statesByVersion(b)
+
+	var versions []uint64
+	for version := range statesA {
+		versions = append(versions, version)
+	}
+	for version := range statesB {
+		if _, ok := statesA[version]; !ok {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	var diffs []HistoryDiff
+	for _, version := range versions {
+		stateA, okA := statesA[version]
+		stateB, okB := statesB[version]
+
+		if !okA {
+			diffs = append(diffs, HistoryDiff{Version: version, Field: "missing", B: stateB.Digest})
+			continue
+		}
+		if !okB {
+			diffs = append(diffs, HistoryDiff{Version: version, Field: "missing", A: stateA.Digest})
+			continue
+		}
+
+		if stateA.Type != stateB.Type {
+			diffs = append(diffs, HistoryDiff{Version: version, Field: "type", A: stateA.Type, B: stateB.Type})
+		}
+		if GetOwnerKey(stateA.Owner) != GetOwnerKey(stateB.Owner) {
+			diffs = append(diffs, HistoryDiff{Version: version, Field: "owner", A: stateA.Owner, B: stateB.Owner})
+		}
+		if !sameJSON(stateA.Content, stateB.Content) {
+			diffs = append(diffs, HistoryDiff{Version: version, Field: "content", A: stateA.Content, B: stateB.Content})
+		}
+	}
+
+	return diffs
+}
+
+func statesByVersion(history *ObjectHistory) map[uint64]*ObjectState {
+	states := make(map[uint64]*ObjectState)
+	if history == nil {
+		return states
+	}
+	for i := range history.States {
+		states[history.States[i].Version] = &history.States[i]
+	}
+	return states
+}
+
+// Compare values by their JSON encoding, so json.Number and float64
+// decodings of the same number are equal
+func sameJSON(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+// A field of an object's content that differs between two states. Path is
+// the dotted path to the field, e.g. "fields.balance"; Old or New is nil
+// when the field is only on one side.
+type ContentChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+func (c ContentChange) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, diffValue(c.Old), diffValue(c.New))
+}
+
+// Compare two versions of an object's content field by field, descending
+// into nested objects, and return the changed leaves ordered by path
+func DiffContent(before, after map[string]interface{}) []ContentChange {
+	changes := []ContentChange{}
+	diffContent("", before, after, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diffContent(prefix string, before, after map[string]interface{}, changes *[]ContentChange) {
+	keys := map[string]bool{}
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		oldValue, inOld := before[key]
+		newValue, inNew := after[key]
+
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			diffContent(path, oldMap, newMap, changes)
+			continue
+		}
+
+		if inOld && inNew && sameJSON(oldValue, newValue) {
+			continue
+		}
+		*changes = append(*changes, ContentChange{Path: path, Old: oldValue, New: newValue})
+	}
+}
@@ -0,0 +1,88 @@
+package suitrace
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCompareHistories(t *testing.T) {
+	owner := func(addr string) map[string]interface{} {
+		return map[string]interface{}{"AddressOwner": addr}
+	}
+
+	a := &ObjectHistory{States: []ObjectState{
+		{Version: 1, Digest: "a1", Type: "0x2::coin::Coin<0x2::sui::SUI>", Owner: owner("0x1"), Content: map[string]interface{}{"balance": json.Number("10")}},
+		{Version: 2, Digest: "a2", Type: "0x2::coin::Coin<0x2::sui::SUI>", Owner: owner("0x1"), Content: map[string]interface{}{"balance": json.Number("20")}},
+		{Version: 3, Digest: "a3", Type: "0x2::coin::Coin<0x2::sui::SUI>", Owner: owner("0x1")},
+	}}
+	b := &ObjectHistory{States: []ObjectState{
+		{Version: 1, Digest: "b1", Type: "0x2::coin::Coin<0x2::sui::SUI>", Owner: owner("0x1"), Content: map[string]interface{}{"balance": float64(10)}},
+		{Version: 2, Digest: "b2", Type: "0x2::coin::Coin<0x2::sui::SUI>", Owner: owner("0x2"), Content: map[string]interface{}{"balance": json.Number("25")}},
+		{Version: 4, Digest: "b4", Type: "0x2::coin::Coin<0x2::sui::SUI>", Owner: owner("0x2")},
+	}}
+
+	want := []HistoryDiff{
+		{Version: 2, Field: "owner", A: owner("0x1"), B: owner("0x2")},
+		{Version: 2, Field: "content", A: map[string]interface{}{"balance": json.Number("20")}, B: map[string]interface{}{"balance": json.Number("25")}},
+		{Version: 3, Field: "missing", A: "a3"},
+		{Version: 4, Field: "missing", B: "b4"},
+	}
+
+	got := CompareHistories(a, b)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("CompareHistories() =\n%v\nwant\n%v", got, want)
+	}
+
+	if diffs := CompareHistories(a, a); len(diffs) != 0 {
+		t.Errorf("CompareHistories(a, a) = %v, want no differences", diffs)
+	}
+
+	if got, want := want[0].String(), `version 2 owner: {"AddressOwner":"0x1"} != {"AddressOwner":"0x2"}`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := want[3].String(), "version 4 missing: <absent> != b4"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestDiffContent(t *testing.T) {
+	before := map[string]interface{}{
+		"dataType": "moveObject",
+		"fields": map[string]interface{}{
+			"balance": "10",
+			"name":    "coin",
+			"meta":    map[string]interface{}{"level": json.Number("1")},
+			"old":     true,
+		},
+	}
+	after := map[string]interface{}{
+		"dataType": "moveObject",
+		"fields": map[string]interface{}{
+			"balance": "25",
+			"name":    "coin",
+			"meta":    map[string]interface{}{"level": float64(1)},
+			"added":   "x",
+		},
+	}
+
+	got := DiffContent(before, after)
+	want := []ContentChange{
+		{Path: "fields.added", New: "x"},
+		{Path: "fields.balance", Old: "10", New: "25"},
+		{Path: "fields.old", Old: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DiffContent = %v, want %v", got, want)
+	}
+
+	if got := want[1].String(); got != "fields.balance: 10 -> 25" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := want[0].String(); got != "fields.added: <absent> -> x" {
+		t.Errorf("String() = %q", got)
+	}
+	if diffs := DiffContent(before, before); len(diffs) != 0 {
+		t.Errorf("identical content: got %v", diffs)
+	}
+}
@@ -0,0 +1,95 @@
+package suitrace
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Node ID of the point an object's history starts from in DOT graphs
+const dotStartNode = "created"
+
+// Node ID and label for an owner in a DOT graph, and whether the owner is
+// terminal: shared and immutable objects never change owner again
+func dotOwnerNode(owner map[string]interface{}) (id, label string, terminal bool) {
+	kind, ownerID := ClassifyOwner(owner)
+	switch kind {
+	case OwnerKindAddress:
+		return ownerID, shortID(ownerID), false
+	case OwnerKindObject:
+		return ownerID, "object " + shortID(ownerID), false
+	case OwnerKindShared:
+		return "Shared", "Shared", true
+	case OwnerKindImmutable:
+		return "Immutable", "Immutable", true
+	}
+	key := GetOwnerKey(owner)
+	return key, key, false
+}
+
+// Abbreviate a 0x-prefixed ID to its first and last four hex digits
+func shortID(id string) string {
+	if len(id) <= 14 {
+		return id
+	}
+	return id[:6] + "…" + id[len(id)-4:]
+}
+
+// Write the ownership timeline of history as a Graphviz DOT digraph. Nodes
+// are owners and edges are transfers labeled with the version and time they
+// took effect. Shared and Immutable are drawn as terminal nodes.
+func WriteOwnershipDOT(w io.Writer, history *ObjectHistory) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "digraph %s {\n", strconv.Quote(history.ID))
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [shape=box];")
+	fmt.Fprintf(bw, "  %s [shape=point];\n", strconv.Quote(dotStartNode))
+
+	declared := map[string]bool{}
+	for _, change := range OwnershipChanges(history) {
+		from := dotStartNode
+		if change.From != nil {
+			from, _, _ = dotOwnerNode(change.From)
+		}
+
+		to, label, terminal := dotOwnerNode(change.To)
+		if !declared[to] {
+			declared[to] = true
+			attrs := "label=" + strconv.Quote(label)
+			if terminal {
+				attrs += ", shape=doubleoctagon"
+			}
+			fmt.Fprintf(bw, "  %s [%s];\n", strconv.Quote(to), attrs)
+		}
+
+		edgeLabel := fmt.Sprintf("v%d", change.Version)
+		if ts := FormatMillis(change.Timestamp); ts != "" {
+			edgeLabel += "\n" + ts
+		}
+		fmt.Fprintf(bw, "  %s -> %s [label=%s];\n", strconv.Quote(from), strconv.Quote(to), strconv.Quote(edgeLabel))
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// Save the ownership timeline of history as a DOT file
+func SaveOwnershipDOT(history *ObjectHistory, filename string) error {
+	file, err := createOutputFile(filename, WriteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create DOT file: %w", err)
+	}
+	defer file.discard()
+
+	if err := WriteOwnershipDOT(file, history); err != nil {
+		return fmt.Errorf("failed to write DOT data: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close DOT file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package suitrace
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteOwnershipDOT(t *testing.T) {
+	alice := "0x00000000000000000000000000000000000000000000000000000000000a11ce"
+	bob := "0x0000000000000000000000000000000000000000000000000000000000000b0b"
+
+	history := &ObjectHistory{
+		ID: testObjectID,
+		States: []ObjectState{
+			{Version: 1, Owner: map[string]interface{}{"AddressOwner": alice}, Timestamp: 1700000000000},
+			{Version: 2, Owner: map[string]interface{}{"AddressOwner": alice}},
+			{Version: 3, Owner: map[string]interface{}{"AddressOwner": bob}, Timestamp: 1700000060000},
+			{Version: 4, Owner: map[string]interface{}{"Shared": map[string]interface{}{"initial_shared_version": "4"}}},
+		},
+	}
+
+	changes := OwnershipChanges(history)
+	if len(changes) != 3 {
+		t.Fatalf("got %d ownership changes, want 3: %+v", len(changes), changes)
+	}
+	if changes[0].From != nil || changes[1].Version != 3 || changes[1].From["AddressOwner"] != alice {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+
+	var out strings.Builder
+	if err := WriteOwnershipDOT(&out, history); err != nil {
+		t.Fatalf("WriteOwnershipDOT: %v", err)
+	}
+	dot := out.String()
+
+	for _, want := range []string{
+		`digraph "` + testObjectID + `" {`,
+		`"created" [shape=point];`,
+		`"` + alice + `" [label="0x0000…11ce"];`,
+		`"Shared" [label="Shared", shape=doubleoctagon];`,
+		`"created" -> "` + alice + `" [label="v1\n2023-11-14T22:13:20.000Z"];`,
+		`"` + alice + `" -> "` + bob + `" [label="v3\n2023-11-14T22:14:20.000Z"];`,
+		`"` + bob + `" -> "Shared" [label="v4"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %s\n%s", want, dot)
+		}
+	}
+	if strings.Count(dot, "->") != 3 {
+		t.Errorf("want 3 edges, got:\n%s", dot)
+	}
+}
+
+func TestParseOwnerImmutable(t *testing.T) {
+	state := parseObjectData(map[string]interface{}{"owner": "Immutable"})
+	if _, ok := state.Owner["Immutable"]; !ok {
+		t.Errorf("owner = %v, want Immutable", state.Owner)
+	}
+	if _, _, terminal := dotOwnerNode(state.Owner); !terminal {
+		t.Error("Immutable should be a terminal node")
+	}
+}
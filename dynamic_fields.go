@@ -0,0 +1,122 @@
+package suitrace
+
+import (
+	"fmt"
+)
+
+// Kinds of dynamic field reported by suix_getDynamicFields
+const (
+	DynamicFieldKindField  = "DynamicField"  // Value stored inline in the field object
+	DynamicFieldKindObject = "DynamicObject" // Value is a standalone object
+)
+
+// Name of a dynamic field, as a Move type and its JSON value
+type DynamicFieldName struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// One entry from suix_getDynamicFields
+type DynamicFieldInfo struct {
+	Name       DynamicFieldName `json:"name"`
+	Kind       string           `json:"type"`
+	ObjectType string           `json:"objectType"`
+	ObjectID   string           `json:"objectId"`
+	Version    uint64           `json:"version,string"`
+	Digest     string           `json:"digest"`
+}
+
+// List every dynamic field of parentID, following the pagination cursor
+func (c *Client) GetDynamicFields(parentID string) ([]DynamicFieldInfo, error) {
+	parentID, err := NormalizeSuiAddress(parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []DynamicFieldInfo{}
+	var cursor interface{}
+
+	for {
+		result, err := c.MakeRPCCall("suix_getDynamicFields", []interface{}{parentID, cursor, nil})
+		if err != nil {
+			return fields, fmt.Errorf("failed to get dynamic fields: %w", err)
+		}
+
+		resultObj, _ := result["result"].(map[string]interface{})
+		data, _ := resultObj["data"].([]interface{})
+		for _, entry := range data {
+			entryObj, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fields = append(fields, parseDynamicFieldInfo(entryObj))
+		}
+
+		hasNext, _ := resultObj["hasNextPage"].(bool)
+		if !hasNext || resultObj["nextCursor"] == nil || len(data) == 0 {
+			break
+		}
+		cursor = resultObj["nextCursor"]
+	}
+
+	c.DebugPrint("Found %d dynamic fields for object %s", len(fields), parentID)
+	return fields, nil
+}
+
+// Extract a dynamic field entry
+func parseDynamicFieldInfo(entry map[string]interface{}) DynamicFieldInfo {
+	info := DynamicFieldInfo{}
+
+	if name, ok := entry["name"].(map[string]interface{}); ok {
+		if nameType, ok := name["type"].(string); ok {
+			info.Name.Type = nameType
+		}
+		info.Name.Value = name["value"]
+	}
+
+	if kind, ok := entry["type"].(string); ok {
+		info.Kind = kind
+	}
+
+	if objType, ok := entry["objectType"].(string); ok {
+		info.ObjectType = objType
+	}
+
+	if objID, ok := entry["objectId"].(string); ok {
+		info.ObjectID = objID
+	}
+
+	if version, err := parseU64(entry["version"]); err == nil {
+		info.Version = version
+	}
+
+	if digest, ok := entry["digest"].(string); ok {
+		info.Digest = digest
+	}
+
+	return info
+}
+
+// Fetch the object holding the value of the dynamic field name on parentID.
+// For DynamicField kinds the content is the Field<Name, Value> wrapper; for
+// DynamicObject kinds it is the stored object itself.
+func (c *Client) GetDynamicFieldObject(parentID string, name DynamicFieldName) (*ObjectState, error) {
+	parentID, err := NormalizeSuiAddress(parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.MakeRPCCall("suix_getDynamicFieldObject", []interface{}{parentID, name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dynamic field object: %w", err)
+	}
+
+	resultObj, _ := result["result"].(map[string]interface{})
+	data, ok := resultObj["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("dynamic field %v on object %s: %w", name.Value, parentID, objectNotFoundError(parentID, resultObj["error"]))
+	}
+
+	state := parseObjectData(data)
+	return &state, nil
+}
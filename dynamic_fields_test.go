@@ -0,0 +1,99 @@
+package suitrace
+
+import (
+	"testing"
+)
+
+func TestGetDynamicFieldsPaginates(t *testing.T) {
+	pages := map[interface{}]mockResponse{
+		nil: {Result: map[string]interface{}{
+			"data": []interface{}{
+				map[string]interface{}{
+					"name":       map[string]interface{}{"type": "u64", "value": "1"},
+					"bcsName":    "2",
+					"type":       "DynamicField",
+					"objectType": "u64",
+					"objectId":   "0xaa",
+					"version":    "10",
+					"digest":     "digestA",
+				},
+			},
+			"nextCursor":  "0xaa",
+			"hasNextPage": true,
+		}},
+		"0xaa": {Result: map[string]interface{}{
+			"data": []interface{}{
+				map[string]interface{}{
+					"name":       map[string]interface{}{"type": "0x2::object::ID", "value": "0xbb"},
+					"type":       "DynamicObject",
+					"objectType": "0x2::coin::Coin<0x2::sui::SUI>",
+					"objectId":   "0xbb",
+					"version":    "18446744073709551615",
+					"digest":     "digestB",
+				},
+			},
+			"nextCursor":  "0xbb",
+			"hasNextPage": false,
+		}},
+	}
+
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_getDynamicFields": func(params []interface{}) mockResponse {
+			if len(params) != 3 || params[0] != testObjectID {
+				t.Errorf("unexpected params: %v", params)
+			}
+			resp, ok := pages[params[1]]
+			if !ok {
+				t.Errorf("unexpected cursor: %v", params[1])
+			}
+			return resp
+		},
+	})
+
+	fields, err := client.GetDynamicFields(testObjectID)
+	if err != nil {
+		t.Fatalf("GetDynamicFields: %v", err)
+	}
+	if len(fields) != 2 {
+		t.Fatalf("got %d fields, want 2", len(fields))
+	}
+
+	if fields[0].Kind != DynamicFieldKindField || fields[0].Name.Type != "u64" || fields[0].Version != 10 {
+		t.Errorf("unexpected first field: %+v", fields[0])
+	}
+	if fields[1].Kind != DynamicFieldKindObject || fields[1].ObjectID != "0xbb" || fields[1].Version != 18446744073709551615 {
+		t.Errorf("unexpected second field: %+v", fields[1])
+	}
+}
+
+func TestGetDynamicFieldObject(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_getDynamicFieldObject": func(params []interface{}) mockResponse {
+			name, _ := params[1].(map[string]interface{})
+			if params[0] != testObjectID || name["type"] != "u64" || name["value"] != "1" {
+				t.Errorf("unexpected params: %v", params)
+			}
+			return fixture(t, "object.json")
+		},
+	})
+
+	state, err := client.GetDynamicFieldObject(testObjectID, DynamicFieldName{Type: "u64", Value: "1"})
+	if err != nil {
+		t.Fatalf("GetDynamicFieldObject: %v", err)
+	}
+	if state.Version != 421337 {
+		t.Errorf("got version %d, want 421337", state.Version)
+	}
+}
+
+func TestGetDynamicFieldObjectNotFound(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_getDynamicFieldObject": respond(mockResponse{Result: map[string]interface{}{
+			"error": map[string]interface{}{"code": "dynamicFieldNotFound"},
+		}}),
+	})
+
+	if _, err := client.GetDynamicFieldObject(testObjectID, DynamicFieldName{Type: "u64", Value: "7"}); err == nil {
+		t.Fatal("expected an error for a missing dynamic field")
+	}
+}
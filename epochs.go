@@ -0,0 +1,165 @@
+package suitrace
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+)
+
+// Checkpoints, transactions and gas costs of one epoch within a fetched range
+type EpochSummary struct {
+	Epoch           int64 `json:"epoch,string"`
+	FirstCheckpoint int64 `json:"firstCheckpoint,string"`
+	LastCheckpoint  int64 `json:"lastCheckpoint,string"`
+	Checkpoints     int   `json:"checkpoints"`
+	Transactions    int64 `json:"transactions"`
+
+	// Gas spent between the first and last checkpoint. Rolling summaries are
+	// cumulative within an epoch, so this is the last checkpoint's summary
+	// minus the first's, or the last's alone when the range includes the
+	// epoch's first checkpoint (FromEpochStart).
+	GasCost        GasCostSummary `json:"gasCost"`
+	FromEpochStart bool           `json:"fromEpochStart"`
+}
+
+// Groups checkpoints by epoch as they are added, for slices and streams
+// alike. Checkpoints must be added in sequence order.
+type EpochAggregator struct {
+	epochs []*epochState
+	last   *CheckpointData
+}
+
+type epochState struct {
+	summary  EpochSummary
+	baseline *GasCostSummary // Rolling summary the costs are counted from
+	latest   *GasCostSummary
+}
+
+func NewEpochAggregator() *EpochAggregator {
+	return &EpochAggregator{}
+}
+
+// Add the next checkpoint
+func (a *EpochAggregator) Add(cp CheckpointData) {
+	var state *epochState
+	if n := len(a.epochs); n > 0 && a.epochs[n-1].summary.Epoch == cp.Epoch {
+		state = a.epochs[n-1]
+	} else {
+		state = &epochState{summary: EpochSummary{Epoch: cp.Epoch, FirstCheckpoint: cp.SequenceNumber}}
+		// The previous checkpoint closed the last epoch, so this one opens its epoch
+		startsEpoch := cp.SequenceNumber == 0 ||
+			(a.last != nil && a.last.SequenceNumber == cp.SequenceNumber-1 && a.last.Epoch < cp.Epoch)
+		if startsEpoch {
+			state.summary.FromEpochStart = true
+			state.baseline = &GasCostSummary{}
+		}
+		a.epochs = append(a.epochs, state)
+	}
+
+	state.summary.LastCheckpoint = cp.SequenceNumber
+	state.summary.Checkpoints++
+	state.summary.Transactions += int64(len(cp.TransactionDigests))
+	if gas := cp.EpochRollingGasCostSummary; gas != nil {
+		if state.baseline == nil {
+			state.baseline = gas
+		}
+		state.latest = gas
+	}
+
+	a.last = &cp
+}
+
+// One summary per epoch seen, in order
+func (a *EpochAggregator) Summaries() []EpochSummary {
+	summaries := make([]EpochSummary, len(a.epochs))
+	for i, state := range a.epochs {
+		summary := state.summary
+		if state.baseline != nil && state.latest != nil {
+			summary.GasCost = GasCostSummary{
+				ComputationCost:         state.latest.ComputationCost - state.baseline.ComputationCost,
+				StorageCost:             state.latest.StorageCost - state.baseline.StorageCost,
+				StorageRebate:           state.latest.StorageRebate - state.baseline.StorageRebate,
+				NonRefundableStorageFee: state.latest.NonRefundableStorageFee - state.baseline.NonRefundableStorageFee,
+			}
+		}
+		summaries[i] = summary
+	}
+	return summaries
+}
+
+// Group checkpoints, sorted by sequence number, into one summary per epoch
+func AggregateEpochs(checkpoints []CheckpointData) []EpochSummary {
+	aggregator := NewEpochAggregator()
+	for _, cp := range checkpoints {
+		aggregator.Add(cp)
+	}
+	return aggregator.Summaries()
+}
+
+// Header of epoch CSV exports
+var epochCSVHeader = []string{
+	"Epoch", "FirstCheckpoint", "LastCheckpoint", "Checkpoints", "Transactions",
+	"ComputationCost", "StorageCost", "StorageRebate", "NonRefundableStorageFee", "FromEpochStart",
+}
+
+// Save epoch summaries to CSV
+func SaveEpochsToCSV(epochs []EpochSummary, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.discard()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(epochCSVHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, epoch := range epochs {
+		record := []string{
+			strconv.FormatInt(epoch.Epoch, 10),
+			strconv.FormatInt(epoch.FirstCheckpoint, 10),
+			strconv.FormatInt(epoch.LastCheckpoint, 10),
+			strconv.Itoa(epoch.Checkpoints),
+			strconv.FormatInt(epoch.Transactions, 10),
+			strconv.FormatInt(epoch.GasCost.ComputationCost, 10),
+			strconv.FormatInt(epoch.GasCost.StorageCost, 10),
+			strconv.FormatInt(epoch.GasCost.StorageRebate, 10),
+			strconv.FormatInt(epoch.GasCost.NonRefundableStorageFee, 10),
+			strconv.FormatBool(epoch.FromEpochStart),
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write record to CSV: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close CSV file: %w", err)
+	}
+
+	return saveMetadataSidecar(filename, opts)
+}
+
+// Save epoch summaries to a JSON array
+func SaveEpochsToJSON(epochs []EpochSummary, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create JSON file: %w", err)
+	}
+	defer file.discard()
+
+	if err := writeJSONArray(file, epochs, opts); err != nil {
+		return fmt.Errorf("failed to write JSON data: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close JSON file: %w", err)
+	}
+
+	return nil
+}
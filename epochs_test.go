@@ -0,0 +1,88 @@
+package suitrace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAggregateEpochs(t *testing.T) {
+	cp := func(seq, epoch int64, txs int, computation int64) CheckpointData {
+		return CheckpointData{
+			SequenceNumber:     seq,
+			Epoch:              epoch,
+			TransactionDigests: make([]string, txs),
+			EpochRollingGasCostSummary: &GasCostSummary{
+				ComputationCost: computation,
+				StorageCost:     2 * computation,
+				StorageRebate:   computation / 2,
+			},
+		}
+	}
+
+	// Starts mid-epoch 7, covers all of epoch 8 so far, and one checkpoint
+	// of epoch 9 without a gas summary
+	checkpoints := []CheckpointData{
+		cp(100, 7, 2, 1000),
+		cp(101, 7, 3, 1400),
+		cp(102, 7, 1, 1500),
+		cp(103, 8, 4, 300),
+		cp(104, 8, 5, 800),
+		{SequenceNumber: 105, Epoch: 9, TransactionDigests: make([]string, 6)},
+	}
+
+	got := AggregateEpochs(checkpoints)
+	want := []EpochSummary{
+		{
+			Epoch: 7, FirstCheckpoint: 100, LastCheckpoint: 102, Checkpoints: 3, Transactions: 6,
+			GasCost: GasCostSummary{ComputationCost: 500, StorageCost: 1000, StorageRebate: 250},
+		},
+		{
+			Epoch: 8, FirstCheckpoint: 103, LastCheckpoint: 104, Checkpoints: 2, Transactions: 9,
+			GasCost:        GasCostSummary{ComputationCost: 800, StorageCost: 1600, StorageRebate: 400},
+			FromEpochStart: true,
+		},
+		{
+			Epoch: 9, FirstCheckpoint: 105, LastCheckpoint: 105, Checkpoints: 1, Transactions: 6,
+			FromEpochStart: true,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AggregateEpochs =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestAggregateEpochsGapIsNotEpochStart(t *testing.T) {
+	// Epoch 8 starts somewhere in the gap between 101 and 200
+	got := AggregateEpochs([]CheckpointData{
+		{SequenceNumber: 101, Epoch: 7},
+		{SequenceNumber: 200, Epoch: 8, EpochRollingGasCostSummary: &GasCostSummary{ComputationCost: 50}},
+		{SequenceNumber: 201, Epoch: 8, EpochRollingGasCostSummary: &GasCostSummary{ComputationCost: 70}},
+	})
+	if len(got) != 2 || got[1].FromEpochStart || got[1].GasCost.ComputationCost != 20 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestSaveEpochsToCSV(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "epochs.csv")
+	epochs := []EpochSummary{{
+		Epoch: 8, FirstCheckpoint: 103, LastCheckpoint: 104, Checkpoints: 2, Transactions: 9,
+		GasCost:        GasCostSummary{ComputationCost: 800, StorageCost: 1600, StorageRebate: 400, NonRefundableStorageFee: 4},
+		FromEpochStart: true,
+	}}
+	if err := SaveEpochsToCSV(epochs, filename, WriteOptions{}); err != nil {
+		t.Fatalf("SaveEpochsToCSV: %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Epoch,FirstCheckpoint,LastCheckpoint,Checkpoints,Transactions,ComputationCost,StorageCost,StorageRebate,NonRefundableStorageFee,FromEpochStart\n" +
+		"8,103,104,2,9,800,1600,400,4,true\n"
+	if string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,202 @@
+package suitrace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Sentinel errors that callers can match with errors.Is
+var (
+	ErrObjectNotFound = errors.New("object not found")
+	ErrInvalidParams  = errors.New("invalid params")
+	ErrNotSupported   = errors.New("not supported by this backend")
+)
+
+// Standard JSON-RPC error codes
+const (
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000 // Generic server-side failure, used by Sui for most call errors
+)
+
+// An error object returned by the JSON-RPC server. Match it with errors.As.
+type RPCError struct {
+	Code    int
+	Message string
+	Data    interface{} // Optional details from the error's data member
+}
+
+func (e *RPCError) Error() string {
+	if e.Data != nil {
+		data, err := json.Marshal(e.Data)
+		if err != nil {
+			data = []byte(fmt.Sprintf("%v", e.Data))
+		}
+		return fmt.Sprintf("API error: code %d: %s (data: %s)", e.Code, e.Message, data)
+	}
+	return fmt.Sprintf("API error: code %d: %s", e.Code, e.Message)
+}
+
+// An RPC error with the invalid params code also matches ErrInvalidParams
+func (e *RPCError) Is(target error) bool {
+	return target == ErrInvalidParams && e.Code == CodeInvalidParams
+}
+
+// A non-2xx HTTP response from the RPC endpoint
+type HTTPError struct {
+	StatusCode int
+	Body       string // Start of the response body, for context
+}
+
+func (e *HTTPError) Error() string {
+	if e.Body != "" {
+		return fmt.Sprintf("HTTP %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+	}
+	return fmt.Sprintf("HTTP %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// A response body longer than the client's MaxResponseBytes
+type ResponseTooLargeError struct {
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response exceeds the %d byte limit", e.Limit)
+}
+
+// A successful response whose body is not valid JSON, typically one cut
+// short by a flaky connection. Body holds the start of what was received.
+type MalformedResponseError struct {
+	Body string
+	Err  error
+}
+
+func (e *MalformedResponseError) Error() string {
+	return fmt.Sprintf("failed to unmarshal response: %v (body starts: %q)", e.Err, e.Body)
+}
+
+func (e *MalformedResponseError) Unwrap() error {
+	return e.Err
+}
+
+// Report whether err is worth retrying. Network failures, timeouts, rate
+// limiting (HTTP 429), 5xx responses, truncated or malformed response
+// bodies and server-side RPC errors are transient. Rejected requests
+// (other 4xx, invalid params, unknown methods), GraphQL query errors,
+// missing objects and requests refused by a tripped CircuitBreaker are
+// permanent: retrying them only wastes quota. Unclassified errors are
+// treated as transient.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, ErrObjectNotFound) || errors.Is(err, ErrNotSupported) {
+		return false
+	}
+
+	// The breaker already decided retrying is pointless for now
+	var unhealthy *EndpointUnhealthyError
+	if errors.As(err, &unhealthy) {
+		return false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+	}
+
+	// The same request would get the same oversized response
+	var tooLarge *ResponseTooLargeError
+	if errors.As(err, &tooLarge) {
+		return false
+	}
+
+	// GraphQL errors report problems with the query itself
+	var gqlErr *GraphQLError
+	if errors.As(err, &gqlErr) {
+		return false
+	}
+
+	// A body cut short in transit usually arrives whole the next time
+	var malformed *MalformedResponseError
+	if errors.As(err, &malformed) {
+		return true
+	}
+
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case CodeInvalidRequest, CodeMethodNotFound, CodeInvalidParams:
+			return false
+		}
+		return true
+	}
+
+	return true
+}
+
+// A checkpoint the node returned without the fields every checkpoint has
+type MalformedCheckpointError struct {
+	SequenceNumber int64 // Sequence that was requested, or -1 when unknown
+	Reason         string
+}
+
+func (e *MalformedCheckpointError) Error() string {
+	if e.SequenceNumber < 0 {
+		return fmt.Sprintf("malformed checkpoint: %s", e.Reason)
+	}
+	return fmt.Sprintf("malformed checkpoint %d: %s", e.SequenceNumber, e.Reason)
+}
+
+// A break in the checkpoint chain found by VerifyChain
+type ChainBreakError struct {
+	SequenceNumber int64 // First checkpoint that does not follow the one before it
+	Reason         string
+}
+
+func (e *ChainBreakError) Error() string {
+	return fmt.Sprintf("checkpoint chain breaks at %d: %s", e.SequenceNumber, e.Reason)
+}
+
+// Build an RPCError from the error member of a JSON-RPC response
+func parseRPCError(errObj map[string]interface{}) *RPCError {
+	rpcErr := &RPCError{}
+
+	switch code := errObj["code"].(type) {
+	case json.Number:
+		if n, err := code.Int64(); err == nil {
+			rpcErr.Code = int(n)
+		}
+	case float64:
+		rpcErr.Code = int(code)
+	case string:
+		if n, err := strconv.Atoi(code); err == nil {
+			rpcErr.Code = n
+		}
+	}
+
+	if message, ok := errObj["message"].(string); ok {
+		rpcErr.Message = message
+	} else {
+		rpcErr.Message = fmt.Sprintf("%v", errObj)
+	}
+
+	rpcErr.Data = errObj["data"]
+
+	return rpcErr
+}
+
+// Build the error for an object the node reports as missing or deleted.
+// errObj is the per-object error from sui_getObject style responses.
+func objectNotFoundError(objectID string, errObj interface{}) error {
+	if details, ok := errObj.(map[string]interface{}); ok && details["code"] != nil {
+		return fmt.Errorf("%w: %s (%v)", ErrObjectNotFound, objectID, details["code"])
+	}
+	return fmt.Errorf("%w: %s", ErrObjectNotFound, objectID)
+}
@@ -0,0 +1,125 @@
+package suitrace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestRPCErrorMatching(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getCheckpoint": respond(fixture(t, "rpc_error.json")),
+		"sui_getObject":     respond(fixture(t, "rpc_error.json")),
+	})
+
+	_, err := client.FetchCheckpoint(1)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("FetchCheckpoint error %v is not an RPCError", err)
+	}
+	if rpcErr.Code != CodeInvalidParams || rpcErr.Message != "Invalid params" {
+		t.Errorf("got %+v", rpcErr)
+	}
+
+	// The RPC error survives wrapping by higher-level functions
+	_, err = client.FetchObjectHistory(testObjectID, HistoryOptions{})
+	if !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("FetchObjectHistory error %v does not match ErrInvalidParams", err)
+	}
+	if errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("FetchObjectHistory error %v should not match ErrObjectNotFound", err)
+	}
+}
+
+func TestObjectNotFound(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getObject": respond(mockResponse{Result: map[string]interface{}{
+			"error": map[string]interface{}{"code": "deleted", "object_id": testObjectID},
+		}}),
+	})
+
+	_, err := client.GetObjectCurrentState(testObjectID)
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("got %v, want ErrObjectNotFound", err)
+	}
+
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		t.Errorf("a missing object is not an RPC error: %v", err)
+	}
+}
+
+func TestRPCErrorKeepsData(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getObject": respond(mockResponse{Error: map[string]interface{}{
+			"code":    -32000,
+			"message": "Request rate limited",
+			"data":    map[string]interface{}{"retryAfter": 2},
+		}}),
+	})
+
+	_, err := client.GetObjectCurrentState(testObjectID)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("got %v, want an RPCError", err)
+	}
+	if rpcErr.Code != CodeServerError || rpcErr.Message != "Request rate limited" {
+		t.Errorf("got %+v", rpcErr)
+	}
+
+	data, ok := rpcErr.Data.(map[string]interface{})
+	if !ok || data["retryAfter"] == nil {
+		t.Errorf("data was not preserved: %#v", rpcErr.Data)
+	}
+	if want := `API error: code -32000: Request rate limited (data: {"retryAfter":2})`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"rate limited", &HTTPError{StatusCode: 429}, true},
+		{"bad gateway", fmt.Errorf("failed to send request: %w", &HTTPError{StatusCode: 502}), true},
+		{"forbidden", &HTTPError{StatusCode: 403}, false},
+		{"invalid params", &RPCError{Code: CodeInvalidParams}, false},
+		{"method not found", &RPCError{Code: CodeMethodNotFound}, false},
+		{"server error", &RPCError{Code: CodeServerError}, true},
+		{"object not found", fmt.Errorf("failed: %w", ErrObjectNotFound), false},
+		{"network", errors.New("connection reset by peer"), true},
+		{"truncated body", &MalformedResponseError{Body: `{"jsonrpc":`, Err: io.ErrUnexpectedEOF}, true},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("%s: IsTransient(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestMalformedCheckpointInPage(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getCheckpoints": respond(mockResponse{Result: map[string]interface{}{
+			"data": []interface{}{
+				map[string]interface{}{"digest": "a", "sequenceNumber": "1"},
+				map[string]interface{}{"sequenceNumber": "2"},
+			},
+			"nextCursor":  "2",
+			"hasNextPage": false,
+		}}),
+	})
+
+	_, _, _, err := client.FetchCheckpointsPaged("0", 2, false)
+	var malformed *MalformedCheckpointError
+	if !errors.As(err, &malformed) {
+		t.Fatalf("got %v, want a MalformedCheckpointError", err)
+	}
+	if malformed.Reason != "missing digest" {
+		t.Errorf("got reason %q", malformed.Reason)
+	}
+}
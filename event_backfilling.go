@@ -1,38 +1,164 @@
-package main
+package suitrace
 
 import (
-	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
-	"flag"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"net/http"
-	"os"
-	"time"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+// Events requested per suix_queryEvents page. EventPageSize is the Sui
+// full node's default maximum; providers that raise it accept up to
+// MaxEventPageSize.
 const (
-	rpcURL = "https://rpc.mainnet.sui.io" // Sui mainnet RPC
+	EventPageSize    = 50
+	MaxEventPageSize = 1000
 )
 
-func FetchEvents(cursor interface{}) ([]map[string]interface{}, interface{}, error) {
+// Check a requested events page size
+func ValidateEventPageSize(pageSize int) error {
+	if pageSize < 1 || pageSize > MaxEventPageSize {
+		return fmt.Errorf("event page size must be between 1 and %d, got %d", MaxEventPageSize, pageSize)
+	}
+	return nil
+}
+
+// How suix_queryEvents pages are requested
+type EventQueryOptions struct {
+	PageSize   int  // Events per page; 0 uses EventPageSize
+	Descending bool // Newest first instead of oldest first
+}
+
+// Build the event filter for an optional Move event type
+func EventTypeFilter(eventType string) map[string]interface{} {
+	if eventType != "" {
+		return map[string]interface{}{
+			"MoveEventType": eventType,
+		}
+	}
+
 	// Using the "All" filter with an empty array as specified in the error message
-	filter := map[string]interface{}{
+	return map[string]interface{}{
 		"All": []interface{}{},
 	}
-	
+}
+
+// Build the event filter matching any of eventTypes: every event when there
+// are none, a MoveEventType filter for one, and an Any of them for more
+func EventTypesFilter(eventTypes []string) map[string]interface{} {
+	if len(eventTypes) <= 1 {
+		eventType := ""
+		if len(eventTypes) == 1 {
+			eventType = eventTypes[0]
+		}
+		return EventTypeFilter(eventType)
+	}
+
+	filters := make([]interface{}, len(eventTypes))
+	for i, eventType := range eventTypes {
+		filters[i] = EventTypeFilter(eventType)
+	}
+	return map[string]interface{}{"Any": filters}
+}
+
+// Event filters accepted by suix_queryEvents. All and Any combine a list of
+// filters, And and Or exactly two.
+var eventFilterKinds = []string{
+	"All", "Any", "And", "Or", "Sender", "Transaction", "Package", "MoveModule",
+	"MoveEventType", "MoveEventModule", "MoveEventField", "TimeRange",
+}
+
+// Check that filter is a suix_queryEvents filter such as
+// {"Any": [{"MoveEventType": "0x3::validator::StakingRequestEvent"}, ...]},
+// including every filter nested in All, Any, And and Or
+func ValidateEventFilter(filter map[string]interface{}) error {
+	if len(filter) != 1 {
+		return fmt.Errorf("event filter must have exactly one key, got %d", len(filter))
+	}
+
+	for kind, value := range filter {
+		known := false
+		for _, k := range eventFilterKinds {
+			known = known || k == kind
+		}
+		if !known {
+			return fmt.Errorf("unknown event filter %q (use %s)", kind, strings.Join(eventFilterKinds, ", "))
+		}
+
+		switch kind {
+		case "All", "Any", "And", "Or":
+			children, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("%s event filter takes a list of filters", kind)
+			}
+			if (kind == "And" || kind == "Or") && len(children) != 2 {
+				return fmt.Errorf("%s event filter takes exactly 2 filters, got %d", kind, len(children))
+			}
+			for i, child := range children {
+				childFilter, ok := child.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("%s event filter: element %d is not a filter", kind, i)
+				}
+				if err := ValidateEventFilter(childFilter); err != nil {
+					return fmt.Errorf("%s event filter: element %d: %w", kind, i, err)
+				}
+			}
+		default:
+			if value == nil || value == "" {
+				return fmt.Errorf("%s event filter needs a value", kind)
+			}
+		}
+	}
+	return nil
+}
+
+// Parse and validate an event filter written as JSON, as read by -filter-file
+func ParseEventFilter(data []byte) (map[string]interface{}, error) {
+	var filter map[string]interface{}
+	if err := json.Unmarshal(data, &filter); err != nil {
+		return nil, fmt.Errorf("invalid event filter JSON: %w", err)
+	}
+	if err := ValidateEventFilter(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// Position in the event stream, as used by suix_queryEvents
+type EventCursor struct {
+	TxDigest string `json:"txDigest"`
+	EventSeq string `json:"eventSeq"`
+}
+
+// Fetch one page of events after cursor, nil meaning from the start. The
+// returned cursor is nil once there are no more pages.
+func (c *Client) FetchEvents(filter map[string]interface{}, cursor *EventCursor) ([]map[string]interface{}, *EventCursor, error) {
+	return c.FetchEventsWithOptions(filter, cursor, EventQueryOptions{})
+}
+
+// Fetch one page of events like FetchEvents, with the page size and order
+// in opts. In descending order the cursor walks back to older events.
+func (c *Client) FetchEventsWithOptions(filter map[string]interface{}, cursor *EventCursor, opts EventQueryOptions) ([]map[string]interface{}, *EventCursor, error) {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = EventPageSize
+	}
+
 	params := []interface{}{
 		filter,
 	}
-	
-	// Add cursor if it exists
+
+	// A nil cursor is sent as null
 	params = append(params, cursor)
-	
-	// Add limit and ascending (true = oldest first, false = newest first)
-	params = append(params, 50, true)
-	
+
+	// Add limit and descending order (false = oldest first, true = newest first)
+	params = append(params, pageSize, opts.Descending)
+
 	payload := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      1,
@@ -42,84 +168,292 @@ func FetchEvents(cursor interface{}) ([]map[string]interface{}, interface{}, err
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal payload: %v", err)
+		return nil, nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	// Debug request
-	fmt.Println("Sending request:", string(payloadBytes))
-
-	resp, err := http.Post(rpcURL, "application/json", bytes.NewReader(payloadBytes))
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read response: %v", err)
-	}
-
-	// Debug response status
-	fmt.Println("Response status:", resp.Status)
-	
-	// Only print first 200 chars of response to avoid flooding console
-	responsePreview := string(body)
-	if len(responsePreview) > 200 {
-		responsePreview = responsePreview[:200] + "..."
-	}
-	fmt.Println("Response preview:", responsePreview)
+	c.DebugPrint("Sending request: %s", string(payloadBytes))
 
 	var result struct {
 		Result struct {
 			Data       []map[string]interface{} `json:"data"`
-			NextCursor interface{}              `json:"nextCursor"`
+			NextCursor *EventCursor             `json:"nextCursor"`
 		} `json:"result"`
 		Error map[string]interface{} `json:"error"`
 	}
 
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, nil, fmt.Errorf("failed to unmarshal response: %v", err)
+	if err := c.call(payloadBytes, &result); err != nil {
+		return nil, nil, err
 	}
 
 	// Check for API errors
 	if result.Error != nil {
-		return nil, nil, fmt.Errorf("API error: %v", result.Error)
+		return nil, nil, parseRPCError(result.Error)
 	}
 
 	return result.Result.Data, result.Result.NextCursor, nil
 }
 
-func SaveEventsToCSV(events []map[string]interface{}, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create CSV file: %v", err)
+// Options controlling an event backfill
+type EventBackfillOptions struct {
+	Limit    int  // Stop after this many events, <= 0 means no limit
+	NoDedup  bool // Keep events repeated across pages or retries
+	PageSize int  // Events per page; 0 uses EventPageSize
+
+	// Fetch the newest events first, so Limit keeps the most recent ones
+	Descending bool
+}
+
+// Unique key for an event from its {txDigest, eventSeq} id, empty if it has none
+func EventKey(event map[string]interface{}) string {
+	id, ok := event["id"].(map[string]interface{})
+	if !ok || id["txDigest"] == nil || id["eventSeq"] == nil {
+		return ""
 	}
-	defer file.Close()
+	return fmt.Sprintf("%v:%v", id["txDigest"], id["eventSeq"])
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+// Page through events matching filter until the cursor is exhausted or the
+// limit is reached. Events already seen are skipped unless NoDedup is set.
+func (c *Client) BackfillEvents(filter map[string]interface{}, opts EventBackfillOptions) ([]map[string]interface{}, error) {
+	return BackfillEvents(context.Background(), c, filter, opts)
+}
+
+// BackfillEvents pages through events from any backend
+func BackfillEvents(ctx context.Context, b Backend, filter map[string]interface{}, opts EventBackfillOptions) ([]map[string]interface{}, error) {
+	allEvents := []map[string]interface{}{}
+	err := pageEvents(ctx, b, filter, opts, func(event map[string]interface{}) {
+		allEvents = append(allEvents, event)
+	})
+	return allEvents, err
+}
+
+// Count the events matching filter the way BackfillEvents would fetch them,
+// without keeping them
+func (c *Client) CountEvents(filter map[string]interface{}, opts EventBackfillOptions) (int, error) {
+	return CountEvents(context.Background(), c, filter, opts)
+}
+
+// CountEvents counts events from any backend
+func CountEvents(ctx context.Context, b Backend, filter map[string]interface{}, opts EventBackfillOptions) (int, error) {
+	count := 0
+	err := pageEvents(ctx, b, filter, opts, func(map[string]interface{}) {
+		count++
+	})
+	return count, err
+}
+
+// Page through events matching filter, passing each new event to handle,
+// until the cursor is exhausted or opts.Limit events were handled
+func pageEvents(ctx context.Context, b Backend, filter map[string]interface{}, opts EventBackfillOptions, handle func(map[string]interface{})) error {
+	if err := ValidateEventFilter(filter); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	var cursor *EventCursor
+	totalFetched := 0
+	duplicates := 0
+	maxRetries := 3
+	retryCount := 0
+	limit := opts.Limit
+	query := EventQueryOptions{PageSize: opts.PageSize, Descending: opts.Descending}
+	if query.PageSize <= 0 {
+		query.PageSize = EventPageSize
+	}
+
+	for {
+		events, nextCursor, err := b.QueryEvents(ctx, filter, cursor, query)
+		if err != nil {
+			// Nodes reject pages above their maximum, so retry at that size
+			if max, ok := pageSizeLimit(err); ok && max > 0 && max < query.PageSize {
+				fmt.Printf("Page size %d is above the node's maximum, using %d\n", query.PageSize, max)
+				query.PageSize = max
+				continue
+			}
+
+			if ctx.Err() != nil {
+				return fmt.Errorf("stopped fetching events: %w", ctx.Err())
+			}
+
+			fmt.Printf("Error fetching events: %v\n", err)
+			if !IsTransient(err) {
+				return fmt.Errorf("giving up on events, the error is not retryable: %w", err)
+			}
+			retryCount++
+
+			if retryCount > maxRetries {
+				return fmt.Errorf("failed to fetch events after %d retries: %w", maxRetries, err)
+			}
 
-	// Dynamically determine headers based on first event
-	var headers []string
-	if len(events) > 0 {
-		// Get all fields from first event
-		for key := range events[0] {
-			headers = append(headers, key)
+			fmt.Printf("Retry attempt %d of %d\n", retryCount, maxRetries)
+			if err := sleepContext(ctx, retryDelay); err != nil {
+				return fmt.Errorf("stopped before retrying events: %w", err)
+			}
+			continue
 		}
-	} else {
-		// Fallback headers if no events
-		headers = []string{"EventID", "PackageID", "TransactionDigest", "ParsedJson"}
+
+		retryCount = 0
+
+		// An empty page ends the backfill even if the server hands back a cursor
+		if len(events) == 0 {
+			fmt.Println("No more events found!")
+			break
+		}
+
+		for _, event := range events {
+			// Pages are fixed-size, so the last one can overshoot the limit
+			if limit > 0 && totalFetched >= limit {
+				break
+			}
+			if !opts.NoDedup {
+				if key := EventKey(event); key != "" {
+					if seen[key] {
+						duplicates++
+						continue
+					}
+					seen[key] = true
+				}
+			}
+			handle(event)
+			totalFetched++
+		}
+		fmt.Printf("Fetched %d events so far...\n", totalFetched)
+
+		// Stop if user-defined limit reached
+		if limit > 0 && totalFetched >= limit {
+			fmt.Printf("Reached user-defined limit of %d events\n", limit)
+			break
+		}
+
+		if nextCursor == nil {
+			fmt.Println("No pagination cursor returned - we've reached the end")
+			break
+		}
+
+		// A cursor that doesn't move would refetch the same page forever
+		if cursor != nil && *nextCursor == *cursor {
+			fmt.Println("Pagination cursor did not advance - stopping")
+			break
+		}
+		cursor = nextCursor
 	}
 
+	if duplicates > 0 {
+		fmt.Printf("Skipped %d duplicate events\n", duplicates)
+	}
+
+	return nil
+}
+
+// Matches the error a Sui node returns for a page size above its maximum,
+// "Page size limit 100 exceeds max limit 50"
+var pageSizeLimitPattern = regexp.MustCompile(`(?i)exceeds max(?:imum)? limit(?: of)? (\d+)`)
+
+// The maximum page size named by a node's page size error
+func pageSizeLimit(err error) (int, bool) {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return 0, false
+	}
+	match := pageSizeLimitPattern.FindStringSubmatch(rpcErr.Message)
+	if match == nil {
+		return 0, false
+	}
+	max, convErr := strconv.Atoi(match[1])
+	return max, convErr == nil
+}
+
+// Apply the backfill's filtering to events loaded from a file: keep events
+// of any of eventTypes (all when empty), drop duplicates unless
+// opts.NoDedup is set, and stop at opts.Limit
+func FilterEvents(events []map[string]interface{}, eventTypes []string, opts EventBackfillOptions) []map[string]interface{} {
+	wanted := map[interface{}]bool{}
+	for _, eventType := range eventTypes {
+		wanted[eventType] = true
+	}
+
+	filtered := []map[string]interface{}{}
+	seen := make(map[string]bool)
+	for _, event := range events {
+		if len(wanted) > 0 && !wanted[event["type"]] {
+			continue
+		}
+		if !opts.NoDedup {
+			if key := EventKey(event); key != "" {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+		}
+		filtered = append(filtered, event)
+		if opts.Limit > 0 && len(filtered) >= opts.Limit {
+			break
+		}
+	}
+	return filtered
+}
+
+// Drop events emitted by system transactions (see IsSystemTransaction),
+// looking up the kind of each distinct transaction with
+// FetchTransactionDetails. Returns the remaining events and how many were
+// dropped.
+func (c *Client) ExcludeSystemEvents(events []map[string]interface{}) ([]map[string]interface{}, int, error) {
+	txs := []CheckpointTransaction{}
+	index := map[string]int{}
+	for _, event := range events {
+		id, _ := event["id"].(map[string]interface{})
+		digest, _ := id["txDigest"].(string)
+		if _, ok := index[digest]; digest != "" && !ok {
+			index[digest] = len(txs)
+			txs = append(txs, CheckpointTransaction{Digest: digest})
+		}
+	}
+
+	if err := c.FetchTransactionDetails(txs); err != nil {
+		return events, 0, err
+	}
+
+	kept := []map[string]interface{}{}
+	for _, event := range events {
+		id, _ := event["id"].(map[string]interface{})
+		digest, _ := id["txDigest"].(string)
+		if i, ok := index[digest]; ok && IsSystemTransaction(txs[i].Kind) {
+			continue
+		}
+		kept = append(kept, event)
+	}
+	return kept, len(events) - len(kept), nil
+}
+
+// Save events to CSV, returning the files written
+func SaveEventsToCSV(events []map[string]interface{}, filename string, opts WriteOptions) ([]string, error) {
+	// Every shard shares the header built from all events
+	headers := EventCSVHeaders(events)
+
+	return saveShards(events, filename, opts, func(events []map[string]interface{}, filename string) error {
+		return writeEventsCSV(events, headers, filename, opts)
+	})
+}
+
+func writeEventsCSV(events []map[string]interface{}, headers []string, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.discard()
+
+	writer := csv.NewWriter(file)
+
 	if err := writer.Write(headers); err != nil {
-		return fmt.Errorf("failed to write CSV header: %v", err)
+		return fmt.Errorf("failed to write CSV header: %w", err)
 	}
 
 	for _, event := range events {
 		var record []string
 		for _, header := range headers {
 			value := ""
-			if val, ok := event[header]; ok && val != nil {
+			if val, ok := eventColumnValue(event, header); ok && val != nil {
 				// For complex objects, convert to JSON string
 				if IsComplexType(val) {
 					jsonBytes, err := json.Marshal(val)
@@ -134,93 +468,178 @@ func SaveEventsToCSV(events []map[string]interface{}, filename string) error {
 			}
 			record = append(record, value)
 		}
-		
+
 		if err := writer.Write(record); err != nil {
-			return fmt.Errorf("failed to write record to CSV: %v", err)
+			return fmt.Errorf("failed to write record to CSV: %w", err)
 		}
 	}
-	
-	return nil
-}
 
-// Helper function to detect complex types (maps/slices) that need JSON serialization
-func IsComplexType(v interface{}) bool {
-	switch v.(type) {
-	case map[string]interface{}, []interface{}:
-		return true
-	default:
-		return false
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV file: %w", err)
 	}
-}
 
-func main() {
-	// CLI flags
-	limit := flag.Int("limit", 200, "Number of events to fetch (max)")
-	filename := flag.String("filename", "events.csv", "Output CSV filename")
-	flag.Parse()
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close CSV file: %w", err)
+	}
 
-	fmt.Println("Starting event backfill...")
+	return saveMetadataSidecar(filename, opts)
+}
 
-	allEvents := []map[string]interface{}{}
-	var cursor interface{}
-	totalFetched := 0
-	maxRetries := 3
-	retryCount := 0
+// A row of an event Parquet export. Every column is nullable since events
+// need not carry every field. Append new columns at the end so existing
+// tables keep reading older files.
+type eventParquetRow struct {
+	TxDigest          *string `parquet:"txDigest,optional"`
+	EventSeq          *int64  `parquet:"eventSeq,optional"`
+	PackageID         *string `parquet:"packageId,optional"`
+	TransactionModule *string `parquet:"transactionModule,optional"`
+	Sender            *string `parquet:"sender,optional"`
+	Type              *string `parquet:"type,optional"`
+	TimestampMs       int64   `parquet:"timestampMs,optional,timestamp(millisecond)"` // Null when zero
+	ParsedJSON        *string `parquet:"parsedJson,optional"`                         // JSON text
+	BCS               *string `parquet:"bcs,optional"`
+}
 
-	startTime := time.Now()
+// Save events to Parquet with a fixed, typed schema, returning the files
+// written. Keys outside the schema (such as flattened parsed.* columns) are
+// not written.
+func SaveEventsToParquet(events []map[string]interface{}, filename string, opts WriteOptions) ([]string, error) {
+	return saveShards(events, filename, opts, func(events []map[string]interface{}, filename string) error {
+		rows := make([]eventParquetRow, len(events))
+		for i, event := range events {
+			id, _ := event["id"].(map[string]interface{})
+			rows[i] = eventParquetRow{
+				TxDigest:          optionalString(id["txDigest"]),
+				EventSeq:          optionalInt64(id["eventSeq"]),
+				PackageID:         optionalString(event["packageId"]),
+				TransactionModule: optionalString(event["transactionModule"]),
+				Sender:            optionalString(event["sender"]),
+				Type:              optionalString(event["type"]),
+				BCS:               optionalString(event["bcs"]),
+			}
+			if timestamp := optionalInt64(event["timestampMs"]); timestamp != nil {
+				rows[i].TimestampMs = *timestamp
+			}
+			if parsed, ok := event["parsedJson"]; ok && parsed != nil {
+				data, err := json.Marshal(parsed)
+				if err != nil {
+					return fmt.Errorf("failed to encode parsedJson: %w", err)
+				}
+				text := string(data)
+				rows[i].ParsedJSON = &text
+			}
+		}
+		return saveParquetFile(filename, rows, opts)
+	})
+}
 
-	for {
-		events, nextCursor, err := FetchEvents(cursor)
-		if err != nil {
-			fmt.Printf("Error fetching events: %v\n", err)
-			retryCount++
+// A string value for a nullable Parquet column, nil when v is not a string
+func optionalString(v interface{}) *string {
+	if s, ok := v.(string); ok {
+		return &s
+	}
+	return nil
+}
 
-			if retryCount > maxRetries {
-				log.Fatalf("Failed to fetch events after %d retries: %v", maxRetries, err)
-			}
+// An int64 value for a nullable Parquet column, nil when v is not a u64
+func optionalInt64(v interface{}) *int64 {
+	n, err := parseU64(v)
+	if err != nil {
+		return nil
+	}
+	i := int64(n)
+	return &i
+}
 
-			fmt.Printf("Retry attempt %d of %d\n", retryCount, maxRetries)
-			continue
+// Columns that lead every event CSV, in this order, whether or not the
+// events have them. The event id is split into its two parts.
+var leadingEventColumns = []string{"type", "packageId", "transactionModule", "sender", "id.txDigest", "id.eventSeq"}
+
+// Build a stable CSV header: the leading columns, then the union of the
+// other keys across all events in sorted order
+func EventCSVHeaders(events []map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	for _, event := range events {
+		for key := range event {
+			seen[key] = true
 		}
+	}
 
-		retryCount = 0
+	headers := append([]string{}, leadingEventColumns...)
+	delete(seen, "id")
+	for _, column := range leadingEventColumns {
+		delete(seen, column)
+	}
 
-		if len(events) == 0 {
-			fmt.Println("No more events found!")
-			break
-		}
+	rest := make([]string, 0, len(seen))
+	for key := range seen {
+		rest = append(rest, key)
+	}
+	sort.Strings(rest)
 
-		allEvents = append(allEvents, events...)
-		totalFetched += len(events)
-		fmt.Printf("Fetched %d events so far...\n", totalFetched)
+	return append(headers, rest...)
+}
 
-		cursor = nextCursor
-		if cursor == nil {
-			fmt.Println("No pagination cursor returned - we've reached the end")
-			break
+// Value of an event CSV column, reaching into the id object for the
+// id.txDigest and id.eventSeq columns
+func eventColumnValue(event map[string]interface{}, column string) (interface{}, bool) {
+	if column == "id.txDigest" || column == "id.eventSeq" {
+		id, _ := event["id"].(map[string]interface{})
+		val, ok := id[strings.TrimPrefix(column, "id.")]
+		return val, ok
+	}
+	val, ok := event[column]
+	return val, ok
+}
+
+// Expand each event's parsedJson object into "parsed.<key>" columns.
+// Returns the events unchanged and false when parsedJson shapes differ between events.
+func FlattenParsedJSON(events []map[string]interface{}) ([]map[string]interface{}, bool) {
+	shape := ""
+	for i, event := range events {
+		parsed, ok := event["parsedJson"].(map[string]interface{})
+		if !ok {
+			return events, false
 		}
 
-		// Stop if user-defined limit reached
-		if totalFetched >= *limit {
-			fmt.Printf("Reached user-defined limit of %d events\n", *limit)
-			break
+		keys := make([]string, 0, len(parsed))
+		for key := range parsed {
+			keys = append(keys, key)
 		}
-	}
+		sort.Strings(keys)
 
-	elapsedTime := time.Since(startTime)
+		eventShape := strings.Join(keys, ",")
+		if i == 0 {
+			shape = eventShape
+		} else if eventShape != shape {
+			return events, false
+		}
+	}
 
-	if len(allEvents) == 0 {
-		fmt.Println("No events fetched!")
-		return
+	flattened := make([]map[string]interface{}, 0, len(events))
+	for _, event := range events {
+		flat := make(map[string]interface{}, len(event))
+		for key, val := range event {
+			if key != "parsedJson" {
+				flat[key] = val
+			}
+		}
+		for key, val := range event["parsedJson"].(map[string]interface{}) {
+			flat["parsed."+key] = val
+		}
+		flattened = append(flattened, flat)
 	}
 
-	fmt.Printf("Fetched a total of %d events in %s\n", len(allEvents), elapsedTime)
-	fmt.Println("Saving events to CSV file...")
+	return flattened, true
+}
 
-	err := SaveEventsToCSV(allEvents, *filename)
-	if err != nil {
-		log.Fatalf("Failed to save events to CSV: %v", err)
+// Helper function to detect complex types (maps/slices) that need JSON serialization
+func IsComplexType(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		return false
 	}
-
-	fmt.Printf("Done! %d events saved to %s 🎉\n", len(allEvents), *filename)
 }
@@ -0,0 +1,619 @@
+package suitrace
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		resp       mockResponse
+		wantEvents int
+		wantCursor bool
+		wantErr    string
+	}{
+		{
+			name:       "recorded page",
+			resp:       fixture(t, "events.json"),
+			wantEvents: 2,
+			wantCursor: true,
+		},
+		{
+			name:       "last page",
+			resp:       mockResponse{Result: map[string]interface{}{"data": []interface{}{}, "nextCursor": nil}},
+			wantEvents: 0,
+			wantCursor: false,
+		},
+		{
+			name:    "API error",
+			resp:    fixture(t, "rpc_error.json"),
+			wantErr: "API error",
+		},
+		{
+			name:    "malformed JSON",
+			resp:    mockResponse{Raw: `{"result":{"data":[{`},
+			wantErr: "failed to unmarshal response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotParams []interface{}
+			client := newTestClient(t, map[string]mockHandler{
+				"suix_queryEvents": func(params []interface{}) mockResponse {
+					gotParams = params
+					return tt.resp
+				},
+			})
+
+			events, cursor, err := client.FetchEvents(EventTypeFilter(""), nil)
+			if len(gotParams) != 4 {
+				t.Errorf("params = %v, want filter, cursor, limit, ascending", gotParams)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(events) != tt.wantEvents {
+				t.Errorf("got %d events, want %d", len(events), tt.wantEvents)
+			}
+			if (cursor != nil) != tt.wantCursor {
+				t.Errorf("cursor = %v, want present=%v", cursor, tt.wantCursor)
+			}
+			if tt.wantEvents > 0 && events[0]["type"] != "0x3::validator::StakingRequestEvent" {
+				t.Errorf("first event type = %v", events[0]["type"])
+			}
+		})
+	}
+}
+
+func TestEventCSVHeaders(t *testing.T) {
+	events := []map[string]interface{}{
+		{"type": "A", "sender": "0x1", "id": map[string]interface{}{"eventSeq": "0"}, "parsedJson": map[string]interface{}{}},
+		{"type": "B", "packageId": "0x2", "bcs": "abc", "timestampMs": "1"},
+	}
+	want := []string{"type", "packageId", "transactionModule", "sender", "id.txDigest", "id.eventSeq", "bcs", "parsedJson", "timestampMs"}
+
+	// Map iteration order is random, so repeat to catch nondeterminism
+	for i := 0; i < 20; i++ {
+		got := EventCSVHeaders(events)
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("EventCSVHeaders = %v, want %v", got, want)
+		}
+	}
+
+	if got := EventCSVHeaders(nil); strings.Join(got, ",") != "type,packageId,transactionModule,sender,id.txDigest,id.eventSeq" {
+		t.Errorf("EventCSVHeaders(nil) = %v", got)
+	}
+}
+
+func TestSaveEventsToCSVFillsMissingColumns(t *testing.T) {
+	events := []map[string]interface{}{
+		{"sender": "0x1", "type": "A", "id": map[string]interface{}{"txDigest": "tx1", "eventSeq": "0"}},
+		{"sender": "0x2", "extra": "x"},
+	}
+	filename := filepath.Join(t.TempDir(), "events.csv")
+	if _, err := SaveEventsToCSV(events, filename, WriteOptions{}); err != nil {
+		t.Fatalf("SaveEventsToCSV: %v", err)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"type", "packageId", "transactionModule", "sender", "id.txDigest", "id.eventSeq", "extra"},
+		{"A", "", "", "0x1", "tx1", "0", ""},
+		{"", "", "", "0x2", "", "", "x"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if strings.Join(rows[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d = %v, want %v", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestFlattenParsedJSON(t *testing.T) {
+	events := []map[string]interface{}{
+		{"type": "T", "parsedJson": map[string]interface{}{"amount": "1", "recipient": "0xa"}},
+		{"type": "T", "parsedJson": map[string]interface{}{"amount": "2", "recipient": "0xb"}},
+	}
+
+	flattened, ok := FlattenParsedJSON(events)
+	if !ok {
+		t.Fatal("expected homogeneous events to flatten")
+	}
+	if _, exists := flattened[0]["parsedJson"]; exists {
+		t.Error("parsedJson column should be removed after flattening")
+	}
+	if flattened[1]["parsed.amount"] != "2" || flattened[1]["parsed.recipient"] != "0xb" {
+		t.Errorf("flattened[1] = %v", flattened[1])
+	}
+	if flattened[0]["type"] != "T" {
+		t.Errorf("non-parsed fields should be kept, got %v", flattened[0])
+	}
+
+	heterogeneous := append(events, map[string]interface{}{
+		"type": "T", "parsedJson": map[string]interface{}{"amount": "3"},
+	})
+	got, ok := FlattenParsedJSON(heterogeneous)
+	if ok {
+		t.Fatal("expected heterogeneous events to fall back")
+	}
+	if _, exists := got[2]["parsedJson"]; !exists {
+		t.Error("fallback should keep the parsedJson blob")
+	}
+}
+
+func TestFetchEventsCursor(t *testing.T) {
+	var gotCursor interface{}
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_queryEvents": func(params []interface{}) mockResponse {
+			gotCursor = params[1]
+			return fixture(t, "events.json")
+		},
+	})
+
+	_, next, err := client.FetchEvents(EventTypeFilter(""), &EventCursor{TxDigest: "tx", EventSeq: "3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{"txDigest": "tx", "eventSeq": "3"}
+	if cursor, ok := gotCursor.(map[string]interface{}); !ok || cursor["txDigest"] != want["txDigest"] || cursor["eventSeq"] != want["eventSeq"] {
+		t.Errorf("sent cursor %v, want %v", gotCursor, want)
+	}
+	if next == nil || *next != (EventCursor{TxDigest: "FzLKBmvNK4m2Zr5qJ1v3xhQzR8cXGy6o5dEwPb9aTfUs", EventSeq: "1"}) {
+		t.Errorf("next cursor = %+v", next)
+	}
+}
+
+// Serve total events in pages of pageSize, with a cursor pointing at the last event of each page
+func eventPages(total, pageSize int, calls *int) mockHandler {
+	return func(params []interface{}) mockResponse {
+		*calls++
+		start := 0
+		if cursor, ok := params[1].(map[string]interface{}); ok {
+			seq, _ := strconv.Atoi(cursor["eventSeq"].(string))
+			start = seq + 1
+		}
+
+		data := []interface{}{}
+		for seq := start; seq < start+pageSize && seq < total; seq++ {
+			data = append(data, map[string]interface{}{
+				"id": map[string]interface{}{"txDigest": "tx", "eventSeq": strconv.Itoa(seq)},
+			})
+		}
+
+		var nextCursor interface{}
+		if start+pageSize < total {
+			nextCursor = map[string]interface{}{"txDigest": "tx", "eventSeq": strconv.Itoa(start + pageSize - 1)}
+		}
+		return mockResponse{Result: map[string]interface{}{"data": data, "nextCursor": nextCursor}}
+	}
+}
+
+func TestBackfillEventsLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		limit     int
+		wantCount int
+		wantCalls int
+	}{
+		{name: "exact limit", total: 10, limit: 4, wantCount: 4, wantCalls: 2},
+		{name: "trims over-fetched page", total: 10, limit: 3, wantCount: 3, wantCalls: 2},
+		{name: "no limit fetches everything", total: 7, limit: 0, wantCount: 7, wantCalls: 4},
+		{name: "limit above total", total: 5, limit: 100, wantCount: 5, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := newTestClient(t, map[string]mockHandler{
+				"suix_queryEvents": eventPages(tt.total, 2, &calls),
+			})
+
+			events, err := client.BackfillEvents(EventTypeFilter(""), EventBackfillOptions{Limit: tt.limit})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(events) != tt.wantCount {
+				t.Errorf("got %d events, want %d", len(events), tt.wantCount)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("made %d calls, want %d", calls, tt.wantCalls)
+			}
+
+			calls = 0
+			count, err := client.CountEvents(EventTypeFilter(""), EventBackfillOptions{Limit: tt.limit})
+			if err != nil {
+				t.Fatalf("CountEvents: %v", err)
+			}
+			if count != tt.wantCount || calls != tt.wantCalls {
+				t.Errorf("CountEvents = %d in %d calls, want %d in %d", count, calls, tt.wantCount, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBackfillEventsStopsOnStuckPagination(t *testing.T) {
+	tests := []struct {
+		name string
+		page func() map[string]interface{}
+	}{
+		{
+			name: "empty page with cursor",
+			page: func() map[string]interface{} {
+				return map[string]interface{}{
+					"data":       []interface{}{},
+					"nextCursor": map[string]interface{}{"txDigest": "tx", "eventSeq": "0"},
+				}
+			},
+		},
+		{
+			name: "cursor never advances",
+			page: func() map[string]interface{} {
+				return map[string]interface{}{
+					"data":       []interface{}{map[string]interface{}{"type": "E"}},
+					"nextCursor": map[string]interface{}{"txDigest": "tx", "eventSeq": "0"},
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := newTestClient(t, map[string]mockHandler{
+				"suix_queryEvents": func(params []interface{}) mockResponse {
+					calls++
+					if calls > 10 {
+						t.Error("backfill kept paging a stuck cursor")
+						return mockResponse{Result: map[string]interface{}{"data": []interface{}{}}}
+					}
+					return mockResponse{Result: tt.page()}
+				},
+			})
+
+			if _, err := client.BackfillEvents(EventTypeFilter(""), EventBackfillOptions{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestBackfillEventsDedup(t *testing.T) {
+	// The second page overlaps the first by one event
+	pages := [][]interface{}{
+		{
+			map[string]interface{}{"id": map[string]interface{}{"txDigest": "a", "eventSeq": "0"}},
+			map[string]interface{}{"id": map[string]interface{}{"txDigest": "a", "eventSeq": "1"}},
+		},
+		{
+			map[string]interface{}{"id": map[string]interface{}{"txDigest": "a", "eventSeq": "1"}},
+			map[string]interface{}{"id": map[string]interface{}{"txDigest": "b", "eventSeq": "0"}},
+		},
+	}
+
+	for _, tt := range []struct {
+		name    string
+		noDedup bool
+		want    int
+	}{
+		{name: "dedup", want: 3},
+		{name: "no dedup", noDedup: true, want: 4},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := newTestClient(t, map[string]mockHandler{
+				"suix_queryEvents": func(params []interface{}) mockResponse {
+					page := pages[calls]
+					calls++
+					var next interface{}
+					if calls < len(pages) {
+						next = map[string]interface{}{"txDigest": "a", "eventSeq": strconv.Itoa(calls)}
+					}
+					return mockResponse{Result: map[string]interface{}{"data": page, "nextCursor": next}}
+				},
+			})
+
+			events, err := client.BackfillEvents(EventTypeFilter(""), EventBackfillOptions{NoDedup: tt.noDedup})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(events) != tt.want {
+				t.Errorf("got %d events, want %d", len(events), tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterEvents(t *testing.T) {
+	event := func(tx, eventType string) map[string]interface{} {
+		return map[string]interface{}{
+			"id":   map[string]interface{}{"txDigest": tx, "eventSeq": "0"},
+			"type": eventType,
+		}
+	}
+	events := []map[string]interface{}{
+		event("a", "0x2::m::E"),
+		event("b", "0x2::m::F"),
+		event("a", "0x2::m::E"),
+		event("c", "0x2::m::E"),
+		event("d", "0x2::m::E"),
+	}
+
+	keys := func(events []map[string]interface{}) string {
+		var out []string
+		for _, e := range events {
+			out = append(out, EventKey(e))
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		eventTypes []string
+		opts       EventBackfillOptions
+		want       string
+	}{
+		{nil, EventBackfillOptions{}, "a:0,b:0,c:0,d:0"},
+		{[]string{"0x2::m::E"}, EventBackfillOptions{}, "a:0,c:0,d:0"},
+		{[]string{"0x2::m::E"}, EventBackfillOptions{NoDedup: true}, "a:0,a:0,c:0,d:0"},
+		{[]string{"0x2::m::E"}, EventBackfillOptions{Limit: 2}, "a:0,c:0"},
+		{[]string{"0x2::m::F", "0x2::m::E"}, EventBackfillOptions{NoDedup: true}, "a:0,b:0,a:0,c:0,d:0"},
+	}
+	for _, tt := range tests {
+		if got := keys(FilterEvents(events, tt.eventTypes, tt.opts)); got != tt.want {
+			t.Errorf("FilterEvents(%q, %+v) = %s, want %s", tt.eventTypes, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestExcludeSystemEvents(t *testing.T) {
+	calls := 0
+	handler := kindsHandler(map[string]string{"epoch": "ChangeEpoch", "user": "ProgrammableTransaction"})
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_multiGetTransactionBlocks": func(params []interface{}) mockResponse {
+			calls++
+			if digests, _ := params[0].([]interface{}); len(digests) != 2 {
+				t.Errorf("expected 2 distinct digests, got %v", digests)
+			}
+			return handler(params)
+		},
+	})
+
+	events := []map[string]interface{}{
+		{"id": map[string]interface{}{"txDigest": "epoch", "eventSeq": "0"}},
+		{"id": map[string]interface{}{"txDigest": "user", "eventSeq": "0"}},
+		{"id": map[string]interface{}{"txDigest": "epoch", "eventSeq": "1"}},
+		{"type": "no id"},
+	}
+
+	kept, excluded, err := client.ExcludeSystemEvents(events)
+	if err != nil {
+		t.Fatalf("ExcludeSystemEvents: %v", err)
+	}
+	if calls != 1 || excluded != 2 || len(kept) != 2 {
+		t.Fatalf("calls=%d excluded=%d kept=%v", calls, excluded, kept)
+	}
+	if EventKey(kept[0]) != "user:0" || kept[1]["type"] != "no id" {
+		t.Errorf("unexpected remaining events: %v", kept)
+	}
+}
+
+func TestBackfillEventsPageSize(t *testing.T) {
+	var sizes []interface{}
+	calls := 0
+	pages := eventPages(10, 4, &calls)
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_queryEvents": func(params []interface{}) mockResponse {
+			sizes = append(sizes, params[2])
+			// Like a full node capped at 4 events per page
+			if params[2].(float64) > 4 {
+				return mockResponse{Error: map[string]interface{}{
+					"code":    CodeInvalidParams,
+					"message": "Page size limit 200 exceeds max limit 4",
+				}}
+			}
+			return pages(params)
+		},
+	})
+
+	events, err := client.BackfillEvents(EventTypeFilter(""), EventBackfillOptions{PageSize: 200})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 10 {
+		t.Errorf("got %d events, want 10", len(events))
+	}
+	want := []interface{}{float64(200), float64(4), float64(4), float64(4)}
+	if !reflect.DeepEqual(sizes, want) {
+		t.Errorf("requested page sizes %v, want %v", sizes, want)
+	}
+}
+
+func TestValidateEventPageSize(t *testing.T) {
+	for _, size := range []int{1, EventPageSize, MaxEventPageSize} {
+		if err := ValidateEventPageSize(size); err != nil {
+			t.Errorf("ValidateEventPageSize(%d) = %v", size, err)
+		}
+	}
+	for _, size := range []int{0, -1, MaxEventPageSize + 1} {
+		if err := ValidateEventPageSize(size); err == nil {
+			t.Errorf("ValidateEventPageSize(%d) should fail", size)
+		}
+	}
+}
+
+// Serve events 0..total-1 in pages of pageSize in the order params[3] asks
+// for, each page's cursor pointing at its last event
+func orderedEventPages(total, pageSize int) mockHandler {
+	return func(params []interface{}) mockResponse {
+		descending := params[3].(bool)
+		next, step := 0, 1
+		if descending {
+			next, step = total-1, -1
+		}
+		if cursor, ok := params[1].(map[string]interface{}); ok {
+			seq, _ := strconv.Atoi(cursor["eventSeq"].(string))
+			next = seq + step
+		}
+
+		data := []interface{}{}
+		last := -1
+		for seq := next; seq >= 0 && seq < total && len(data) < pageSize; seq += step {
+			data = append(data, map[string]interface{}{
+				"id": map[string]interface{}{"txDigest": "tx", "eventSeq": strconv.Itoa(seq)},
+			})
+			last = seq
+		}
+
+		var nextCursor interface{}
+		if more := last+step >= 0 && last+step < total; len(data) > 0 && more {
+			nextCursor = map[string]interface{}{"txDigest": "tx", "eventSeq": strconv.Itoa(last)}
+		}
+		return mockResponse{Result: map[string]interface{}{"data": data, "nextCursor": nextCursor}}
+	}
+}
+
+func TestBackfillEventsOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		descending bool
+		limit      int
+		want       []string
+	}{
+		{name: "oldest first", want: []string{"0", "1", "2", "3", "4", "5", "6"}},
+		{name: "newest first", descending: true, want: []string{"6", "5", "4", "3", "2", "1", "0"}},
+		{name: "oldest first with limit", limit: 3, want: []string{"0", "1", "2"}},
+		{name: "newest first with limit", descending: true, limit: 3, want: []string{"6", "5", "4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, map[string]mockHandler{
+				"suix_queryEvents": orderedEventPages(7, 2),
+			})
+
+			events, err := client.BackfillEvents(EventTypeFilter(""), EventBackfillOptions{Limit: tt.limit, Descending: tt.descending})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := []string{}
+			for _, event := range events {
+				got = append(got, event["id"].(map[string]interface{})["eventSeq"].(string))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got events %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventTypesFilter(t *testing.T) {
+	got := EventTypesFilter([]string{"0x3::validator::StakingRequestEvent", "0x3::validator::UnstakingRequestEvent"})
+	want := map[string]interface{}{"Any": []interface{}{
+		map[string]interface{}{"MoveEventType": "0x3::validator::StakingRequestEvent"},
+		map[string]interface{}{"MoveEventType": "0x3::validator::UnstakingRequestEvent"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EventTypesFilter = %v, want %v", got, want)
+	}
+	if err := ValidateEventFilter(got); err != nil {
+		t.Errorf("composed filter is invalid: %v", err)
+	}
+
+	if got := EventTypesFilter([]string{"0x2::m::E"}); !reflect.DeepEqual(got, EventTypeFilter("0x2::m::E")) {
+		t.Errorf("single type = %v", got)
+	}
+	if got := EventTypesFilter(nil); !reflect.DeepEqual(got, EventTypeFilter("")) {
+		t.Errorf("no types = %v", got)
+	}
+}
+
+func TestValidateEventFilter(t *testing.T) {
+	tests := []struct {
+		filter  string
+		wantErr string
+	}{
+		{filter: `{"All":[]}`},
+		{filter: `{"Any":[{"MoveEventType":"0x2::m::E"},{"Sender":"0xa"}]}`},
+		{filter: `{"And":[{"MoveModule":{"package":"0x2","module":"m"}},{"Or":[{"Sender":"0xa"},{"Sender":"0xb"}]}]}`},
+		{filter: `{}`, wantErr: "exactly one key"},
+		{filter: `{"Sender":"0xa","Transaction":"tx"}`, wantErr: "exactly one key"},
+		{filter: `{"EventType":"0x2::m::E"}`, wantErr: "unknown event filter"},
+		{filter: `{"Any":{"Sender":"0xa"}}`, wantErr: "list of filters"},
+		{filter: `{"Or":[{"Sender":"0xa"}]}`, wantErr: "exactly 2 filters"},
+		{filter: `{"Any":[{"Sender":"0xa"},{"Bogus":1}]}`, wantErr: "element 1: unknown event filter"},
+		{filter: `{"MoveEventType":""}`, wantErr: "needs a value"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseEventFilter([]byte(tt.filter))
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ParseEventFilter(%s) = %v", tt.filter, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseEventFilter(%s) = %v, want error containing %q", tt.filter, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBackfillEventsStopsDuringRetryBackoff(t *testing.T) {
+	oldRetry := retryDelay
+	retryDelay = time.Minute
+	defer func() { retryDelay = oldRetry }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_queryEvents": func(params []interface{}) mockResponse {
+			calls++
+			// Cancel once the page has failed and the backfill is backing off
+			time.AfterFunc(50*time.Millisecond, cancel)
+			return mockResponse{Status: 429, Raw: "rate limited"}
+		},
+	})
+
+	start := time.Now()
+	_, err := BackfillEvents(ctx, client, EventTypeFilter(""), EventBackfillOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %s to stop, want it to return promptly", elapsed)
+	}
+	if calls != 1 {
+		t.Errorf("made %d calls, want 1: retries must wait for the backoff", calls)
+	}
+}
@@ -0,0 +1,231 @@
+package suitrace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A malformed event filter expression. Pos is the 1-based character
+// position of the problem in the expression.
+type ExpressionError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ExpressionError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+// Fields of an event filter expression and the filter each compiles to
+var eventExpressionFields = map[string]func(value string) (map[string]interface{}, error){
+	"package": func(value string) (map[string]interface{}, error) {
+		return map[string]interface{}{"Package": value}, nil
+	},
+	"module": func(value string) (map[string]interface{}, error) {
+		pkg, module, ok := strings.Cut(value, "::")
+		if !ok || pkg == "" || module == "" || strings.Contains(module, "::") {
+			return nil, fmt.Errorf("module must be <package>::<module>, got %q", value)
+		}
+		return map[string]interface{}{"MoveModule": map[string]interface{}{"package": pkg, "module": module}}, nil
+	},
+	"type": func(value string) (map[string]interface{}, error) {
+		// <package>::<module>::* matches every event type of a module
+		if prefix, ok := strings.CutSuffix(value, "::*"); ok {
+			pkg, module, ok := strings.Cut(prefix, "::")
+			if !ok || pkg == "" || module == "" || strings.Contains(module, "::") {
+				return nil, fmt.Errorf("type wildcard must be <package>::<module>::*, got %q", value)
+			}
+			return map[string]interface{}{"MoveEventModule": map[string]interface{}{"package": pkg, "module": module}}, nil
+		}
+		if strings.Contains(value, "*") {
+			return nil, fmt.Errorf("type only supports a trailing ::* wildcard, got %q", value)
+		}
+		return map[string]interface{}{"MoveEventType": value}, nil
+	},
+	"sender": func(value string) (map[string]interface{}, error) {
+		return map[string]interface{}{"Sender": value}, nil
+	},
+	"tx": func(value string) (map[string]interface{}, error) {
+		return map[string]interface{}{"Transaction": value}, nil
+	},
+}
+
+// Compile an event filter expression such as
+//
+//	package=0x2 AND (type=0x2::coin::* OR sender=0xabc)
+//
+// into a suix_queryEvents filter. Terms are field=value with the fields
+// package, module (<package>::<module>), type (an event type, or
+// <package>::<module>::* for all of a module's events), sender and tx.
+// AND binds tighter than OR, and parentheses group. Keywords and field
+// names are case-insensitive; values containing spaces can be quoted.
+// Errors are *ExpressionError.
+func ParseEventExpression(expr string) (map[string]interface{}, error) {
+	tokens, err := tokenizeExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &expressionParser{tokens: tokens, end: len(expr) + 1}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok != nil {
+		return nil, &ExpressionError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+
+	if err := ValidateEventFilter(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+type expressionToken struct {
+	text   string
+	pos    int  // 1-based
+	quoted bool // A quoted value, never a keyword or operator
+}
+
+// Split expr into parentheses, '=', and words or quoted values
+func tokenizeExpression(expr string) ([]expressionToken, error) {
+	tokens := []expressionToken{}
+	for i := 0; i < len(expr); {
+		switch c := expr[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')' || c == '=':
+			tokens = append(tokens, expressionToken{text: string(c), pos: i + 1})
+			i++
+		case c == '"':
+			end := strings.IndexByte(expr[i+1:], '"')
+			if end < 0 {
+				return nil, &ExpressionError{Pos: i + 1, Msg: "unterminated quoted value"}
+			}
+			tokens = append(tokens, expressionToken{text: expr[i+1 : i+1+end], pos: i + 1, quoted: true})
+			i += end + 2
+		default:
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t\n()=\"", rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, expressionToken{text: expr[start:i], pos: start + 1})
+		}
+	}
+	return tokens, nil
+}
+
+type expressionParser struct {
+	tokens []expressionToken
+	next   int
+	end    int // Position just past the expression, for errors at its end
+}
+
+func (p *expressionParser) peek() *expressionToken {
+	if p.next >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.next]
+}
+
+// Whether the next token is the keyword (AND or OR), consuming it if so
+func (p *expressionParser) keyword(word string) bool {
+	tok := p.peek()
+	if tok == nil || tok.quoted || !strings.EqualFold(tok.text, word) {
+		return false
+	}
+	p.next++
+	return true
+}
+
+// or := and (OR and)*
+func (p *expressionParser) parseOr() (map[string]interface{}, error) {
+	return p.parseList("Any", "OR", p.parseAnd)
+}
+
+// and := term (AND term)*
+func (p *expressionParser) parseAnd() (map[string]interface{}, error) {
+	return p.parseList("All", "AND", p.parseTerm)
+}
+
+// One or more operands joined by keyword, combined under kind when there
+// are several
+func (p *expressionParser) parseList(kind, keyword string, operand func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []interface{}{first}
+	for p.keyword(keyword) {
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, next)
+	}
+
+	if len(filters) == 1 {
+		return first, nil
+	}
+	return map[string]interface{}{kind: filters}, nil
+}
+
+// term := '(' or ')' | field '=' value
+func (p *expressionParser) parseTerm() (map[string]interface{}, error) {
+	tok := p.peek()
+	if tok == nil {
+		return nil, &ExpressionError{Pos: p.end, Msg: "expected a field=value term or '('"}
+	}
+
+	if tok.text == "(" && !tok.quoted {
+		p.next++
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing := p.peek()
+		if closing == nil {
+			return nil, &ExpressionError{Pos: p.end, Msg: fmt.Sprintf("missing ')' for '(' at position %d", tok.pos)}
+		}
+		if closing.text != ")" || closing.quoted {
+			return nil, &ExpressionError{Pos: closing.pos, Msg: fmt.Sprintf("expected ')', got %q", closing.text)}
+		}
+		p.next++
+		return filter, nil
+	}
+
+	if tok.quoted || tok.text == ")" || tok.text == "=" || strings.EqualFold(tok.text, "AND") || strings.EqualFold(tok.text, "OR") {
+		return nil, &ExpressionError{Pos: tok.pos, Msg: fmt.Sprintf("expected a field, got %q", tok.text)}
+	}
+	compile, ok := eventExpressionFields[strings.ToLower(tok.text)]
+	if !ok {
+		return nil, &ExpressionError{Pos: tok.pos, Msg: fmt.Sprintf("unknown field %q (use package, module, type, sender or tx)", tok.text)}
+	}
+	p.next++
+
+	if eq := p.peek(); eq == nil || eq.text != "=" || eq.quoted {
+		pos := p.end
+		if eq != nil {
+			pos = eq.pos
+		}
+		return nil, &ExpressionError{Pos: pos, Msg: fmt.Sprintf("expected '=' after %s", tok.text)}
+	}
+	p.next++
+
+	value := p.peek()
+	if value == nil || (!value.quoted && (value.text == "(" || value.text == ")" || value.text == "=")) || value.text == "" {
+		pos := p.end
+		if value != nil {
+			pos = value.pos
+		}
+		return nil, &ExpressionError{Pos: pos, Msg: fmt.Sprintf("expected a value for %s", tok.text)}
+	}
+	p.next++
+
+	filter, err := compile(value.text)
+	if err != nil {
+		return nil, &ExpressionError{Pos: value.pos, Msg: err.Error()}
+	}
+	return filter, nil
+}
@@ -0,0 +1,81 @@
+package suitrace
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseEventExpression(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{
+			expr: `sender=0xabc`,
+			want: `{"Sender":"0xabc"}`,
+		},
+		{
+			expr: `package=0x2 AND (type=0x2::coin::* OR sender=0xabc)`,
+			want: `{"All":[{"Package":"0x2"},{"Any":[{"MoveEventModule":{"module":"coin","package":"0x2"}},{"Sender":"0xabc"}]}]}`,
+		},
+		{
+			// AND binds tighter than OR
+			expr: `type=0x3::validator::StakingRequestEvent or sender=0xa and tx=Abc`,
+			want: `{"Any":[{"MoveEventType":"0x3::validator::StakingRequestEvent"},{"All":[{"Sender":"0xa"},{"Transaction":"Abc"}]}]}`,
+		},
+		{
+			expr: `Module=0x2::kiosk AND sender="0xb" AND sender=0xc`,
+			want: `{"All":[{"MoveModule":{"module":"kiosk","package":"0x2"}},{"Sender":"0xb"},{"Sender":"0xc"}]}`,
+		},
+		{
+			expr: `((type = 0x2::m::E))`,
+			want: `{"MoveEventType":"0x2::m::E"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		filter, err := ParseEventExpression(tt.expr)
+		if err != nil {
+			t.Errorf("ParseEventExpression(%q): %v", tt.expr, err)
+			continue
+		}
+		got, _ := json.Marshal(filter)
+		if string(got) != tt.want {
+			t.Errorf("ParseEventExpression(%q) =\n%s\nwant\n%s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseEventExpressionErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantPos int
+		wantMsg string
+	}{
+		{expr: ``, wantPos: 1, wantMsg: "expected a field=value term"},
+		{expr: `color=red`, wantPos: 1, wantMsg: `unknown field "color"`},
+		{expr: `sender 0xa`, wantPos: 8, wantMsg: "expected '=' after sender"},
+		{expr: `sender=`, wantPos: 8, wantMsg: "expected a value for sender"},
+		{expr: `sender=0xa AND`, wantPos: 15, wantMsg: "expected a field=value term"},
+		{expr: `(sender=0xa OR tx=b`, wantPos: 20, wantMsg: "missing ')' for '(' at position 1"},
+		{expr: `sender=0xa)`, wantPos: 11, wantMsg: `unexpected ")"`},
+		{expr: `sender=0xa tx=b`, wantPos: 12, wantMsg: `unexpected "tx"`},
+		{expr: `type=0x2::coin*`, wantPos: 6, wantMsg: "trailing ::* wildcard"},
+		{expr: `module=0x2`, wantPos: 8, wantMsg: "<package>::<module>"},
+		{expr: `sender="0xa`, wantPos: 8, wantMsg: "unterminated quoted value"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseEventExpression(tt.expr)
+		var exprErr *ExpressionError
+		if !errors.As(err, &exprErr) {
+			t.Errorf("ParseEventExpression(%q) = %v, want an ExpressionError", tt.expr, err)
+			continue
+		}
+		if exprErr.Pos != tt.wantPos || !strings.Contains(exprErr.Msg, tt.wantMsg) {
+			t.Errorf("ParseEventExpression(%q) = %v, want position %d: %s", tt.expr, err, tt.wantPos, tt.wantMsg)
+		}
+	}
+}
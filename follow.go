@@ -0,0 +1,68 @@
+package suitrace
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const DefaultPollInterval = 2 * time.Second
+
+// Emit every checkpoint from startSeq onward as it is produced, in order,
+// polling the chain head every PollInterval. A checkpoint that fails to
+// fetch is retried on the next poll rather than skipped. The returned
+// channel is closed when ctx is done.
+func (c *Client) FollowCheckpoints(ctx context.Context, startSeq int64) (<-chan CheckpointData, error) {
+	return FollowCheckpoints(ctx, c, startSeq, c.PollInterval)
+}
+
+// Follow new checkpoints from any backend, polling every interval
+// (DefaultPollInterval when zero)
+func FollowCheckpoints(ctx context.Context, b Backend, startSeq int64, interval time.Duration) (<-chan CheckpointData, error) {
+	if startSeq < 0 {
+		return nil, fmt.Errorf("start checkpoint must be >= 0")
+	}
+
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	checkpoints := make(chan CheckpointData)
+
+	go func() {
+		defer close(checkpoints)
+
+		next := startSeq
+		for {
+			latest, err := b.LatestSequenceNumber(ctx)
+			if err != nil {
+				fmt.Printf("Error fetching latest checkpoint: %v\n", err)
+			}
+
+			// Catch up to the chain head, stopping at the first failure
+			for err == nil && next <= latest {
+				var checkpoint *CheckpointData
+				checkpoint, err = b.GetCheckpoint(ctx, next)
+				if err != nil {
+					fmt.Printf("Error fetching checkpoint %d: %v\n", next, err)
+					break
+				}
+
+				select {
+				case checkpoints <- *checkpoint:
+					next++
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			debugPrint(b, "Waiting for checkpoint %d", next)
+
+			if sleepContext(ctx, interval) != nil {
+				return
+			}
+		}
+	}()
+
+	return checkpoints, nil
+}
@@ -0,0 +1,61 @@
+package suitrace
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFollowCheckpointsEmitsInOrderWithoutRepeats(t *testing.T) {
+	var polls int32
+	var failedOnce int32
+	client := newTestClient(t, map[string]mockHandler{
+		// The chain head advances by two checkpoints on every poll
+		"sui_getLatestCheckpointSequenceNumber": func(params []interface{}) mockResponse {
+			n := atomic.AddInt32(&polls, 1)
+			return mockResponse{Result: strconv.Itoa(9 + 2*int(n))}
+		},
+		"sui_getCheckpoint": func(params []interface{}) mockResponse {
+			seq := params[0].(string)
+			// Fail one checkpoint once, it must be retried rather than skipped
+			if seq == "12" && atomic.CompareAndSwapInt32(&failedOnce, 0, 1) {
+				return mockResponse{Error: map[string]interface{}{"code": -32000, "message": "transient"}}
+			}
+			return mockResponse{Result: map[string]interface{}{"digest": "d" + seq, "sequenceNumber": seq}}
+		},
+	})
+	client.PollInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	checkpoints, err := client.FollowCheckpoints(ctx, 10)
+	if err != nil {
+		t.Fatalf("FollowCheckpoints: %v", err)
+	}
+
+	for want := int64(10); want <= 20; want++ {
+		select {
+		case cp := <-checkpoints:
+			if cp.SequenceNumber != want {
+				t.Fatalf("got checkpoint %d, want %d", cp.SequenceNumber, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for checkpoint %d", want)
+		}
+	}
+
+	cancel()
+	for range checkpoints {
+		// Drain until closed
+	}
+}
+
+func TestFollowCheckpointsRejectsNegativeStart(t *testing.T) {
+	client := NewClient("http://unused")
+	if _, err := client.FollowCheckpoints(context.Background(), -1); err == nil {
+		t.Fatal("expected error for negative start")
+	}
+}
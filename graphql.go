@@ -0,0 +1,325 @@
+package suitrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const DefaultGraphQLURL = "https://sui-mainnet.mystenlabs.com/graphql" // Sui mainnet GraphQL
+
+// Maximum page size the Sui GraphQL service accepts for connections
+const graphQLPageSize = 50
+
+// GraphQLClient fetches data from the Sui GraphQL API, mapping results into
+// the same structs as the JSON-RPC Client. Only checkpoints are supported so far.
+type GraphQLClient struct {
+	URL        string
+	HTTPClient *http.Client
+	Debug      bool            // Print queries and responses
+	Limiter    *rate.Limiter   // Every query waits for a token when set
+	APIKey     string          // Sent as "Authorization: Bearer <APIKey>" when set
+	Header     http.Header     // Extra headers sent with every query
+	Tracer     *Tracer         // Records per-query timing phases when set
+	Breaker    *CircuitBreaker // Fails queries fast while the endpoint looks down when set
+	HostLimit  *HostLimiter    // Caps the queries in flight to each host when set
+
+	MaxResponseBytes int64 // Fail with ResponseTooLargeError on longer response bodies; 0 means no limit
+
+	ctx context.Context // Context for requests, set by withContext
+}
+
+// Send queries through the proxy at proxyURL instead of the one named by
+// the environment
+func (g *GraphQLClient) SetProxy(proxyURL string) error {
+	proxy, err := ParseProxyURL(proxyURL)
+	if err != nil {
+		return err
+	}
+	g.HTTPClient.Transport = proxyTransport(proxy)
+	return nil
+}
+
+// Shallow copy of the client whose requests are bound to ctx
+func (g *GraphQLClient) withContext(ctx context.Context) *GraphQLClient {
+	bound := *g
+	bound.ctx = ctx
+	return &bound
+}
+
+// Create a client for the given GraphQL endpoint
+func NewGraphQLClient(url string) *GraphQLClient {
+	return &GraphQLClient{
+		URL:        url,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// An error reported in the errors member of a GraphQL response
+type GraphQLError struct {
+	Messages []string
+}
+
+func (e *GraphQLError) Error() string {
+	return fmt.Sprintf("GraphQL error: %s", strings.Join(e.Messages, "; "))
+}
+
+// Print debug output when Debug is set
+func (g *GraphQLClient) DebugPrint(format string, a ...interface{}) {
+	if g.Debug {
+		fmt.Printf("[DEBUG] "+format+"\n", a...)
+	}
+}
+
+// Run a GraphQL query and decode its data member into out
+func (g *GraphQLClient) query(query string, variables map[string]interface{}, out interface{}) error {
+	payloadBytes, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal query: %w", err)
+	}
+
+	g.DebugPrint("Sending query to %s: %s", g.URL, string(payloadBytes))
+
+	// Request a truncated or otherwise malformed response again
+	for attempt := 0; ; attempt++ {
+		err := g.send(payloadBytes, out)
+		var malformed *MalformedResponseError
+		if !errors.As(err, &malformed) || attempt >= malformedResponseRetries {
+			return err
+		}
+		g.DebugPrint("Malformed response, retrying (%d of %d): %v", attempt+1, malformedResponseRetries, err)
+
+		// Drop whatever the failed decode filled in
+		reflect.ValueOf(out).Elem().SetZero()
+	}
+}
+
+// Send one query payload and decode its data member into out
+func (g *GraphQLClient) send(payloadBytes []byte, out interface{}) error {
+	ctx := g.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := g.Breaker.allow(); err != nil {
+		return err
+	}
+
+	if err := waitLimiter(ctx, g.Limiter); err != nil {
+		return err
+	}
+
+	release, err := g.HostLimit.acquire(ctx, g.URL)
+	if err != nil {
+		return err
+	}
+
+	reqCtx := ctx
+	var timer *requestTimer
+	if g.Tracer != nil {
+		reqCtx, timer = g.Tracer.start(ctx)
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, g.URL, bytes.NewReader(payloadBytes))
+	if err != nil {
+		release()
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setAuthHeaders(req.Header, g.APIKey, g.Header)
+
+	resp, err := g.HTTPClient.Do(req)
+	if err != nil {
+		release()
+		if ctx.Err() == nil {
+			g.Breaker.record(err)
+		}
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	if timer != nil {
+		resp.Body = g.Tracer.traceBody(resp.Body, timer, func(trace RequestTrace) {
+			g.DebugPrint("Timing: %s", trace)
+		})
+	}
+	defer drainAndClose(resp.Body)
+
+	if g.MaxResponseBytes > 0 {
+		resp.Body = limitBody(resp.Body, g.MaxResponseBytes)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		preview, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		httpErr := &HTTPError{StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(preview))}
+		g.Breaker.record(httpErr)
+		return httpErr
+	}
+	g.Breaker.record(nil)
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := decodeJSONBody(resp.Body, &result, g.Debug, g.DebugPrint); err != nil {
+		return err
+	}
+
+	if len(result.Errors) > 0 {
+		gqlErr := &GraphQLError{}
+		for _, e := range result.Errors {
+			gqlErr.Messages = append(gqlErr.Messages, e.Message)
+		}
+		return gqlErr
+	}
+
+	if err := json.Unmarshal(result.Data, out); err != nil {
+		return fmt.Errorf("failed to decode data: %w", err)
+	}
+	return nil
+}
+
+const latestCheckpointQuery = `query {
+  checkpoint {
+    sequenceNumber
+  }
+}`
+
+// Fetch the sequence number of the latest checkpoint
+func (g *GraphQLClient) FetchLatestSequenceNumber() (int64, error) {
+	var data struct {
+		Checkpoint *struct {
+			SequenceNumber int64 `json:"sequenceNumber"`
+		} `json:"checkpoint"`
+	}
+	if err := g.query(latestCheckpointQuery, nil, &data); err != nil {
+		return 0, err
+	}
+	if data.Checkpoint == nil {
+		return 0, fmt.Errorf("no latest checkpoint in response")
+	}
+	return data.Checkpoint.SequenceNumber, nil
+}
+
+const checkpointQuery = `query ($seq: UInt53, $first: Int, $after: String) {
+  checkpoint(id: {sequenceNumber: $seq}) {
+    digest
+    previousCheckpointDigest
+    sequenceNumber
+    timestamp
+    networkTotalTransactions
+    validatorSignatures
+    epoch {
+      epochId
+    }
+    rollingGasSummary {
+      computationCost
+      storageCost
+      storageRebate
+      nonRefundableStorageFee
+    }
+    transactionBlocks(first: $first, after: $after) {
+      pageInfo {
+        hasNextPage
+        endCursor
+      }
+      nodes {
+        digest
+      }
+    }
+  }
+}`
+
+// Fetch a single checkpoint by sequence number, following the pages of its
+// transaction list
+func (g *GraphQLClient) FetchCheckpoint(sequenceNumber int64) (*CheckpointData, error) {
+	var checkpoint *CheckpointData
+	var after interface{}
+
+	for {
+		var data struct {
+			Checkpoint *struct {
+				Digest                   string `json:"digest"`
+				PreviousCheckpointDigest string `json:"previousCheckpointDigest"`
+				SequenceNumber           int64  `json:"sequenceNumber"`
+				Timestamp                string `json:"timestamp"`
+				NetworkTotalTransactions int64  `json:"networkTotalTransactions"`
+				ValidatorSignatures      string `json:"validatorSignatures"`
+				Epoch                    *struct {
+					EpochID int64 `json:"epochId"`
+				} `json:"epoch"`
+				RollingGasSummary map[string]interface{} `json:"rollingGasSummary"`
+				TransactionBlocks struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						Digest string `json:"digest"`
+					} `json:"nodes"`
+				} `json:"transactionBlocks"`
+			} `json:"checkpoint"`
+		}
+
+		variables := map[string]interface{}{"seq": sequenceNumber, "first": graphQLPageSize, "after": after}
+		if err := g.query(checkpointQuery, variables, &data); err != nil {
+			return nil, err
+		}
+
+		cp := data.Checkpoint
+		if cp == nil {
+			return nil, &MalformedCheckpointError{SequenceNumber: sequenceNumber, Reason: "checkpoint not found"}
+		}
+
+		if checkpoint == nil {
+			checkpoint = &CheckpointData{
+				Digest:                   cp.Digest,
+				PreviousDigest:           cp.PreviousCheckpointDigest,
+				SequenceNumber:           cp.SequenceNumber,
+				ValidatorSignature:       cp.ValidatorSignatures,
+				NetworkTotalTransactions: cp.NetworkTotalTransactions,
+				TransactionDigests:       []string{},
+			}
+			if ts, err := time.Parse(time.RFC3339Nano, cp.Timestamp); err == nil {
+				checkpoint.TimestampMs = ts.UnixMilli()
+			}
+			if cp.Epoch != nil {
+				checkpoint.Epoch = cp.Epoch.EpochID
+			}
+			if cp.RollingGasSummary != nil {
+				checkpoint.EpochRollingGasCostSummary = parseGasCostSummary(cp.RollingGasSummary)
+			}
+		}
+
+		for _, tx := range cp.TransactionBlocks.Nodes {
+			checkpoint.TransactionDigests = append(checkpoint.TransactionDigests, tx.Digest)
+		}
+
+		if !cp.TransactionBlocks.PageInfo.HasNextPage {
+			break
+		}
+		after = cp.TransactionBlocks.PageInfo.EndCursor
+	}
+
+	if checkpoint.Digest == "" {
+		return nil, &MalformedCheckpointError{SequenceNumber: sequenceNumber, Reason: "missing digest"}
+	}
+	if checkpoint.SequenceNumber != sequenceNumber {
+		return nil, &MalformedCheckpointError{SequenceNumber: sequenceNumber, Reason: fmt.Sprintf("got sequence number %d", checkpoint.SequenceNumber)}
+	}
+
+	return checkpoint, nil
+}
@@ -0,0 +1,134 @@
+package suitrace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Start a GraphQL server that answers every query with handler's data
+func newTestGraphQLClient(t *testing.T, handler func(query string, variables map[string]interface{}) interface{}) *GraphQLClient {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("mock GraphQL server: failed to decode request: %v", err)
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(handler(req.Query, req.Variables))
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewGraphQLClient(srv.URL)
+}
+
+func TestGraphQLFetchCheckpoint(t *testing.T) {
+	client := newTestGraphQLClient(t, func(query string, variables map[string]interface{}) interface{} {
+		if variables["seq"] != float64(120000000) {
+			t.Errorf("unexpected variables: %v", variables)
+		}
+
+		// Transactions come back in two pages
+		txs := []interface{}{map[string]interface{}{"digest": "tx1"}}
+		pageInfo := map[string]interface{}{"hasNextPage": true, "endCursor": "c1"}
+		if variables["after"] == "c1" {
+			txs = []interface{}{map[string]interface{}{"digest": "tx2"}}
+			pageInfo = map[string]interface{}{"hasNextPage": false, "endCursor": "c2"}
+		}
+
+		return map[string]interface{}{"data": map[string]interface{}{
+			"checkpoint": map[string]interface{}{
+				"digest":                   "9nHkFAmUN3dUr3vbnwPGJw7jF8wjD7X6p9vB6NfWKuoa",
+				"previousCheckpointDigest": "4xaJ8vJQjF4xG2n3cX9m9XhxPTX6XBt4sZdfwd7vX1pr",
+				"sequenceNumber":           120000000,
+				"timestamp":                "2024-12-18T23:00:00.123Z",
+				"networkTotalTransactions": 3184735510,
+				"validatorSignatures":      "sig",
+				"epoch":                    map[string]interface{}{"epochId": 512},
+				"rollingGasSummary": map[string]interface{}{
+					"computationCost":         "481250000000",
+					"storageCost":             "1288924000000",
+					"storageRebate":           "1107281656000",
+					"nonRefundableStorageFee": "11184663192",
+				},
+				"transactionBlocks": map[string]interface{}{
+					"pageInfo": pageInfo,
+					"nodes":    txs,
+				},
+			},
+		}}
+	})
+
+	got, err := client.FetchCheckpoint(120000000)
+	if err != nil {
+		t.Fatalf("FetchCheckpoint: %v", err)
+	}
+
+	if got.Digest != "9nHkFAmUN3dUr3vbnwPGJw7jF8wjD7X6p9vB6NfWKuoa" ||
+		got.PreviousDigest != "4xaJ8vJQjF4xG2n3cX9m9XhxPTX6XBt4sZdfwd7vX1pr" ||
+		got.SequenceNumber != 120000000 ||
+		got.TimestampMs != 1734562800123 ||
+		got.NetworkTotalTransactions != 3184735510 ||
+		got.ValidatorSignature != "sig" ||
+		got.Epoch != 512 {
+		t.Errorf("unexpected checkpoint: %+v", *got)
+	}
+	if gas := got.EpochRollingGasCostSummary; gas == nil || gas.ComputationCost != 481250000000 || gas.NonRefundableStorageFee != 11184663192 {
+		t.Errorf("EpochRollingGasCostSummary = %+v", gas)
+	}
+	if strings.Join(got.TransactionDigests, ",") != "tx1,tx2" {
+		t.Errorf("TransactionDigests = %v, want [tx1 tx2]", got.TransactionDigests)
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	client := newTestGraphQLClient(t, func(query string, variables map[string]interface{}) interface{} {
+		return map[string]interface{}{
+			"data":   nil,
+			"errors": []interface{}{map[string]interface{}{"message": "Unknown field"}},
+		}
+	})
+
+	_, err := client.FetchLatestSequenceNumber()
+	var gqlErr *GraphQLError
+	if !errors.As(err, &gqlErr) || gqlErr.Messages[0] != "Unknown field" {
+		t.Fatalf("got %v, want a GraphQLError", err)
+	}
+}
+
+func TestGraphQLBackendCheckpointRange(t *testing.T) {
+	client := newTestGraphQLClient(t, func(query string, variables map[string]interface{}) interface{} {
+		seq := variables["seq"].(float64)
+		return map[string]interface{}{"data": map[string]interface{}{
+			"checkpoint": map[string]interface{}{
+				"digest":            "d" + strings.Repeat("x", int(seq)-9),
+				"sequenceNumber":    seq,
+				"timestamp":         "2024-12-18T23:00:00Z",
+				"transactionBlocks": map[string]interface{}{"pageInfo": map[string]interface{}{"hasNextPage": false}, "nodes": []interface{}{}},
+			},
+		}}
+	})
+
+	checkpoints, err := FetchCheckpointRange(context.Background(), client, 10, 12, 2)
+	if err != nil {
+		t.Fatalf("FetchCheckpointRange failed: %v", err)
+	}
+	if len(checkpoints) != 3 || checkpoints[0].SequenceNumber != 10 || checkpoints[2].SequenceNumber != 12 {
+		t.Fatalf("unexpected checkpoints: %+v", checkpoints)
+	}
+
+	_, err = FetchObjectHistory(context.Background(), client, testObjectID, HistoryOptions{})
+	if !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("got %v, want ErrNotSupported", err)
+	}
+}
@@ -0,0 +1,88 @@
+package suitrace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Caps the requests in flight to each host, however many clients send
+// them. A slot is taken before a request is sent and given back when its
+// response body is closed, so a slow response keeps its connection counted.
+// This is separate from the rate limiter, which spaces requests out in time
+// but lets any number overlap. Share one between clients; a nil limiter
+// does not limit. WebSocket subscriptions are not counted.
+type HostLimiter struct {
+	PerHost int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// Create a limiter allowing perHost requests in flight to each host
+func NewHostLimiter(perHost int) *HostLimiter {
+	return &HostLimiter{PerHost: perHost}
+}
+
+// The host requests to rawURL are counted against: its lowercased host and
+// port, or the whole URL when it does not parse
+func limiterHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return strings.ToLower(u.Host)
+	}
+	return rawURL
+}
+
+// Wait for a free slot on rawURL's host and return the function that gives
+// it back. Gives up when ctx is cancelled.
+func (l *HostLimiter) acquire(ctx context.Context, rawURL string) (release func(), err error) {
+	if l == nil || l.PerHost <= 0 {
+		return func() {}, nil
+	}
+
+	host := limiterHost(rawURL)
+	l.mu.Lock()
+	if l.slots == nil {
+		l.slots = make(map[string]chan struct{})
+	}
+	slots, ok := l.slots[host]
+	if !ok {
+		slots = make(chan struct{}, l.PerHost)
+		l.slots[host] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for a connection slot on %s: %w", host, ctx.Err())
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-slots }) }, nil
+}
+
+// Requests currently in flight to rawURL's host
+func (l *HostLimiter) InFlight(rawURL string) int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.slots[limiterHost(rawURL)])
+}
+
+// A response body that gives back its host slot when closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
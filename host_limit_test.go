@@ -0,0 +1,85 @@
+package suitrace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHostLimiter(t *testing.T) {
+	gate := make(chan struct{})
+	started := make(chan struct{}, 1)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-gate
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"42"}`))
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"7"}`))
+	}))
+	defer fast.Close()
+
+	limiter := NewHostLimiter(1)
+	client := NewClient(slow.URL)
+	client.HostLimit = limiter
+	// A second endpoint sharing the limiter, like -rpc-b
+	other := *client
+	other.URL = fast.URL
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.FetchLatestSequenceNumber()
+		done <- err
+	}()
+	<-started
+	if n := limiter.InFlight(slow.URL); n != 1 {
+		t.Fatalf("InFlight = %d, want 1", n)
+	}
+
+	// Another host is not held up by the busy one
+	if seq, err := other.FetchLatestSequenceNumber(); err != nil || seq != 7 {
+		t.Fatalf("other host: %d, %v", seq, err)
+	}
+
+	// The busy host's only slot is taken, so a second request waits
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.withContext(ctx).FetchLatestSequenceNumber()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want to time out waiting for a slot", err)
+	}
+
+	close(gate)
+	if err := <-done; err != nil {
+		t.Fatalf("slow request: %v", err)
+	}
+	if n := limiter.InFlight(slow.URL); n != 0 {
+		t.Errorf("InFlight after the response was read = %d, want 0", n)
+	}
+	if seq, err := client.FetchLatestSequenceNumber(); err != nil || seq != 42 {
+		t.Errorf("after release: %d, %v", seq, err)
+	}
+}
+
+func TestHostLimiterReleasesOnHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	client.HostLimit = NewHostLimiter(1)
+	for i := 0; i < 2; i++ {
+		var httpErr *HTTPError
+		if _, err := client.FetchLatestSequenceNumber(); !errors.As(err, &httpErr) {
+			t.Fatalf("request %d: got %v, want the endpoint's HTTP error", i+1, err)
+		}
+	}
+	if n := client.HostLimit.InFlight(srv.URL); n != 0 {
+		t.Errorf("InFlight = %d, want 0", n)
+	}
+}
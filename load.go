@@ -0,0 +1,266 @@
+package suitrace
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// An input file, transparently decompressed when its name ends in .gz
+type inputFile struct {
+	file *os.File
+	gz   *gzip.Reader
+}
+
+// Open a file written by the Save* functions for reading
+func openInputFile(filename string) (*inputFile, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	in := &inputFile{file: file}
+	if strings.HasSuffix(filename, ".gz") {
+		if in.gz, err = gzip.NewReader(file); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read gzip header: %w", err)
+		}
+	}
+	return in, nil
+}
+
+func (f *inputFile) Read(p []byte) (int, error) {
+	if f.gz != nil {
+		return f.gz.Read(p)
+	}
+	return f.file.Read(p)
+}
+
+func (f *inputFile) Close() error {
+	if f.gz != nil {
+		f.gz.Close()
+	}
+	return f.file.Close()
+}
+
+// Decode a whole JSON file into v
+func loadJSON(filename string, v interface{}) error {
+	file, err := openInputFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open JSON file: %w", err)
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse JSON file %s: %w", filename, err)
+	}
+	return nil
+}
+
+// Decode a JSON array file into v, unwrapping the "data" field of files
+// written with ExportMetadata
+func loadJSONArray(filename string, v interface{}) error {
+	var raw json.RawMessage
+	if err := loadJSON(filename, &raw); err != nil {
+		return err
+	}
+
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapped struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &wrapped); err != nil {
+			return fmt.Errorf("failed to parse JSON file %s: %w", filename, err)
+		}
+		if wrapped.Data == nil {
+			return fmt.Errorf("failed to parse JSON file %s: object has no data array", filename)
+		}
+		raw = wrapped.Data
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("failed to parse JSON file %s: %w", filename, err)
+	}
+	return nil
+}
+
+// Load checkpoints saved by SaveCheckpointsToJSON, one file (or shard) at a
+// time. Files written with -fields only fill in the fields they hold; a
+// transactionCount without transactions becomes that many empty digests.
+func LoadCheckpointsJSON(filename string) ([]CheckpointData, error) {
+	var records []struct {
+		CheckpointData
+		TransactionCount *int   `json:"transactionCount"`
+		Timestamp        string `json:"timestamp"`
+	}
+	if err := loadJSONArray(filename, &records); err != nil {
+		return nil, err
+	}
+
+	checkpoints := make([]CheckpointData, len(records))
+	for i, record := range records {
+		cp := record.CheckpointData
+		if cp.TransactionDigests == nil && record.TransactionCount != nil {
+			cp.TransactionDigests = make([]string, *record.TransactionCount)
+		}
+		if cp.TimestampMs == 0 && record.Timestamp != "" {
+			t, err := time.Parse(time.RFC3339, record.Timestamp)
+			if err != nil {
+				return nil, fmt.Errorf("checkpoint %d: invalid timestamp: %w", i, err)
+			}
+			cp.TimestampMs = t.UnixMilli()
+		}
+		checkpoints[i] = cp
+	}
+	return checkpoints, nil
+}
+
+// Load checkpoints saved by SaveCheckpointsToCSV, one file (or shard) at a
+// time. Columns are matched by header, so any -fields selection loads.
+//
+// CSV output is lossy: the default columns carry only TransactionCount, so
+// TransactionDigests comes back as that many empty strings (its length is
+// right, the digests are not), and PreviousDigest and ValidatorSignature
+// are empty unless their columns were selected.
+func LoadCheckpointsCSV(filename string) ([]CheckpointData, error) {
+	file, err := openInputFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	byHeader := make(map[string]checkpointField, len(checkpointFields))
+	for _, field := range checkpointFields {
+		byHeader[field.header] = field
+	}
+
+	fields := make([]checkpointField, len(headers))
+	for i, header := range headers {
+		field, ok := byHeader[header]
+		if !ok {
+			return nil, fmt.Errorf("unknown checkpoint CSV column %q", header)
+		}
+		fields[i] = field
+	}
+
+	var checkpoints []CheckpointData
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV record: %w", err)
+		}
+
+		var cp CheckpointData
+		for i, field := range fields {
+			if err := field.parse(&cp, record[i]); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %w", line, field.header, err)
+			}
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, nil
+}
+
+// Load an object history saved by SaveObjectHistoryToJSON
+func LoadObjectHistoryJSON(filename string) (*ObjectHistory, error) {
+	var history ObjectHistory
+	if err := loadJSON(filename, &history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// A line of a JSON Lines file that could not be used
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Load events from a JSON array or a JSON Lines (NDJSON) file, such as the
+// output of events -follow. In JSON Lines files, lines that are not a JSON
+// object are skipped and returned as LineErrors; blank lines are ignored.
+// Numbers are kept as json.Number so u64 values survive.
+func LoadEventsJSON(filename string) ([]map[string]interface{}, []*LineError, error) {
+	file, err := openInputFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open events file: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	if first, err := peekNonSpace(reader); err == nil && first == '[' {
+		var events []map[string]interface{}
+		decoder := json.NewDecoder(reader)
+		decoder.UseNumber()
+		if err := decoder.Decode(&events); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse JSON file %s: %w", filename, err)
+		}
+		return events, nil, nil
+	}
+
+	events := []map[string]interface{}{}
+	var skipped []*LineError
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, 64<<20) // Events can carry large parsedJson payloads
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var event map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&event); err != nil {
+			skipped = append(skipped, &LineError{Line: line, Err: err})
+			continue
+		}
+		if event == nil || decoder.More() {
+			skipped = append(skipped, &LineError{Line: line, Err: fmt.Errorf("not a single JSON object")})
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return events, skipped, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+
+	return events, skipped, nil
+}
+
+// The first non-whitespace byte of r, without consuming it
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, r.UnreadByte()
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package suitrace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadCheckpointsRoundTrip(t *testing.T) {
+	checkpoints := []CheckpointData{
+		{Digest: "a", PreviousDigest: "z", SequenceNumber: 1, TimestampMs: 1734562800456, ValidatorSignature: "sig", TransactionDigests: []string{"t1", "t2"}, NetworkTotalTransactions: 10, EventRoot: "r"},
+		{Digest: "b", PreviousDigest: "a", SequenceNumber: 2, TimestampMs: 1734562801000, TransactionDigests: []string{}, NetworkTotalTransactions: 10},
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		opts     WriteOptions
+		save     func([]CheckpointData, string, WriteOptions) ([]string, error)
+		load     func(string) ([]CheckpointData, error)
+		want     []CheckpointData
+	}{
+		{
+			name:     "json",
+			filename: "checkpoints.json.gz",
+			save:     SaveCheckpointsToJSON,
+			load:     LoadCheckpointsJSON,
+			want:     checkpoints,
+		},
+		{
+			name:     "csv with every field",
+			filename: "checkpoints.csv",
+			opts:     WriteOptions{Fields: CheckpointFieldNames()},
+			save:     SaveCheckpointsToCSV,
+			load:     LoadCheckpointsCSV,
+			want:     checkpoints,
+		},
+		{
+			name:     "default csv keeps only the transaction count",
+			filename: "checkpoints.csv",
+			save:     SaveCheckpointsToCSV,
+			load:     LoadCheckpointsCSV,
+			want: []CheckpointData{
+				{Digest: "a", SequenceNumber: 1, TimestampMs: 1734562800456, TransactionDigests: []string{"", ""}, NetworkTotalTransactions: 10, EventRoot: "r"},
+				{Digest: "b", SequenceNumber: 2, TimestampMs: 1734562801000, TransactionDigests: []string{}, NetworkTotalTransactions: 10},
+			},
+		},
+		{
+			name:     "json projection with human time",
+			filename: "checkpoints.json",
+			opts:     WriteOptions{Fields: []string{"digest", "timestamp", "transactionCount"}},
+			save:     SaveCheckpointsToJSON,
+			load:     LoadCheckpointsJSON,
+			want: []CheckpointData{
+				{Digest: "a", TimestampMs: 1734562800456, TransactionDigests: []string{"", ""}},
+				{Digest: "b", TimestampMs: 1734562801000, TransactionDigests: []string{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), tt.filename)
+			if _, err := tt.save(checkpoints, filename, tt.opts); err != nil {
+				t.Fatalf("save failed: %v", err)
+			}
+
+			got, err := tt.load(filename)
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loaded %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadObjectHistoryJSON(t *testing.T) {
+	history := &ObjectHistory{
+		ID:        testObjectID,
+		States:    []ObjectState{{Version: 3, Digest: "d", Timestamp: 1734562800456}},
+		FirstSeen: 1734562800456,
+		LastSeen:  1734562800456,
+	}
+
+	metadata := &ExportMetadata{
+		Network:     "mainnet",
+		RPCURL:      "https://rpc.example",
+		GeneratedAt: "2024-12-18T22:20:00Z",
+		Range:       map[string]interface{}{"object": testObjectID},
+	}
+
+	filename := filepath.Join(t.TempDir(), "history.json")
+	if err := SaveObjectHistoryToJSON(history, filename, WriteOptions{Metadata: metadata}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	got, err := LoadObjectHistoryJSON(filename)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	history.GeneratedBy = GeneratedBy()
+	history.Metadata = metadata.embedded()
+	if history.Metadata.ToolVersion != GeneratedBy() {
+		t.Errorf("metadata toolVersion = %q, want %q", history.Metadata.ToolVersion, GeneratedBy())
+	}
+	if !reflect.DeepEqual(got, history) {
+		t.Errorf("loaded %+v, want %+v", got, history)
+	}
+}
+
+func TestLoadEventsJSON(t *testing.T) {
+	dir := t.TempDir()
+
+	ndjson := filepath.Join(dir, "events.ndjson")
+	lines := `{"id":{"txDigest":"a","eventSeq":"0"},"type":"0x2::m::E","parsedJson":{"amount":18446744073709551615}}
+
+not json
+[1, 2]
+null
+{"id":{"txDigest":"b","eventSeq":"0"},"type":"0x2::m::F"} {"extra":true}
+{"id":{"txDigest":"c","eventSeq":"1"},"type":"0x2::m::E"}
+`
+	if err := os.WriteFile(ndjson, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	events, skipped, err := LoadEventsJSON(ndjson)
+	if err != nil {
+		t.Fatalf("LoadEventsJSON: %v", err)
+	}
+	if len(events) != 2 || EventKey(events[1]) != "c:1" {
+		t.Fatalf("got events %v, want a and c", events)
+	}
+	amount := events[0]["parsedJson"].(map[string]interface{})["amount"]
+	if amount != json.Number("18446744073709551615") {
+		t.Errorf("amount = %v (%T), want the exact u64", amount, amount)
+	}
+
+	var skippedLines []int
+	for _, lineErr := range skipped {
+		skippedLines = append(skippedLines, lineErr.Line)
+	}
+	if !reflect.DeepEqual(skippedLines, []int{3, 4, 5, 6}) {
+		t.Errorf("skipped lines %v, want [3 4 5 6]", skippedLines)
+	}
+
+	array := filepath.Join(dir, "events.json")
+	if err := os.WriteFile(array, []byte(` [{"type":"0x2::m::E"},{"type":"0x2::m::F"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events, skipped, err = LoadEventsJSON(array)
+	if err != nil || len(events) != 2 || len(skipped) != 0 {
+		t.Errorf("JSON array: got %d events, %v skipped, err %v", len(events), skipped, err)
+	}
+}
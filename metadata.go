@@ -0,0 +1,83 @@
+package suitrace
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Provenance of an export: where its data came from, which build wrote it
+// and when. JSON arrays are wrapped in an object holding these fields and a
+// "data" array; CSV and Parquet files get a <file>.meta.json sidecar.
+type ExportMetadata struct {
+	Network     string                 `json:"network,omitempty"` // Network name such as mainnet, when known
+	ChainID     string                 `json:"chainId,omitempty"` // Chain identifier reported by the endpoint
+	RPCURL      string                 `json:"rpcUrl,omitempty"`  // Endpoint the data was fetched from
+	ToolVersion string                 `json:"toolVersion"`       // Defaults to GeneratedBy()
+	GeneratedAt string                 `json:"generatedAt"`       // RFC3339 UTC; defaults to when the file is written
+	Range       map[string]interface{} `json:"range,omitempty"`   // What was requested, such as {"start": 1, "end": 9}
+}
+
+// The metadata to write, with ToolVersion and GeneratedAt filled in
+func (m ExportMetadata) stamped() ExportMetadata {
+	if m.ToolVersion == "" {
+		m.ToolVersion = GeneratedBy()
+	}
+	if m.GeneratedAt == "" {
+		m.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	return m
+}
+
+// The metadata to embed in a single JSON document, stamped; nil without
+// metadata
+func (m *ExportMetadata) embedded() *ExportMetadata {
+	if m == nil {
+		return nil
+	}
+	stamped := m.stamped()
+	return &stamped
+}
+
+// The opening of a wrapped JSON array: the metadata object, left open, up to
+// and including the "data" key
+func metadataHeader(meta *ExportMetadata, opts WriteOptions) ([]byte, error) {
+	data, err := marshalJSON(meta.stamped(), "", opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	// Drop the closing brace so the data array can follow
+	if opts.Compact {
+		return append(data[:len(data)-1], `,"data":`...), nil
+	}
+	return append(data[:len(data)-2], ",\n  \"data\": "...), nil
+}
+
+// Write opts.Metadata next to filename as filename.meta.json, for formats
+// that cannot carry it themselves. Does nothing without metadata or when
+// filename is not a regular file, such as /dev/stdout.
+func saveMetadataSidecar(filename string, opts WriteOptions) error {
+	if opts.Metadata == nil {
+		return nil
+	}
+	if info, err := os.Stat(filename); err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	file, err := createOutputFile(filename+".meta.json", WriteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create metadata file: %w", err)
+	}
+	defer file.discard()
+
+	if err := writeJSON(file, opts.Metadata.stamped(), WriteOptions{Canonical: opts.Canonical}); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close metadata file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package suitrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteJSONArrayWithMetadata(t *testing.T) {
+	meta := &ExportMetadata{
+		RPCURL:      "https://rpc.example",
+		ToolVersion: "suitrace/v1.0.0",
+		GeneratedAt: "2024-01-02T03:04:05Z",
+		Range:       map[string]interface{}{"start": 1, "end": 2},
+	}
+	checkpoints := []CheckpointData{
+		{Digest: "a", SequenceNumber: 1, TransactionDigests: []string{"tx1"}},
+		{Digest: "b", SequenceNumber: 2},
+	}
+
+	// The wrapper must match marshaling the same document in one go
+	wrapped := func(data interface{}) struct {
+		ExportMetadata
+		Data interface{} `json:"data"`
+	} {
+		return struct {
+			ExportMetadata
+			Data interface{} `json:"data"`
+		}{*meta, data}
+	}
+
+	tests := []struct {
+		name  string
+		items []CheckpointData
+		opts  WriteOptions
+		want  func() ([]byte, error)
+	}{
+		{
+			name:  "pretty",
+			items: checkpoints,
+			opts:  WriteOptions{Metadata: meta},
+			want:  func() ([]byte, error) { return json.MarshalIndent(wrapped(checkpoints), "", "  ") },
+		},
+		{
+			name:  "compact",
+			items: checkpoints,
+			opts:  WriteOptions{Compact: true, Metadata: meta},
+			want:  func() ([]byte, error) { return json.Marshal(wrapped(checkpoints)) },
+		},
+		{
+			name:  "empty",
+			items: []CheckpointData{},
+			opts:  WriteOptions{Metadata: meta},
+			want:  func() ([]byte, error) { return json.MarshalIndent(wrapped([]CheckpointData{}), "", "  ") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeJSONArray(&buf, tt.items, tt.opts); err != nil {
+				t.Fatalf("writeJSONArray: %v", err)
+			}
+
+			want, err := tt.want()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, '\n')
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("got:\n%s\nwant:\n%s", buf.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestLoadCheckpointsJSONWithMetadata(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "checkpoints.json")
+	checkpoints := []CheckpointData{{Digest: "a", SequenceNumber: 7}}
+	if _, err := SaveCheckpointsToJSON(checkpoints, filename, WriteOptions{Metadata: &ExportMetadata{}}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	got, err := LoadCheckpointsJSON(filename)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Digest != "a" || got[0].SequenceNumber != 7 {
+		t.Errorf("loaded %+v", got)
+	}
+}
+
+func TestCSVMetadataSidecar(t *testing.T) {
+	dir := t.TempDir()
+	meta := &ExportMetadata{RPCURL: "https://rpc.example", Range: map[string]interface{}{"start": 5}}
+
+	files, err := SaveCheckpointsToCSV([]CheckpointData{{Digest: "a", SequenceNumber: 5}}, filepath.Join(dir, "checkpoints.csv"), WriteOptions{Metadata: meta})
+	if err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(files[0] + ".meta.json")
+	if err != nil {
+		t.Fatalf("no sidecar: %v", err)
+	}
+	var got ExportMetadata
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid sidecar: %v", err)
+	}
+	if got.RPCURL != meta.RPCURL || got.ToolVersion != GeneratedBy() || got.GeneratedAt == "" || got.Range["start"] != float64(5) {
+		t.Errorf("sidecar = %+v", got)
+	}
+
+	// Without metadata there is no sidecar
+	plain := filepath.Join(dir, "plain.csv")
+	if _, err := SaveCheckpointsToCSV(nil, plain, WriteOptions{}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if _, err := os.Stat(plain + ".meta.json"); !os.IsNotExist(err) {
+		t.Errorf("unexpected sidecar for plain export: %v", err)
+	}
+}
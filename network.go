@@ -0,0 +1,107 @@
+package suitrace
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Networks reported by DetectNetwork
+const (
+	NetworkMainnet = "mainnet"
+	NetworkTestnet = "testnet"
+	NetworkDevnet  = "devnet"
+	NetworkUnknown = "unknown"
+)
+
+// Chain identifiers (the first four bytes of the genesis checkpoint digest,
+// hex-encoded) of the long-lived networks. Devnet is wiped regularly and
+// gets a new one each time, so it is recognized by its URL instead.
+var knownChainIDs = map[string]string{
+	"35834a8a": NetworkMainnet,
+	"4c78adac": NetworkTestnet,
+}
+
+// Fetch the endpoint's chain identifier with sui_getChainIdentifier. The
+// result is kept in c.ChainID, and a ChainID set beforehand is returned
+// without asking the endpoint.
+func (c *Client) GetChainIdentifier() (string, error) {
+	if c.ChainID != "" {
+		return c.ChainID, nil
+	}
+
+	result, err := c.MakeRPCCall("sui_getChainIdentifier", []interface{}{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get chain identifier: %w", err)
+	}
+	chainID, ok := result["result"].(string)
+	if !ok || chainID == "" {
+		return "", fmt.Errorf("invalid chain identifier response: %v", result["result"])
+	}
+
+	c.ChainID = chainID
+	return chainID, nil
+}
+
+// Name the network the client's endpoint serves: mainnet, testnet, devnet
+// or unknown. See NetworkName.
+func (c *Client) DetectNetwork() (string, error) {
+	chainID, err := c.GetChainIdentifier()
+	if err != nil {
+		return NetworkUnknown, err
+	}
+	return NetworkName(chainID, c.URL), nil
+}
+
+// Name the network of chainID. Unknown chains whose rpcURL host names
+// devnet (such as fullnode.devnet.sui.io) are devnet; a mainnet or testnet
+// URL serving an unknown chain is not trusted.
+func NetworkName(chainID, rpcURL string) string {
+	if network, ok := knownChainIDs[strings.ToLower(chainID)]; ok {
+		return network
+	}
+	if u, err := url.Parse(rpcURL); err == nil && strings.Contains(strings.ToLower(u.Hostname()), NetworkDevnet) {
+		return NetworkDevnet
+	}
+	return NetworkUnknown
+}
+
+// The chain identifier an endpoint reported
+type EndpointChain struct {
+	URL     string
+	ChainID string
+}
+
+// Endpoints that serve different chains, whose data must not be mixed
+type ChainMismatchError struct {
+	Endpoints []EndpointChain
+}
+
+func (e *ChainMismatchError) Error() string {
+	parts := make([]string, len(e.Endpoints))
+	for i, ep := range e.Endpoints {
+		parts[i] = fmt.Sprintf("%s is on %s (chain %s)", ep.URL, NetworkName(ep.ChainID, ep.URL), ep.ChainID)
+	}
+	return "endpoints serve different chains: " + strings.Join(parts, ", ")
+}
+
+// Fetch the chain identifier of every client's endpoint. When they are not
+// all the same, the identifiers are returned along with a
+// *ChainMismatchError.
+func CheckChainIdentifiers(clients ...*Client) ([]EndpointChain, error) {
+	endpoints := make([]EndpointChain, 0, len(clients))
+	for _, c := range clients {
+		chainID, err := c.GetChainIdentifier()
+		if err != nil {
+			return endpoints, fmt.Errorf("%s: %w", c.URL, err)
+		}
+		endpoints = append(endpoints, EndpointChain{URL: c.URL, ChainID: chainID})
+	}
+
+	for _, ep := range endpoints {
+		if !strings.EqualFold(ep.ChainID, endpoints[0].ChainID) {
+			return endpoints, &ChainMismatchError{Endpoints: endpoints}
+		}
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,95 @@
+package suitrace
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDetectNetworkCachesChainID(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getChainIdentifier": func(params []interface{}) mockResponse {
+			calls++
+			return mockResponse{Result: "4c78adac"}
+		},
+	})
+
+	for i := 0; i < 2; i++ {
+		network, err := client.DetectNetwork()
+		if err != nil {
+			t.Fatalf("DetectNetwork: %v", err)
+		}
+		if network != NetworkTestnet {
+			t.Errorf("network = %q, want testnet", network)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("sui_getChainIdentifier called %d times, want 1", calls)
+	}
+	if client.ChainID != "4c78adac" {
+		t.Errorf("ChainID = %q", client.ChainID)
+	}
+}
+
+func TestDetectNetworkChainIDOverride(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getChainIdentifier": func(params []interface{}) mockResponse {
+			t.Error("the chain identifier should not be fetched when ChainID is set")
+			return mockResponse{Result: "4c78adac"}
+		},
+	})
+	client.ChainID = "35834a8a"
+
+	if network, err := client.DetectNetwork(); err != nil || network != NetworkMainnet {
+		t.Errorf("DetectNetwork = %q, %v, want mainnet", network, err)
+	}
+}
+
+func TestNetworkName(t *testing.T) {
+	tests := []struct {
+		chainID, url, want string
+	}{
+		{"35834a8a", "https://rpc.example", NetworkMainnet},
+		{"4C78ADAC", "https://rpc.example", NetworkTestnet},
+		{"a1b2c3d4", "https://fullnode.devnet.sui.io:443", NetworkDevnet},
+		{"a1b2c3d4", "https://fullnode.testnet.sui.io:443", NetworkUnknown},
+		{"a1b2c3d4", "http://localhost:9000", NetworkUnknown},
+	}
+	for _, tt := range tests {
+		if got := NetworkName(tt.chainID, tt.url); got != tt.want {
+			t.Errorf("NetworkName(%q, %q) = %q, want %q", tt.chainID, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestCheckChainIdentifiers(t *testing.T) {
+	chain := func(id string) *Client {
+		return newTestClient(t, map[string]mockHandler{
+			"sui_getChainIdentifier": respond(mockResponse{Result: id}),
+		})
+	}
+
+	mainnetA, mainnetB, testnet := chain("35834a8a"), chain("35834a8a"), chain("4c78adac")
+	endpoints, err := CheckChainIdentifiers(mainnetA, mainnetB)
+	if err != nil {
+		t.Fatalf("CheckChainIdentifiers: %v", err)
+	}
+	if len(endpoints) != 2 || endpoints[1] != (EndpointChain{URL: mainnetB.URL, ChainID: "35834a8a"}) {
+		t.Errorf("endpoints = %+v", endpoints)
+	}
+
+	endpoints, err = CheckChainIdentifiers(mainnetA, testnet)
+	var mismatch *ChainMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("err = %v, want a ChainMismatchError", err)
+	}
+	if len(endpoints) != 2 || len(mismatch.Endpoints) != 2 {
+		t.Errorf("endpoints = %+v, mismatch = %+v", endpoints, mismatch.Endpoints)
+	}
+	for _, want := range []string{"mainnet (chain 35834a8a)", testnet.URL + " is on testnet"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
@@ -1,356 +1,731 @@
-package main
+package suitrace
 
 import (
-	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
-	"flag"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"net/http"
+	"io"
+	"math/big"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
-	"time"
+	"strings"
 )
 
-const (
-	// Exported constant for RPC URL
-	RpcURL = "https://rpc.mainnet.sui.io" // Sui mainnet RPC
-)
+// Maximum number of object IDs in one sui_multiGetObjects call
+const MaxMultiGetObjects = 50
 
 type ObjectState struct {
-	Version    string                 `json:"version"`
-	Digest     string                 `json:"digest"`
-	Type       string                 `json:"type"`
-	Owner      map[string]interface{} `json:"owner"`
+	ObjectID string                 `json:"objectId,omitempty"` // Set when states of several objects are returned together
+	Version  uint64                 `json:"version,string"`
+	Digest   string                 `json:"digest"`
+	Type     string                 `json:"type"`
+	Owner    map[string]interface{} `json:"owner"`
+
+	// Owner's kind (see ClassifyOwner) and owning address or object ID, set
+	// by the JSON writers when WriteOptions.FlattenOwner is on
+	OwnerType    string `json:"ownerType,omitempty"`
+	OwnerAddress string `json:"ownerAddress,omitempty"`
+
 	PreviousTx string                 `json:"previousTransaction"`
+	ChangeKind string                 `json:"changeKind,omitempty"` // What PreviousTx did to the object: created, mutated, transferred, wrapped or deleted
 	Content    map[string]interface{} `json:"content"`
 	Timestamp  int64                  `json:"timestamp"`
+
+	BalanceChanges []BalanceChange          `json:"balanceChanges,omitempty"`
+	Events         []map[string]interface{} `json:"events,omitempty"`
 }
 
-type ObjectHistory struct {
-	ID         string        `json:"id"`
-	States     []ObjectState `json:"states"`
-	FirstSeen  int64         `json:"firstSeen"`
-	LastSeen   int64         `json:"lastSeen"`
-	NumChanges int           `json:"numChanges"`
-	NumOwners  int           `json:"numOwners"`
+// A coin balance movement recorded by a transaction
+type BalanceChange struct {
+	Owner    map[string]interface{} `json:"owner"`
+	CoinType string                 `json:"coinType"`
+	Amount   *big.Int               `json:"amount"`
 }
 
-// Debug mode flag
-var debugMode bool
+// Options controlling how much detail is fetched for an object history
+type HistoryOptions struct {
+	WithBalances bool // Attach each transaction's balance changes to its state
+	WithEvents   bool // Attach events whose parsed fields reference the object
+
+	// Parts of each transaction block to request when reading the object's
+	// state from it. Nil requests only the object changes, plus the events
+	// and balance changes when WithEvents and WithBalances are set.
+	Show *TransactionBlockOptions
 
-// Helper function to print debug info
-func DebugPrint(format string, a ...interface{}) {
-	if debugMode {
-		fmt.Printf("[DEBUG] "+format+"\n", a...)
+	// Resume point of an incremental fetch: only transactions newer than
+	// AfterDigest, and states above AfterVersion, are fetched. Merge the
+	// result into the earlier history with Merge.
+	AfterDigest  string
+	AfterVersion uint64
+
+	// Process at most this many of the object's transactions, newest
+	// first, and mark the history Truncated when there were more. 0 for
+	// no limit.
+	MaxTransactions int
+}
+
+// Parts of a transaction block GetObjectDetailsFromTransaction requested
+// before they became configurable; pass as HistoryOptions.Show to keep the
+// full responses
+var FullObjectTransactionOptions = TransactionBlockOptions{ShowInput: true, ShowEffects: true, ShowObjectChanges: true}
+
+// Parts of a transaction block to request for an object's state in it
+func (o HistoryOptions) transactionOptions() TransactionBlockOptions {
+	show := TransactionBlockOptions{ShowObjectChanges: true}
+	if o.Show != nil {
+		show = *o.Show
+		show.ShowObjectChanges = true // The state is read from the object changes
 	}
+	show.ShowEvents = show.ShowEvents || o.WithEvents
+	show.ShowBalanceChanges = show.ShowBalanceChanges || o.WithBalances
+	return show
+}
+
+type ObjectHistory struct {
+	// Tool and version that wrote the file, set by the JSON writers
+	GeneratedBy string `json:"generatedBy,omitempty"`
+	// Provenance of the export, set by the JSON writers from
+	// WriteOptions.Metadata
+	Metadata *ExportMetadata `json:"metadata,omitempty"`
+
+	ID        string        `json:"id"`
+	States    []ObjectState `json:"states"`
+	FirstSeen int64         `json:"firstSeen"`
+	LastSeen  int64         `json:"lastSeen"`
+
+	// RFC3339 forms of FirstSeen and LastSeen in the -timezone zone (UTC by
+	// default), set by the JSON writers when WriteOptions.HumanTime is on
+	FirstSeenTime string `json:"firstSeenTime,omitempty"`
+	LastSeenTime  string `json:"lastSeenTime,omitempty"`
+	NumChanges    int    `json:"numChanges"`
+	NumOwners     int    `json:"numOwners"`
+
+	// Set when HistoryOptions.MaxTransactions left older transactions out,
+	// so the earliest states are missing
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // Helper function to make RPC calls
-func MakeRPCCall(method string, params []interface{}) (map[string]interface{}, error) {
+func (c *Client) MakeRPCCall(method string, params []interface{}) (map[string]interface{}, error) {
 	payload := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      1,
 		"method":  method,
 		"params":  params,
 	}
-	
+
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %v", err)
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
-	
-	DebugPrint("Sending request to %s: %s", RpcURL, string(payloadBytes))
-	
-	resp, err := http.Post(RpcURL, "application/json", bytes.NewReader(payloadBytes))
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-	
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
-	}
-	
-	DebugPrint("Received response: %s", string(body))
-	
+
+	c.DebugPrint("Sending request to %s: %s", c.URL, string(payloadBytes))
+
 	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+	if err := c.call(payloadBytes, &result); err != nil {
+		return nil, err
 	}
-	
+
 	// Check for API errors
 	if errObj, exists := result["error"]; exists && errObj != nil {
+		if errMap, ok := errObj.(map[string]interface{}); ok {
+			return nil, parseRPCError(errMap)
+		}
 		return nil, fmt.Errorf("API error: %v", errObj)
 	}
-	
+
 	return result, nil
 }
 
-// Get all transactions for an object
-func GetAllObjectTransactions(objectID string) ([]string, error) {
-	result, err := MakeRPCCall("sui_queryTransactionBlocks", []interface{}{
-		map[string]interface{}{
-			"InputObject": objectID,
-		},
-		nil, // cursor
-		nil, // limit
-		true, // descending order
-	})
-	
+// Digests requested per page when listing an object's transactions, the
+// node's maximum
+const DefaultTransactionPageSize = 50
+
+// Transaction filters that together cover an object's history: the
+// transactions that took it as an input, and the ones that changed it
+// without doing so, such as the one that created it
+var objectTransactionFilters = []string{"InputObject", "ChangedObject"}
+
+// A transaction listed for an object, with the checkpoint that orders it and
+// the filters that found it
+type objectTransaction struct {
+	digest     string
+	checkpoint uint64
+	filters    []string
+}
+
+// Get all transactions for an object, newest first. Both filters in
+// objectTransactionFilters are queried, following the pagination cursor
+// until the last page, and the digests are merged without duplicates.
+func (c *Client) GetAllObjectTransactions(objectID string) ([]string, error) {
+	return c.GetObjectTransactions(objectID, 0)
+}
+
+// Get the newest limit transactions for an object like
+// GetAllObjectTransactions, paging no further than needed. 0 for all.
+func (c *Client) GetObjectTransactions(objectID string, limit int) ([]string, error) {
+	objectID, err := NormalizeSuiAddress(objectID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query transactions: %v", err)
+		return nil, err
 	}
-	
-	var txDigests []string
-	
-	if resultObj, ok := result["result"].(map[string]interface{}); ok {
-		if data, ok := resultObj["data"].([]interface{}); ok {
-			for _, tx := range data {
-				if txObj, ok := tx.(map[string]interface{}); ok {
-					if digest, ok := txObj["digest"].(string); ok {
-						txDigests = append(txDigests, digest)
-					}
-				}
+
+	byDigest := map[string]*objectTransaction{}
+	txs := []*objectTransaction{}
+	for _, filter := range objectTransactionFilters {
+		err := c.pageObjectTransactions(filter, objectID, limit, func(digest string, checkpoint uint64) {
+			if tx, ok := byDigest[digest]; ok {
+				tx.filters = append(tx.filters, filter)
+				return
 			}
+			tx := &objectTransaction{digest: digest, checkpoint: checkpoint, filters: []string{filter}}
+			byDigest[digest] = tx
+			txs = append(txs, tx)
+		})
+		if err != nil {
+			return objectTransactionDigests(txs), err
 		}
 	}
-	
-	DebugPrint("Found %d transactions for object %s", len(txDigests), objectID)
-	return txDigests, nil
+
+	// Each query is newest first; interleave them the same way
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].checkpoint > txs[j].checkpoint
+	})
+
+	// The newest limit of each filter include the newest limit overall
+	if limit > 0 && len(txs) > limit {
+		txs = txs[:limit]
+	}
+
+	for _, tx := range txs {
+		c.DebugPrint("  %s (checkpoint %d) found by %s", tx.digest, tx.checkpoint, strings.Join(tx.filters, ", "))
+	}
+	c.DebugPrint("Found %d transactions for object %s", len(txs), objectID)
+	return objectTransactionDigests(txs), nil
 }
 
-// Get object details from a transaction
-func GetObjectDetailsFromTransaction(txDigest string, objectID string) (*ObjectState, error) {
-	result, err := MakeRPCCall("sui_getTransactionBlock", []interface{}{
-		txDigest,
-		map[string]interface{}{
-			"showEffects": true,
-			"showInput": true,
-			"showEvents": false,
-			"showObjectChanges": true,
-			"showBalanceChanges": false,
-		},
+func objectTransactionDigests(txs []*objectTransaction) []string {
+	digests := make([]string, len(txs))
+	for i, tx := range txs {
+		digests[i] = tx.digest
+	}
+	return digests
+}
+
+// Page through the transactions matching {filter: objectID}, newest first,
+// passing each one's digest and checkpoint to handle, and stopping after
+// limit of them unless it is 0
+func (c *Client) pageObjectTransactions(filter, objectID string, limit int, handle func(digest string, checkpoint uint64)) error {
+	err := c.PageTransactionBlocks(map[string]interface{}{filter: objectID}, TransactionQueryOptions{Descending: true, Limit: limit}, func(block map[string]interface{}) {
+		if digest, ok := block["digest"].(string); ok {
+			checkpoint, _ := parseU64(block["checkpoint"])
+			handle(digest, checkpoint)
+		}
 	})
-	
+	if err != nil {
+		return fmt.Errorf("%s: %w", filter, err)
+	}
+	return nil
+}
+
+// Get object details from a transaction
+func (c *Client) GetObjectDetailsFromTransaction(txDigest string, objectID string, opts HistoryOptions) (*ObjectState, error) {
+	objectID, err := NormalizeSuiAddress(objectID)
 	if err != nil {
 		return nil, err
 	}
-	
+
+	result, err := c.MakeRPCCall("sui_getTransactionBlock", []interface{}{txDigest, opts.transactionOptions().params()})
+
+	if err != nil {
+		return nil, err
+	}
+
 	// Extract transaction timestamp
 	var timestamp int64
 	if resultObj, ok := result["result"].(map[string]interface{}); ok {
-		if timestampMs, ok := resultObj["timestamp_ms"].(string); ok {
-			if ts, err := strconv.ParseInt(timestampMs, 10, 64); err == nil {
-				timestamp = ts
-			}
-		}
+		timestamp, _ = transactionTimestamp(resultObj)
+		c.Timestamps.Put(txDigest, timestamp)
 	}
-	
+
 	// Look for object changes related to our object
 	state := &ObjectState{
 		PreviousTx: txDigest,
 		Timestamp:  timestamp,
 	}
-	
+
 	foundObject := false
-	
+
 	if resultObj, ok := result["result"].(map[string]interface{}); ok {
 		if objectChanges, ok := resultObj["objectChanges"].([]interface{}); ok {
 			for _, change := range objectChanges {
 				if changeObj, ok := change.(map[string]interface{}); ok {
 					// Check if this change is for our object
-					if objID, ok := changeObj["objectId"].(string); ok && objID == objectID {
+					if objID, ok := changeObj["objectId"].(string); ok && sameSuiAddress(objID, objectID) {
 						foundObject = true
-						
+
 						// Extract object details
-						if version, ok := changeObj["version"].(float64); ok {
-							state.Version = fmt.Sprintf("%d", int64(version))
+						if version, err := parseU64(changeObj["version"]); err == nil {
+							state.Version = version
 						}
-						
-						if objType, ok := changeObj["objectType"].(string); ok {
-							state.Type = objType
-						}
-						
+
+						state.Type, state.ChangeKind = objectChangeTypes(changeObj)
+
 						if digest, ok := changeObj["digest"].(string); ok {
 							state.Digest = digest
 						}
-						
+
 						// Extract owner information
-						if owner, ok := changeObj["owner"].(map[string]interface{}); ok {
-							state.Owner = owner
-						}
-						
+						state.Owner = parseOwner(changeObj["owner"])
+
 						break
 					}
 				}
 			}
 		}
 	}
-	
+
 	if !foundObject {
-		return nil, fmt.Errorf("object %s not found in transaction %s", objectID, txDigest)
+		return nil, fmt.Errorf("%w: %s in transaction %s", ErrObjectNotFound, objectID, txDigest)
+	}
+
+	if opts.WithBalances {
+		if resultObj, ok := result["result"].(map[string]interface{}); ok {
+			state.BalanceChanges = parseBalanceChanges(resultObj["balanceChanges"])
+		}
 	}
-	
+
+	if opts.WithEvents {
+		if resultObj, ok := result["result"].(map[string]interface{}); ok {
+			state.Events = eventsReferencingObject(resultObj["events"], objectID)
+		}
+	}
+
 	return state, nil
 }
 
+// The object type and change kind of an objectChanges entry. The object
+// type is under objectType, but some responses put it under type, which
+// otherwise holds the change kind (created, mutated, transferred, wrapped,
+// deleted). Deleted and wrapped objects have no object type at all.
+func objectChangeTypes(change map[string]interface{}) (objectType, kind string) {
+	objectType, _ = change["objectType"].(string)
+	if t, ok := change["type"].(string); ok {
+		if strings.Contains(t, "::") {
+			if objectType == "" {
+				objectType = t
+			}
+		} else {
+			kind = t
+		}
+	}
+	return objectType, kind
+}
+
+// Extract balance changes from a transaction's balanceChanges array
+func parseBalanceChanges(raw interface{}) []BalanceChange {
+	entries, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	changes := []BalanceChange{}
+	for _, entry := range entries {
+		entryObj, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		change := BalanceChange{}
+		if owner, ok := entryObj["owner"].(map[string]interface{}); ok {
+			change.Owner = owner
+		}
+		if coinType, ok := entryObj["coinType"].(string); ok {
+			change.CoinType = coinType
+		}
+
+		// Amounts are signed and can exceed 64 bits
+		if amount, ok := new(big.Int).SetString(fmt.Sprintf("%v", entryObj["amount"]), 10); ok {
+			change.Amount = amount
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// Pick the events whose parsedJson mentions objectID anywhere in its fields
+func eventsReferencingObject(raw interface{}, objectID string) []map[string]interface{} {
+	events, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var related []map[string]interface{}
+	for _, event := range events {
+		eventObj, ok := event.(map[string]interface{})
+		if ok && referencesObject(eventObj["parsedJson"], objectID) {
+			related = append(related, eventObj)
+		}
+	}
+
+	return related
+}
+
+// Recursively search a decoded JSON value for a string equal to objectID
+func referencesObject(v interface{}, objectID string) bool {
+	switch val := v.(type) {
+	case string:
+		return strings.HasPrefix(val, "0x") && sameSuiAddress(val, objectID)
+	case map[string]interface{}:
+		for _, field := range val {
+			if referencesObject(field, objectID) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if referencesObject(item, objectID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Owners are objects such as {"AddressOwner": "0x..."}, except for the
+// string "Immutable", which is kept as {"Immutable": true}
+func parseOwner(raw interface{}) map[string]interface{} {
+	switch owner := raw.(type) {
+	case map[string]interface{}:
+		return owner
+	case string:
+		return map[string]interface{}{owner: true}
+	}
+	return nil
+}
+
 // Get object's current state
-func GetObjectCurrentState(objectID string) (*ObjectState, error) {
-	result, err := MakeRPCCall("sui_getObject", []interface{}{
+func (c *Client) GetObjectCurrentState(objectID string) (*ObjectState, error) {
+	objectID, err := NormalizeSuiAddress(objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.MakeRPCCall("sui_getObject", []interface{}{
 		objectID,
 		map[string]interface{}{
-			"showContent": true,
-			"showOwner": true,
-			"showType": true,
+			"showContent":             true,
+			"showOwner":               true,
+			"showType":                true,
 			"showPreviousTransaction": true,
 		},
 	})
-	
+
 	if err != nil {
 		return nil, err
 	}
-	
-	state := &ObjectState{}
-	
-	if resultObj, ok := result["result"].(map[string]interface{}); ok {
-		if data, ok := resultObj["data"].(map[string]interface{}); ok {
-			// Extract object details
-			if version, ok := data["version"].(float64); ok {
-				state.Version = fmt.Sprintf("%d", int64(version))
-			}
-			
-			if objType, ok := data["type"].(string); ok {
-				state.Type = objType
-			}
-			
-			if digest, ok := data["digest"].(string); ok {
-				state.Digest = digest
-			}
-			
-			// Extract owner information
-			if owner, ok := data["owner"].(map[string]interface{}); ok {
-				state.Owner = owner
-			}
-			
-			// Extract previous transaction
-			if prevTx, ok := data["previousTransaction"].(string); ok {
-				state.PreviousTx = prevTx
-				
-				// Get timestamp from previous transaction
-				txData, err := GetTransactionTimestamp(prevTx)
-				if err == nil && txData > 0 {
-					state.Timestamp = txData
+
+	resultObj, _ := result["result"].(map[string]interface{})
+	data, ok := resultObj["data"].(map[string]interface{})
+	if !ok {
+		return nil, objectNotFoundError(objectID, resultObj["error"])
+	}
+
+	state := parseObjectData(data)
+
+	// Get timestamp from previous transaction
+	if state.PreviousTx != "" {
+		txData, err := c.GetTransactionTimestamp(state.PreviousTx)
+		if err == nil && txData > 0 {
+			state.Timestamp = txData
+		}
+	}
+
+	return &state, nil
+}
+
+// Extract object details from the data field of a sui_getObject style response
+func parseObjectData(data map[string]interface{}) ObjectState {
+	state := ObjectState{}
+
+	if version, err := parseU64(data["version"]); err == nil {
+		state.Version = version
+	}
+
+	if objType, ok := data["type"].(string); ok {
+		state.Type = objType
+	}
+
+	if digest, ok := data["digest"].(string); ok {
+		state.Digest = digest
+	}
+
+	// Extract owner information
+	state.Owner = parseOwner(data["owner"])
+
+	// Extract previous transaction
+	if prevTx, ok := data["previousTransaction"].(string); ok {
+		state.PreviousTx = prevTx
+	}
+
+	// Extract content
+	if content, ok := data["content"].(map[string]interface{}); ok {
+		state.Content = content
+	}
+
+	return state
+}
+
+// Fetch the current state of many objects with sui_multiGetObjects, in
+// batches of MaxMultiGetObjects. Objects that do not exist are skipped with a
+// warning. Timestamps are not looked up to keep this to one call per batch.
+func (c *Client) MultiGetObjects(objectIDs []string) ([]*ObjectState, error) {
+	ids := make([]string, 0, len(objectIDs))
+	for _, id := range objectIDs {
+		normalized, err := NormalizeSuiAddress(id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, normalized)
+	}
+
+	states := []*ObjectState{}
+	for _, batch := range shardItems(ids, MaxMultiGetObjects) {
+		if len(batch) == 0 {
+			continue
+		}
+
+		result, err := c.MakeRPCCall("sui_multiGetObjects", []interface{}{
+			batch,
+			map[string]interface{}{
+				"showContent":             true,
+				"showOwner":               true,
+				"showType":                true,
+				"showPreviousTransaction": true,
+			},
+		})
+		if err != nil {
+			return states, fmt.Errorf("failed to get objects: %w", err)
+		}
+
+		entries, _ := result["result"].([]interface{})
+		for i, entry := range entries {
+			entryObj, _ := entry.(map[string]interface{})
+			data, ok := entryObj["data"].(map[string]interface{})
+			if !ok {
+				id := ""
+				if i < len(batch) {
+					id = batch[i]
 				}
+				fmt.Printf("Warning: object %s not found: %v\n", id, entryObj["error"])
+				continue
 			}
-			
-			// Extract content
-			if content, ok := data["content"].(map[string]interface{}); ok {
-				state.Content = content
+
+			state := parseObjectData(data)
+			if objID, ok := data["objectId"].(string); ok {
+				state.ObjectID = objID
+			} else if i < len(batch) {
+				state.ObjectID = batch[i]
 			}
+			states = append(states, &state)
 		}
 	}
-	
-	return state, nil
+
+	return states, nil
 }
 
-// Get transaction timestamp
-func GetTransactionTimestamp(txDigest string) (int64, error) {
-	result, err := MakeRPCCall("sui_getTransactionBlock", []interface{}{
-		txDigest,
-		map[string]interface{}{
-			"showEffects": true,
-			"showInput": false,
-			"showEvents": false,
-			"showObjectChanges": false,
-			"showBalanceChanges": false,
-		},
-	})
-	
+// Get transaction timestamp. The timestamp is part of every response, so
+// none of the optional parts are requested.
+func (c *Client) GetTransactionTimestamp(txDigest string) (int64, error) {
+	if timestamp, ok := c.Timestamps.Get(txDigest); ok {
+		return timestamp, nil
+	}
+
+	result, err := c.MakeRPCCall("sui_getTransactionBlock", []interface{}{txDigest, TransactionBlockOptions{}.params()})
+
 	if err != nil {
 		return 0, err
 	}
-	
+
 	if resultObj, ok := result["result"].(map[string]interface{}); ok {
-		if timestampMs, ok := resultObj["timestamp_ms"].(string); ok {
-			timestamp, err := strconv.ParseInt(timestampMs, 10, 64)
-			if err == nil {
-				return timestamp, nil
-			}
+		if timestamp, ok := transactionTimestamp(resultObj); ok {
+			c.Timestamps.Put(txDigest, timestamp)
+			return timestamp, nil
 		}
 	}
-	
+
 	return 0, fmt.Errorf("timestamp not found in transaction %s", txDigest)
 }
 
 // Fetch entire object history
-func FetchObjectHistory(objectID string) (*ObjectHistory, error) {
+func (c *Client) FetchObjectHistory(objectID string, opts HistoryOptions) (*ObjectHistory, error) {
+	return FetchObjectHistory(context.Background(), c, objectID, opts)
+}
+
+// Fetch the version history of an object from any backend
+func FetchObjectHistory(ctx context.Context, b Backend, objectID string, opts HistoryOptions) (*ObjectHistory, error) {
+	objectID, err := NormalizeSuiAddress(objectID)
+	if err != nil {
+		return nil, err
+	}
+
 	history := &ObjectHistory{
 		ID:     objectID,
 		States: []ObjectState{},
 	}
-	
+
 	// First, get current state
-	currentState, err := GetObjectCurrentState(objectID)
+	currentState, err := b.GetObject(ctx, objectID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current object state: %v", err)
+		return nil, fmt.Errorf("failed to get current object state: %w", err)
 	}
-	
+
 	// Add current state to history
 	history.States = append(history.States, *currentState)
-	
-	// Get all transactions for this object
-	txDigests, err := GetAllObjectTransactions(objectID)
+
+	// Nothing happened since the resume point
+	upToDate := (opts.AfterDigest != "" && currentState.PreviousTx == opts.AfterDigest) ||
+		(opts.AfterVersion > 0 && currentState.Version <= opts.AfterVersion)
+	if upToDate {
+		history.computeStats()
+		return history, nil
+	}
+
+	// Get all transactions for this object, or one more than the limit to
+	// tell whether any were left out
+	listLimit := 0
+	if opts.MaxTransactions > 0 {
+		listLimit = opts.MaxTransactions + 1
+	}
+	txDigests, err := b.ObjectTransactions(ctx, objectID, listLimit)
+	if opts.MaxTransactions > 0 && len(txDigests) > opts.MaxTransactions {
+		txDigests = txDigests[:opts.MaxTransactions]
+		history.Truncated = true
+	}
 	if err != nil {
 		fmt.Printf("Warning: Failed to get all transactions: %v\n", err)
 		// Continue with just the current state
 	} else {
-		DebugPrint("Found %d transactions for object", len(txDigests))
-		
+		debugPrint(b, "Found %d transactions for object", len(txDigests))
+
 		// Get object state from each transaction
 		for _, txDigest := range txDigests {
-			// Skip if this is the transaction we already have
+			// Digests come newest first, so the rest are already known
+			if opts.AfterDigest != "" && txDigest == opts.AfterDigest {
+				break
+			}
+
+			// Skip the transaction that produced the current state. States
+			// are deduplicated below too, for when PreviousTx is missing.
 			if txDigest == currentState.PreviousTx {
 				continue
 			}
-			
-			state, err := GetObjectDetailsFromTransaction(txDigest, objectID)
+
+			state, err := b.ObjectAtTransaction(ctx, txDigest, objectID, opts)
 			if err != nil {
-				DebugPrint("Warning: Failed to get object details from tx %s: %v", txDigest, err)
+				debugPrint(b, "Warning: Failed to get object details from tx %s: %v", txDigest, err)
 				continue
 			}
-			
+
+			if opts.AfterVersion > 0 && state.Version <= opts.AfterVersion {
+				break
+			}
+
 			// Add to history
 			history.States = append(history.States, *state)
 		}
 	}
-	
+
+	history.States = dedupStates(history.States)
+
 	// Sort states by version
 	sort.Slice(history.States, func(i, j int) bool {
-		vI, _ := strconv.ParseUint(history.States[i].Version, 10, 64)
-		vJ, _ := strconv.ParseUint(history.States[j].Version, 10, 64)
-		return vI < vJ
+		return history.States[i].Version < history.States[j].Version
 	})
-	
-	// Calculate statistics
-	if len(history.States) > 0 {
-		history.NumChanges = len(history.States) - 1
-		
+
+	history.computeStats()
+
+	return history, nil
+}
+
+// Drop states with the same version and digest as an earlier one, such as
+// the current state fetched again from its own transaction
+func dedupStates(states []ObjectState) []ObjectState {
+	type stateKey struct {
+		version uint64
+		digest  string
+	}
+	seen := make(map[stateKey]bool, len(states))
+	unique := states[:0]
+	for _, state := range states {
+		key := stateKey{state.Version, state.Digest}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, state)
+	}
+	return unique
+}
+
+// Merge the states of other, a history of the same object such as one
+// fetched with a resume point, into h. States are keyed by version, and
+// other's copy wins when both have one.
+func (h *ObjectHistory) Merge(other *ObjectHistory) error {
+	if !sameSuiAddress(h.ID, other.ID) {
+		return fmt.Errorf("cannot merge history of %s into history of %s", other.ID, h.ID)
+	}
+
+	byVersion := make(map[uint64]int, len(h.States))
+	for i, state := range h.States {
+		byVersion[state.Version] = i
+	}
+	for _, state := range other.States {
+		if i, ok := byVersion[state.Version]; ok {
+			h.States[i] = state
+			continue
+		}
+		byVersion[state.Version] = len(h.States)
+		h.States = append(h.States, state)
+	}
+	h.Truncated = h.Truncated || other.Truncated
+
+	sort.Slice(h.States, func(i, j int) bool {
+		return h.States[i].Version < h.States[j].Version
+	})
+	h.computeStats()
+	return nil
+}
+
+// Append a newer state, e.g. one seen while watching the object, and
+// update the history's statistics
+func (h *ObjectHistory) Append(state ObjectState) {
+	h.States = append(h.States, state)
+	h.computeStats()
+}
+
+// Recompute the change count, owner count and first/last seen of the
+// history from its states
+func (h *ObjectHistory) computeStats() {
+	if len(h.States) > 0 {
+		h.NumChanges = len(h.States) - 1
+
 		// Track unique owners
 		uniqueOwners := make(map[string]bool)
-		
+
 		// Find first and last seen timestamps
 		var minTimestamp int64 = 9223372036854775807 // Max int64
 		var maxTimestamp int64 = 0
-		
-		for _, state := range history.States {
+
+		for _, state := range h.States {
 			// Track unique owners
 			ownerKey := GetOwnerKey(state.Owner)
 			uniqueOwners[ownerKey] = true
-			
+
 			// Track timestamps
 			if state.Timestamp > 0 {
 				if state.Timestamp < minTimestamp {
@@ -361,18 +736,16 @@ func FetchObjectHistory(objectID string) (*ObjectHistory, error) {
 				}
 			}
 		}
-		
-		history.NumOwners = len(uniqueOwners)
-		
+
+		h.NumOwners = len(uniqueOwners)
+
 		if minTimestamp < 9223372036854775807 {
-			history.FirstSeen = minTimestamp
+			h.FirstSeen = minTimestamp
 		}
 		if maxTimestamp > 0 {
-			history.LastSeen = maxTimestamp
+			h.LastSeen = maxTimestamp
 		}
 	}
-	
-	return history, nil
 }
 
 // Helper function to create a unique key for an owner
@@ -380,126 +753,366 @@ func GetOwnerKey(owner map[string]interface{}) string {
 	if owner == nil {
 		return "unknown"
 	}
-	
+
 	// Convert owner to a unique string representation
 	ownerBytes, err := json.Marshal(owner)
 	if err != nil {
 		return "error"
 	}
-	
+
 	return string(ownerBytes)
 }
 
 // Save object history to JSON file
-func SaveObjectHistoryToJSON(history *ObjectHistory, filename string) error {
-	file, err := os.Create(filename)
+func SaveObjectHistoryToJSON(history *ObjectHistory, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
 	if err != nil {
-		return fmt.Errorf("failed to create JSON file: %v", err)
+		return fmt.Errorf("failed to create JSON file: %w", err)
+	}
+	defer file.discard()
+
+	history = formatHistory(history, opts)
+	stamped := *history
+	stamped.GeneratedBy = GeneratedBy()
+	stamped.Metadata = opts.Metadata.embedded()
+
+	if err := writeJSON(file, &stamped, opts); err != nil {
+		return fmt.Errorf("failed to write JSON data: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close JSON file: %w", err)
+	}
+
+	return nil
+}
+
+// Copy of history with the human-readable first and last seen times filled in
+func withHumanTime(history *ObjectHistory) *ObjectHistory {
+	formatted := *history
+	formatted.FirstSeenTime = FormatMillis(history.FirstSeen)
+	formatted.LastSeenTime = FormatMillis(history.LastSeen)
+	return &formatted
+}
+
+// Copy of history as the JSON writers write it, with human-readable times
+// and flattened owners when opts asks for them
+func formatHistory(history *ObjectHistory, opts WriteOptions) *ObjectHistory {
+	if opts.HumanTime {
+		history = withHumanTime(history)
+	}
+	if opts.FlattenOwner {
+		flat := *history
+		flat.States = make([]ObjectState, len(history.States))
+		for i, state := range history.States {
+			flat.States[i] = state.withFlatOwner()
+		}
+		history = &flat
+	}
+	return history
+}
+
+// Copy of the state with OwnerType and OwnerAddress filled in from Owner
+func (s ObjectState) withFlatOwner() ObjectState {
+	s.OwnerType, s.OwnerAddress = ClassifyOwner(s.Owner)
+	return s
+}
+
+// States as the JSON writers write them, with flattened owners when opts
+// asks for them, and ordered by object ID and version when it asks for
+// canonical output
+func formatStates(states []*ObjectState, opts WriteOptions) []*ObjectState {
+	if opts.FlattenOwner {
+		flat := make([]*ObjectState, len(states))
+		for i, state := range states {
+			s := state.withFlatOwner()
+			flat[i] = &s
+		}
+		states = flat
+	}
+	if opts.Canonical {
+		sorted := append([]*ObjectState(nil), states...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if sorted[i].ObjectID != sorted[j].ObjectID {
+				return sorted[i].ObjectID < sorted[j].ObjectID
+			}
+			return sorted[i].Version < sorted[j].Version
+		})
+		states = sorted
+	}
+	return states
+}
+
+// Save several object histories to one JSON array file
+func SaveObjectHistoriesToJSON(histories []*ObjectHistory, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create JSON file: %w", err)
+	}
+	defer file.discard()
+
+	formatted := make([]*ObjectHistory, len(histories))
+	for i, history := range histories {
+		formatted[i] = formatHistory(history, opts)
+	}
+	if opts.Canonical {
+		// Objects found by a type scan come in the order the scan met them
+		sort.SliceStable(formatted, func(i, j int) bool { return formatted[i].ID < formatted[j].ID })
+	}
+	histories = formatted
+
+	if err := writeJSONArray(file, histories, opts); err != nil {
+		return fmt.Errorf("failed to write JSON data: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close JSON file: %w", err)
+	}
+
+	return nil
+}
+
+// Columns of the object history CSV, one row per state
+var objectHistoryCSVHeader = []string{"ObjectID", "Version", "Digest", "Type", "OwnerType", "OwnerAddress", "PreviousTx", "TimestampMs", "Timestamp"}
+
+// Write the states of one or more object histories as CSV rows
+func WriteObjectHistoriesCSV(w io.Writer, histories []*ObjectHistory) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(objectHistoryCSVHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, history := range histories {
+		for _, state := range history.States {
+			ownerType, ownerAddress := ClassifyOwner(state.Owner)
+			record := []string{
+				history.ID,
+				strconv.FormatUint(state.Version, 10),
+				state.Digest,
+				state.Type,
+				ownerType,
+				ownerAddress,
+				state.PreviousTx,
+				strconv.FormatInt(state.Timestamp, 10),
+				FormatMillis(state.Timestamp),
+			}
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write record to CSV: %w", err)
+			}
+		}
 	}
-	defer file.Close()
-	
-	data, err := json.MarshalIndent(history, "", "  ")
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV file: %w", err)
+	}
+	return nil
+}
+
+// Save an object history to CSV, one row per state
+func SaveObjectHistoryToCSV(history *ObjectHistory, filename string, opts WriteOptions) error {
+	return SaveObjectHistoriesToCSV([]*ObjectHistory{history}, filename, opts)
+}
+
+// Save several object histories to one CSV file, their rows told apart by
+// the ObjectID column
+func SaveObjectHistoriesToCSV(histories []*ObjectHistory, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
 	if err != nil {
-		return fmt.Errorf("failed to marshal history data: %v", err)
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.discard()
+
+	if err := WriteObjectHistoriesCSV(file, histories); err != nil {
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close CSV file: %w", err)
 	}
-	
-	_, err = file.Write(data)
+
+	return saveMetadataSidecar(filename, opts)
+}
+
+// Save object states to one JSON array file
+func SaveObjectStatesToJSON(states []*ObjectState, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
 	if err != nil {
-		return fmt.Errorf("failed to write JSON data: %v", err)
+		return fmt.Errorf("failed to create JSON file: %w", err)
 	}
-	
+	defer file.discard()
+
+	if err := writeJSONArray(file, formatStates(states, opts), opts); err != nil {
+		return fmt.Errorf("failed to write JSON data: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close JSON file: %w", err)
+	}
+
 	return nil
 }
 
+// Save an object history to <dir>/<objectId>.json, returning the file written
+func SaveObjectHistoryToDir(history *ObjectHistory, dir string, opts WriteOptions) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	ext := ".json"
+	if opts.Gzip {
+		ext += ".gz"
+	}
+
+	filename := filepath.Join(dir, history.ID+ext)
+	if err := SaveObjectHistoryToJSON(history, filename, opts); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// Save each object state to <dir>/<objectId>.json, returning the files written
+func SaveObjectStatesToDir(states []*ObjectState, dir string, opts WriteOptions) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	ext := ".json"
+	if opts.Gzip {
+		ext += ".gz"
+	}
+
+	files := []string{}
+	for _, state := range formatStates(states, opts) {
+		filename := filepath.Join(dir, state.ObjectID+ext)
+
+		file, err := createOutputFile(filename, opts)
+		if err != nil {
+			return files, fmt.Errorf("failed to create JSON file: %w", err)
+		}
+
+		if err := writeJSON(file, state, opts); err != nil {
+			file.discard()
+			return files, fmt.Errorf("failed to write JSON data: %w", err)
+		}
+
+		if err := file.Close(); err != nil {
+			return files, fmt.Errorf("failed to close JSON file: %w", err)
+		}
+		files = append(files, filename)
+	}
+
+	return files, nil
+}
+
 // Print a summary of the object history
 func PrintObjectSummary(history *ObjectHistory) {
-	fmt.Printf("Object ID: %s\n", history.ID)
-	fmt.Printf("Number of versions: %d\n", len(history.States))
-	fmt.Printf("Number of changes: %d\n", history.NumChanges)
-	fmt.Printf("Number of owners: %d\n", history.NumOwners)
-	
+	WriteObjectSummary(os.Stdout, history)
+}
+
+// Write the summary printed by PrintObjectSummary to w
+func WriteObjectSummary(w io.Writer, history *ObjectHistory) {
+	fmt.Fprintf(w, "Object ID: %s\n", history.ID)
+	fmt.Fprintf(w, "Number of versions: %d\n", len(history.States))
+	fmt.Fprintf(w, "Number of changes: %d\n", history.NumChanges)
+	fmt.Fprintf(w, "Number of owners: %d\n", history.NumOwners)
+	if history.Truncated {
+		fmt.Fprintln(w, "Truncated: yes, older transactions were not fetched")
+	}
+
 	if history.FirstSeen > 0 {
-		firstSeen := time.Unix(history.FirstSeen/1000, 0)
-		fmt.Printf("First seen: %s\n", firstSeen.Format(time.RFC3339))
+		fmt.Fprintf(w, "First seen: %s\n", FormatMillis(history.FirstSeen))
 	}
-	
+
 	if history.LastSeen > 0 {
-		lastSeen := time.Unix(history.LastSeen/1000, 0)
-		fmt.Printf("Last seen: %s\n", lastSeen.Format(time.RFC3339))
+		fmt.Fprintf(w, "Last seen: %s\n", FormatMillis(history.LastSeen))
 	}
-	
+
 	if len(history.States) > 0 {
-		fmt.Printf("Current type: %s\n", history.States[len(history.States)-1].Type)
+		fmt.Fprintf(w, "Current type: %s\n", history.States[len(history.States)-1].Type)
 	}
-	
-	fmt.Println("Version history:")
+
+	fmt.Fprintln(w, "Version history:")
 	for i, state := range history.States {
 		timestamp := "unknown"
 		if state.Timestamp > 0 {
-			t := time.Unix(state.Timestamp/1000, 0)
-			timestamp = t.Format(time.RFC3339)
-		}
-		fmt.Printf("  %d. Version %s - %s\n", i+1, state.Version, timestamp)
-	}
-}
-
-func main() {
-	objectID := flag.String("object", "", "Object ID to track")
-	outputFile := flag.String("output", "", "Output JSON file (optional)")
-	verbose := flag.Bool("verbose", false, "Print detailed information")
-	debug := flag.Bool("debug", false, "Enable debug mode for API responses")
-	flag.Parse()
-	
-	debugMode = *debug
-	
-	if *objectID == "" {
-		fmt.Println("Error: Object ID is required")
-		flag.Usage()
-		return
-	}
-	
-	startTime := time.Now()
-	fmt.Printf("Fetching history for object: %s\n", *objectID)
-	
-	history, err := FetchObjectHistory(*objectID)
-	if err != nil {
-		log.Fatalf("Failed to fetch object history: %v", err)
-	}
-	
-	elapsedTime := time.Since(startTime)
-	
-	if len(history.States) == 0 {
-		fmt.Println("No object history found!")
-		return
-	}
-	
-	fmt.Printf("Fetched %d versions in %s\n", len(history.States), elapsedTime)
-	
-	// Print summary
-	PrintObjectSummary(history)
-	
-	// Save to JSON if output file is specified
-	if *outputFile != "" {
-		fmt.Printf("Saving history to JSON file: %s\n", *outputFile)
-		if err := SaveObjectHistoryToJSON(history, *outputFile); err != nil {
-			log.Fatalf("Failed to save history to JSON: %v", err)
-		}
-		fmt.Printf("History saved successfully to %s\n", *outputFile)
-	}
-	
-	if *verbose && len(history.States) > 0 {
-		fmt.Println("\nDetailed state information:")
-		for i, state := range history.States {
-			fmt.Printf("\nState %d (Version %s):\n", i+1, state.Version)
-			fmt.Printf("  Digest: %s\n", state.Digest)
-			fmt.Printf("  Type: %s\n", state.Type)
-			fmt.Printf("  Previous Transaction: %s\n", state.PreviousTx)
-			
-			// Print owner details
-			if state.Owner != nil {
-				ownerBytes, _ := json.MarshalIndent(state.Owner, "  ", "  ")
-				fmt.Printf("  Owner: %s\n", string(ownerBytes))
-			}
+			timestamp = FormatMillis(state.Timestamp)
 		}
+		fmt.Fprintf(w, "  %d. Version %d - %s\n", i+1, state.Version, timestamp)
 	}
-}
\ No newline at end of file
+}
+
+// Headline statistics of an object history, without its states
+type ObjectSummary struct {
+	// Tool and version that wrote the file, set by the JSON writers
+	GeneratedBy string `json:"generatedBy,omitempty"`
+	// Provenance of the export, set by the JSON writers from
+	// WriteOptions.Metadata
+	Metadata *ExportMetadata `json:"metadata,omitempty"`
+
+	ID          string `json:"id"`
+	NumVersions int    `json:"numVersions"`
+	NumChanges  int    `json:"numChanges"`
+	NumOwners   int    `json:"numOwners"`
+	FirstSeen   int64  `json:"firstSeen"`
+	LastSeen    int64  `json:"lastSeen"`
+
+	// RFC3339 forms of FirstSeen and LastSeen, set by the JSON writers when
+	// WriteOptions.HumanTime is on
+	FirstSeenTime string `json:"firstSeenTime,omitempty"`
+	LastSeenTime  string `json:"lastSeenTime,omitempty"`
+
+	CurrentType  string                 `json:"currentType"`
+	CurrentOwner map[string]interface{} `json:"currentOwner"`
+	Truncated    bool                   `json:"truncated,omitempty"` // See ObjectHistory.Truncated
+}
+
+// Summarize the history: its statistics and the type and owner of its
+// latest state
+func (h *ObjectHistory) Summary() ObjectSummary {
+	summary := ObjectSummary{
+		ID:          h.ID,
+		NumVersions: len(h.States),
+		NumChanges:  h.NumChanges,
+		NumOwners:   h.NumOwners,
+		FirstSeen:   h.FirstSeen,
+		LastSeen:    h.LastSeen,
+		Truncated:   h.Truncated,
+	}
+	if len(h.States) > 0 {
+		current := h.States[len(h.States)-1]
+		summary.CurrentType = current.Type
+		summary.CurrentOwner = current.Owner
+	}
+	return summary
+}
+
+// Write the history's summary as JSON
+func WriteObjectSummaryJSON(w io.Writer, history *ObjectHistory, opts WriteOptions) error {
+	summary := history.Summary()
+	summary.GeneratedBy = GeneratedBy()
+	summary.Metadata = opts.Metadata.embedded()
+	if opts.HumanTime {
+		summary.FirstSeenTime = FormatMillis(summary.FirstSeen)
+		summary.LastSeenTime = FormatMillis(summary.LastSeen)
+	}
+	return writeJSON(w, summary, opts)
+}
+
+// Save the history's summary to a JSON file
+func SaveObjectSummaryToJSON(history *ObjectHistory, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create JSON file: %w", err)
+	}
+	defer file.discard()
+
+	if err := WriteObjectSummaryJSON(file, history, opts); err != nil {
+		return fmt.Errorf("failed to write JSON data: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close JSON file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,701 @@
+package suitrace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testObjectID = "0x5d8b8a7f9c2e4b6a1d3f0e9c8b7a6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c"
+
+func TestGetObjectCurrentState(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    mockResponse
+		want    ObjectState
+		wantErr string
+	}{
+		{
+			name: "recorded object",
+			resp: fixture(t, "object.json"),
+			want: ObjectState{
+				Version:    421337,
+				Digest:     "7Hq3ZbUqj3iQx5u8WkR5pCn1aXGz2eYvT6sLmB9dFhJo",
+				Type:       "0x2::coin::Coin<0x2::sui::SUI>",
+				PreviousTx: "Bv7m2Nq4sK8pXr6tW3yZ1aC5dE9fG2hJ4kL6mN8pQ1rS",
+				Timestamp:  1734562800456,
+			},
+		},
+		{
+			name:    "API error",
+			resp:    fixture(t, "rpc_error.json"),
+			wantErr: "API error",
+		},
+		{
+			name:    "malformed JSON",
+			resp:    mockResponse{Raw: `not json`},
+			wantErr: "failed to unmarshal response",
+		},
+		{
+			name: "numeric version above 2^53",
+			resp: mockResponse{Raw: `{"jsonrpc":"2.0","id":1,"result":{"data":{"version":9007199254740993}}}`},
+			want: ObjectState{Version: 9007199254740993},
+		},
+		{
+			name: "string version above 2^53",
+			resp: mockResponse{Result: map[string]interface{}{"data": map[string]interface{}{"version": "18446744073709551615"}}},
+			want: ObjectState{Version: 18446744073709551615},
+		},
+		{
+			name:    "missing data",
+			resp:    mockResponse{Result: map[string]interface{}{}},
+			wantErr: "object not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, map[string]mockHandler{
+				"sui_getObject":           respond(tt.resp),
+				"sui_getTransactionBlock": respond(fixture(t, "transaction_block.json")),
+			})
+
+			got, err := client.GetObjectCurrentState(testObjectID)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Version != tt.want.Version ||
+				got.Digest != tt.want.Digest ||
+				got.Type != tt.want.Type ||
+				got.PreviousTx != tt.want.PreviousTx ||
+				got.Timestamp != tt.want.Timestamp {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetObjectDetailsFromTransaction(t *testing.T) {
+	const txDigest = "Cq9sP2vX4mT7yB1nR5kW8zA3dF6hJ9uL2eG4oQ7iN1cV"
+
+	tests := []struct {
+		name     string
+		resp     mockResponse
+		objectID string
+		want     ObjectState
+		wantErr  string
+	}{
+		{
+			name:     "recorded transaction",
+			resp:     fixture(t, "transaction_block.json"),
+			objectID: testObjectID,
+			want: ObjectState{
+				Version:    421300,
+				Digest:     "3Jd8Kk2fNp7Qw1Xz5Rv9Ty4Ub6Ic0Oe3Lg8Mh2Ni5Pj",
+				Type:       "0x2::coin::Coin<0x2::sui::SUI>",
+				PreviousTx: txDigest,
+				ChangeKind: "mutated",
+				Timestamp:  1734562800456,
+			},
+		},
+		{
+			name: "numeric version above 2^53",
+			resp: mockResponse{Raw: `{"jsonrpc":"2.0","id":1,"result":{"objectChanges":[` +
+				`{"type":"mutated","objectId":"` + testObjectID + `","version":9007199254740993}]}}`},
+			objectID: testObjectID,
+			want:     ObjectState{Version: 9007199254740993, PreviousTx: txDigest, ChangeKind: "mutated"},
+		},
+		{
+			name:     "short uppercase ID matches full form",
+			resp:     fixture(t, "transaction_block.json"),
+			objectID: strings.ToUpper(strings.TrimPrefix(testObjectID, "0x")),
+			want: ObjectState{
+				Version:    421300,
+				Digest:     "3Jd8Kk2fNp7Qw1Xz5Rv9Ty4Ub6Ic0Oe3Lg8Mh2Ni5Pj",
+				Type:       "0x2::coin::Coin<0x2::sui::SUI>",
+				PreviousTx: txDigest,
+				ChangeKind: "mutated",
+				Timestamp:  1734562800456,
+			},
+		},
+		{
+			name:     "truncated leading zeros match",
+			resp:     mockResponse{Raw: `{"jsonrpc":"2.0","id":1,"result":{"objectChanges":[{"type":"mutated","objectId":"0x0000000000000000000000000000000000000000000000000000000000000006","version":"7"}]}}`},
+			objectID: "0x6",
+			want:     ObjectState{Version: 7, PreviousTx: txDigest, ChangeKind: "mutated"},
+		},
+		{
+			name: "deleted object without type, digest or owner",
+			resp: mockResponse{Raw: `{"jsonrpc":"2.0","id":1,"result":{"objectChanges":[` +
+				`{"type":"deleted","sender":"0xa","objectId":"` + testObjectID + `","version":"12"}]}}`},
+			objectID: testObjectID,
+			want:     ObjectState{Version: 12, PreviousTx: txDigest, ChangeKind: "deleted"},
+		},
+		{
+			name: "object type under type",
+			resp: mockResponse{Raw: `{"jsonrpc":"2.0","id":1,"result":{"objectChanges":[` +
+				`{"type":"0x2::coin::Coin<0x2::sui::SUI>","objectId":"` + testObjectID + `","version":"3","digest":"d3"}]}}`},
+			objectID: testObjectID,
+			want:     ObjectState{Version: 3, Digest: "d3", Type: "0x2::coin::Coin<0x2::sui::SUI>", PreviousTx: txDigest},
+		},
+		{
+			name:     "invalid ID",
+			resp:     fixture(t, "transaction_block.json"),
+			objectID: "0xnothex",
+			wantErr:  "invalid Sui address",
+		},
+		{
+			name:     "object not in changes",
+			resp:     fixture(t, "transaction_block.json"),
+			objectID: "0x2222222222222222222222222222222222222222222222222222222222222222",
+			wantErr:  "object not found",
+		},
+		{
+			name:     "API error",
+			resp:     fixture(t, "rpc_error.json"),
+			objectID: testObjectID,
+			wantErr:  "API error",
+		},
+		{
+			name:     "malformed JSON",
+			resp:     mockResponse{Raw: `{"result":`},
+			objectID: testObjectID,
+			wantErr:  "failed to unmarshal response",
+		},
+		{
+			name:     "missing objectChanges",
+			resp:     mockResponse{Result: map[string]interface{}{"digest": txDigest}},
+			objectID: testObjectID,
+			wantErr:  "object not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, map[string]mockHandler{
+				"sui_getTransactionBlock": respond(tt.resp),
+			})
+
+			got, err := client.GetObjectDetailsFromTransaction(txDigest, tt.objectID, HistoryOptions{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got.Version != tt.want.Version ||
+				got.Digest != tt.want.Digest ||
+				got.Type != tt.want.Type ||
+				got.PreviousTx != tt.want.PreviousTx ||
+				got.ChangeKind != tt.want.ChangeKind ||
+				got.Timestamp != tt.want.Timestamp {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetObjectDetailsFromTransactionShowOptions(t *testing.T) {
+	const txDigest = "Cq9sP2vX4mT7yB1nR5kW8zA3dF6hJ9uL2eG4oQ7iN1cV"
+
+	tests := []struct {
+		name string
+		opts HistoryOptions
+		want map[string]bool
+	}{
+		{
+			name: "minimal by default",
+			opts: HistoryOptions{},
+			want: map[string]bool{"showObjectChanges": true, "showInput": false, "showEffects": false, "showEvents": false},
+		},
+		{
+			name: "full options reproduce the old request",
+			opts: HistoryOptions{Show: &FullObjectTransactionOptions},
+			want: map[string]bool{"showObjectChanges": true, "showInput": true, "showEffects": true, "showEvents": false},
+		},
+		{
+			name: "object changes and events are always added",
+			opts: HistoryOptions{WithEvents: true, Show: &TransactionBlockOptions{ShowEffects: true}},
+			want: map[string]bool{"showObjectChanges": true, "showInput": false, "showEffects": true, "showEvents": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotOptions map[string]interface{}
+			client := newTestClient(t, map[string]mockHandler{
+				"sui_getTransactionBlock": func(params []interface{}) mockResponse {
+					gotOptions = params[1].(map[string]interface{})
+					return fixture(t, "transaction_block.json")
+				},
+			})
+
+			if _, err := client.GetObjectDetailsFromTransaction(txDigest, testObjectID, tt.opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for key, want := range tt.want {
+				if gotOptions[key] != want {
+					t.Errorf("%s = %v, want %v", key, gotOptions[key], want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetObjectDetailsFromTransactionWithBalances(t *testing.T) {
+	const txDigest = "Cq9sP2vX4mT7yB1nR5kW8zA3dF6hJ9uL2eG4oQ7iN1cV"
+
+	for _, withBalances := range []bool{false, true} {
+		var gotOptions map[string]interface{}
+		client := newTestClient(t, map[string]mockHandler{
+			"sui_getTransactionBlock": func(params []interface{}) mockResponse {
+				gotOptions = params[1].(map[string]interface{})
+				return fixture(t, "transaction_block.json")
+			},
+		})
+
+		state, err := client.GetObjectDetailsFromTransaction(txDigest, testObjectID, HistoryOptions{WithBalances: withBalances})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotOptions["showBalanceChanges"] != withBalances {
+			t.Errorf("showBalanceChanges = %v, want %v", gotOptions["showBalanceChanges"], withBalances)
+		}
+
+		if !withBalances {
+			if state.BalanceChanges != nil {
+				t.Errorf("BalanceChanges = %v, want none without the option", state.BalanceChanges)
+			}
+			continue
+		}
+
+		if len(state.BalanceChanges) != 2 {
+			t.Fatalf("got %d balance changes, want 2", len(state.BalanceChanges))
+		}
+		first := state.BalanceChanges[0]
+		if first.CoinType != "0x2::sui::SUI" || first.Amount.String() != "-123456789012345678901234567890" {
+			t.Errorf("first balance change = %+v (amount %s)", first, first.Amount)
+		}
+		if first.Owner["AddressOwner"] == nil {
+			t.Errorf("owner not parsed: %v", first.Owner)
+		}
+		if state.BalanceChanges[1].Amount.String() != "2500" {
+			t.Errorf("second amount = %s, want 2500", state.BalanceChanges[1].Amount)
+		}
+	}
+}
+
+func TestGetObjectDetailsFromTransactionWithEvents(t *testing.T) {
+	var gotOptions map[string]interface{}
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getTransactionBlock": func(params []interface{}) mockResponse {
+			gotOptions = params[1].(map[string]interface{})
+			return fixture(t, "transaction_block.json")
+		},
+	})
+
+	// Short form of testObjectID must still match the nested reference
+	state, err := client.GetObjectDetailsFromTransaction("Cq9sP2vX4mT7yB1nR5kW8zA3dF6hJ9uL2eG4oQ7iN1cV",
+		strings.TrimPrefix(testObjectID, "0x"), HistoryOptions{WithEvents: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotOptions["showEvents"] != true {
+		t.Errorf("showEvents = %v, want true", gotOptions["showEvents"])
+	}
+	if len(state.Events) != 1 || state.Events[0]["type"] != "0xdee9::clob_v2::OrderPlaced<0x2::sui::SUI>" {
+		t.Errorf("events = %v, want only the OrderPlaced event", state.Events)
+	}
+}
+
+func TestMultiGetObjectsBatches(t *testing.T) {
+	ids := []string{}
+	for i := 1; i <= MaxMultiGetObjects+1; i++ {
+		ids = append(ids, fmt.Sprintf("0x%x", i))
+	}
+	missing, _ := NormalizeSuiAddress("0x3")
+
+	var batchSizes []int
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_multiGetObjects": func(params []interface{}) mockResponse {
+			batch, _ := params[0].([]interface{})
+			batchSizes = append(batchSizes, len(batch))
+
+			entries := []interface{}{}
+			for i, id := range batch {
+				if id == missing {
+					entries = append(entries, map[string]interface{}{
+						"error": map[string]interface{}{"code": "notExists", "object_id": id},
+					})
+					continue
+				}
+				entries = append(entries, map[string]interface{}{
+					"data": map[string]interface{}{"objectId": id, "version": fmt.Sprint(i + 1), "type": "0x2::coin::Coin<0x2::sui::SUI>"},
+				})
+			}
+			return mockResponse{Result: entries}
+		},
+	})
+
+	states, err := client.MultiGetObjects(ids)
+	if err != nil {
+		t.Fatalf("MultiGetObjects: %v", err)
+	}
+
+	if len(batchSizes) != 2 || batchSizes[0] != MaxMultiGetObjects || batchSizes[1] != 1 {
+		t.Errorf("got batch sizes %v, want [%d 1]", batchSizes, MaxMultiGetObjects)
+	}
+	if len(states) != len(ids)-1 {
+		t.Fatalf("got %d states, want %d", len(states), len(ids)-1)
+	}
+
+	first, _ := NormalizeSuiAddress(ids[0])
+	if states[0].ObjectID != first || states[0].Version != 1 {
+		t.Errorf("unexpected first state: %+v", states[0])
+	}
+	for _, state := range states {
+		if state.ObjectID == missing {
+			t.Errorf("missing object %s was returned", missing)
+		}
+	}
+}
+
+func TestWriteObjectSummaryKeepsMillis(t *testing.T) {
+	history := &ObjectHistory{
+		ID:        testObjectID,
+		FirstSeen: 1700000000123,
+		LastSeen:  1700000000423,
+		States: []ObjectState{
+			{Version: 1, Timestamp: 1700000000123},
+			{Version: 2, Timestamp: 1700000000423},
+		},
+	}
+
+	var buf strings.Builder
+	WriteObjectSummary(&buf, history)
+	out := buf.String()
+
+	for _, want := range []string{
+		"First seen: 2023-11-14T22:13:20.123Z",
+		"Last seen: 2023-11-14T22:13:20.423Z",
+		"1. Version 1 - 2023-11-14T22:13:20.123Z",
+		"2. Version 2 - 2023-11-14T22:13:20.423Z",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteObjectSummaryJSON(t *testing.T) {
+	history := &ObjectHistory{
+		ID: testObjectID,
+		States: []ObjectState{
+			{Version: 1, Timestamp: 1700000000123, Type: "0x2::kiosk::Kiosk", Owner: map[string]interface{}{"AddressOwner": "0xa"}},
+			{Version: 2, Timestamp: 1700000000423, Type: "0x2::kiosk::Kiosk", Owner: map[string]interface{}{"AddressOwner": "0xb"}},
+		},
+	}
+	history.computeStats()
+
+	var buf strings.Builder
+	if err := WriteObjectSummaryJSON(&buf, history, WriteOptions{Compact: true, HumanTime: true}); err != nil {
+		t.Fatalf("WriteObjectSummaryJSON: %v", err)
+	}
+
+	want := `{"generatedBy":"` + GeneratedBy() + `","id":"` + testObjectID + `","numVersions":2,"numChanges":1,"numOwners":2,` +
+		`"firstSeen":1700000000123,"lastSeen":1700000000423,` +
+		`"firstSeenTime":"2023-11-14T22:13:20.123Z","lastSeenTime":"2023-11-14T22:13:20.423Z",` +
+		`"currentType":"0x2::kiosk::Kiosk","currentOwner":{"AddressOwner":"0xb"}}` + "\n"
+	if buf.String() != want {
+		t.Errorf("summary =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	metadata := &ExportMetadata{RPCURL: "https://rpc.example", ToolVersion: "suitrace test", GeneratedAt: "2024-12-18T22:20:00Z"}
+	if err := WriteObjectSummaryJSON(&buf, history, WriteOptions{Compact: true, Metadata: metadata}); err != nil {
+		t.Fatalf("WriteObjectSummaryJSON: %v", err)
+	}
+	wantPrefix := `{"generatedBy":"` + GeneratedBy() + `","metadata":{"rpcUrl":"https://rpc.example","toolVersion":"suitrace test","generatedAt":"2024-12-18T22:20:00Z"},"id":`
+	if !strings.HasPrefix(buf.String(), wantPrefix) {
+		t.Errorf("summary with metadata =\n%s\nwant it to start with\n%s", buf.String(), wantPrefix)
+	}
+}
+
+// Backend serving a fixed object history; methods it does not override panic
+type historyBackend struct {
+	Backend
+	current ObjectState
+	states  map[string]ObjectState // By transaction digest
+	digests []string               // Newest first
+	queried []string
+}
+
+func (b *historyBackend) GetObject(ctx context.Context, objectID string) (*ObjectState, error) {
+	state := b.current
+	return &state, nil
+}
+
+func (b *historyBackend) ObjectTransactions(ctx context.Context, objectID string, limit int) ([]string, error) {
+	if limit > 0 && len(b.digests) > limit {
+		return b.digests[:limit], nil
+	}
+	return b.digests, nil
+}
+
+func (b *historyBackend) ObjectAtTransaction(ctx context.Context, txDigest, objectID string, opts HistoryOptions) (*ObjectState, error) {
+	b.queried = append(b.queried, txDigest)
+	state := b.states[txDigest]
+	return &state, nil
+}
+
+func TestFetchObjectHistoryResume(t *testing.T) {
+	newBackend := func() *historyBackend {
+		return &historyBackend{
+			current: ObjectState{Version: 5, PreviousTx: "tx5"},
+			states: map[string]ObjectState{
+				"tx4": {Version: 4, PreviousTx: "tx4"},
+				"tx3": {Version: 3, PreviousTx: "tx3"},
+				"tx2": {Version: 2, PreviousTx: "tx2"},
+			},
+			digests: []string{"tx5", "tx4", "tx3", "tx2"},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		opts        HistoryOptions
+		wantQueried int
+		wantStates  int
+	}{
+		{"full", HistoryOptions{}, 3, 4},
+		{"after digest", HistoryOptions{AfterDigest: "tx3"}, 1, 2},
+		{"after version", HistoryOptions{AfterVersion: 3}, 2, 2},
+		{"up to date", HistoryOptions{AfterDigest: "tx5"}, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBackend()
+			history, err := FetchObjectHistory(context.Background(), b, testObjectID, tt.opts)
+			if err != nil {
+				t.Fatalf("FetchObjectHistory: %v", err)
+			}
+			if len(b.queried) != tt.wantQueried || len(history.States) != tt.wantStates {
+				t.Errorf("queried %v and got %d states, want %d queries and %d states", b.queried, len(history.States), tt.wantQueried, tt.wantStates)
+			}
+		})
+	}
+}
+
+func TestFetchObjectHistoryDedupsCurrentState(t *testing.T) {
+	// Without a previousTransaction the current state's own transaction is
+	// not skipped and yields the same state again
+	b := &historyBackend{
+		current: ObjectState{Version: 5, Digest: "d5"},
+		states: map[string]ObjectState{
+			"tx5": {Version: 5, Digest: "d5", PreviousTx: "tx5"},
+			"tx4": {Version: 4, Digest: "d4", PreviousTx: "tx4"},
+		},
+		digests: []string{"tx5", "tx4"},
+	}
+
+	history, err := FetchObjectHistory(context.Background(), b, testObjectID, HistoryOptions{})
+	if err != nil {
+		t.Fatalf("FetchObjectHistory: %v", err)
+	}
+	if len(history.States) != 2 || history.States[0].Version != 4 || history.States[1].Version != 5 {
+		t.Errorf("states = %+v, want versions 4 and 5", history.States)
+	}
+	if history.NumChanges != 1 {
+		t.Errorf("NumChanges = %d, want 1", history.NumChanges)
+	}
+}
+
+func TestFetchObjectHistoryMaxTransactions(t *testing.T) {
+	b := &historyBackend{
+		current: ObjectState{Version: 5, PreviousTx: "tx5"},
+		states: map[string]ObjectState{
+			"tx4": {Version: 4, PreviousTx: "tx4"},
+			"tx3": {Version: 3, PreviousTx: "tx3"},
+		},
+		digests: []string{"tx5", "tx4", "tx3"},
+	}
+
+	history, err := FetchObjectHistory(context.Background(), b, testObjectID, HistoryOptions{MaxTransactions: 2})
+	if err != nil {
+		t.Fatalf("FetchObjectHistory: %v", err)
+	}
+	if len(history.States) != 2 || !history.Truncated || !history.Summary().Truncated {
+		t.Errorf("got %d states, truncated %v, want 2 states of a truncated history", len(history.States), history.Truncated)
+	}
+
+	history, err = FetchObjectHistory(context.Background(), b, testObjectID, HistoryOptions{MaxTransactions: 3})
+	if err != nil {
+		t.Fatalf("FetchObjectHistory: %v", err)
+	}
+	if len(history.States) != 3 || history.Truncated {
+		t.Errorf("got %d states, truncated %v, want the whole history", len(history.States), history.Truncated)
+	}
+}
+
+func TestObjectHistoryMerge(t *testing.T) {
+	saved := &ObjectHistory{ID: testObjectID, States: []ObjectState{
+		{Version: 1, Timestamp: 100},
+		{Version: 3, Timestamp: 0},
+	}}
+	update := &ObjectHistory{ID: testObjectID, States: []ObjectState{
+		{Version: 3, Timestamp: 300},
+		{Version: 4, Timestamp: 400},
+	}}
+
+	if err := saved.Merge(update); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if len(saved.States) != 3 || saved.States[1].Timestamp != 300 || saved.States[2].Version != 4 {
+		t.Errorf("unexpected merged states: %+v", saved.States)
+	}
+	if saved.FirstSeen != 100 || saved.LastSeen != 400 {
+		t.Errorf("stats not recomputed: first %d, last %d", saved.FirstSeen, saved.LastSeen)
+	}
+
+	if err := saved.Merge(&ObjectHistory{ID: "0x2"}); err == nil {
+		t.Error("expected an error merging another object's history")
+	}
+}
+
+// Serve suix_queryTransactionBlocks from pages keyed by filter and cursor
+func queryPagesHandler(t *testing.T, pages map[string]map[interface{}]mockResponse) mockHandler {
+	return func(params []interface{}) mockResponse {
+		query, _ := params[0].(map[string]interface{})
+		filter, _ := query["filter"].(map[string]interface{})
+		for name, value := range filter {
+			if value != testObjectID || params[3] != true {
+				t.Errorf("unexpected params: %v", params)
+			}
+			resp, ok := pages[name][params[1]]
+			if !ok {
+				t.Errorf("unexpected %s cursor: %v", name, params[1])
+			}
+			return resp
+		}
+		t.Errorf("missing filter: %v", params)
+		return mockResponse{}
+	}
+}
+
+func TestGetAllObjectTransactionsPaginates(t *testing.T) {
+	var pageSizes []interface{}
+	handler := queryPagesHandler(t, map[string]map[interface{}]mockResponse{
+		"InputObject": {
+			nil: {Result: map[string]interface{}{
+				"data":        []interface{}{map[string]interface{}{"digest": "tx4", "checkpoint": "40"}, map[string]interface{}{"digest": "tx3", "checkpoint": "30"}},
+				"nextCursor":  "tx3",
+				"hasNextPage": true,
+			}},
+			"tx3": {Result: map[string]interface{}{
+				"data":        []interface{}{map[string]interface{}{"digest": "tx2", "checkpoint": "20"}},
+				"nextCursor":  "tx2",
+				"hasNextPage": false,
+			}},
+		},
+		"ChangedObject": {
+			nil: {Result: map[string]interface{}{
+				"data":        []interface{}{map[string]interface{}{"digest": "tx4", "checkpoint": "40"}, map[string]interface{}{"digest": "tx1", "checkpoint": "10"}},
+				"nextCursor":  "tx1",
+				"hasNextPage": false,
+			}},
+		},
+	})
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_queryTransactionBlocks": func(params []interface{}) mockResponse {
+			pageSizes = append(pageSizes, params[2])
+			return handler(params)
+		},
+	})
+	client.TransactionPageSize = 2
+
+	digests, err := client.GetAllObjectTransactions(testObjectID)
+	if err != nil {
+		t.Fatalf("GetAllObjectTransactions: %v", err)
+	}
+	if got := strings.Join(digests, ","); got != "tx4,tx3,tx2,tx1" {
+		t.Errorf("digests = %s, want tx4,tx3,tx2,tx1", got)
+	}
+	if len(pageSizes) != 3 || pageSizes[0] != float64(2) {
+		t.Errorf("page sizes = %v, want three pages of 2", pageSizes)
+	}
+
+	// A limit stops each filter's paging once it has enough digests
+	pageSizes = nil
+	digests, err = client.GetObjectTransactions(testObjectID, 2)
+	if err != nil {
+		t.Fatalf("GetObjectTransactions: %v", err)
+	}
+	if got := strings.Join(digests, ","); got != "tx4,tx3" {
+		t.Errorf("digests = %s, want tx4,tx3", got)
+	}
+	if len(pageSizes) != 2 {
+		t.Errorf("made %d requests, want one page per filter", len(pageSizes))
+	}
+}
+
+func TestGetAllObjectTransactionsStopsOnStuckCursor(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_queryTransactionBlocks": func(params []interface{}) mockResponse {
+			calls++
+			return mockResponse{Result: map[string]interface{}{
+				"data":        []interface{}{map[string]interface{}{"digest": "tx1"}},
+				"nextCursor":  "tx1",
+				"hasNextPage": true,
+			}}
+		},
+	})
+
+	digests, err := client.GetAllObjectTransactions(testObjectID)
+	if err != nil {
+		t.Fatalf("GetAllObjectTransactions: %v", err)
+	}
+	// Two pages for each filter, and the digest only once
+	if calls != 4 || len(digests) != 1 {
+		t.Errorf("got %d calls and digests %v, want 4 calls and one digest", calls, digests)
+	}
+}
+
+func TestWriteObjectHistoriesCSV(t *testing.T) {
+	histories := []*ObjectHistory{
+		{ID: "0xa", States: []ObjectState{
+			{Version: 1, Digest: "d1", Type: "0x2::coin::Coin", Owner: map[string]interface{}{"AddressOwner": "0xb"}, PreviousTx: "tx1", Timestamp: 1700000000000},
+			{Version: 2, Digest: "d2", Type: "0x2::coin::Coin", Owner: map[string]interface{}{"Shared": map[string]interface{}{}}, PreviousTx: "tx2"},
+		}},
+		{ID: "0xc", States: []ObjectState{
+			{Version: 5, Digest: "d5", Type: "0x2::kiosk::Item", Owner: map[string]interface{}{"ObjectOwner": "0xd"}, PreviousTx: "tx5", Timestamp: 1700000001000},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteObjectHistoriesCSV(&buf, histories); err != nil {
+		t.Fatalf("WriteObjectHistoriesCSV: %v", err)
+	}
+	want := `ObjectID,Version,Digest,Type,OwnerType,OwnerAddress,PreviousTx,TimestampMs,Timestamp
+0xa,1,d1,0x2::coin::Coin,address,0xb,tx1,1700000000000,2023-11-14T22:13:20.000Z
+0xa,2,d2,0x2::coin::Coin,shared,,tx2,0,
+0xc,5,d5,0x2::kiosk::Item,object,0xd,tx5,1700000001000,2023-11-14T22:13:21.000Z
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,109 @@
+package suitrace
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Upper bound on transactions scanned by FetchObjectsByType
+const DefaultTypeScanLimit = 1000
+
+// Report whether a Move type matches pattern. Patterns containing *, ? or [
+// are globs (e.g. "0x2::coin::Coin<*>"). Otherwise the pattern matches the
+// type exactly or as a prefix ending at a "::" or "<" boundary, so
+// "0x2::coin::Coin" matches "0x2::coin::Coin<0x2::sui::SUI>" but not
+// "0x2::coin::CoinMetadata<...>". An empty pattern matches everything.
+func TypeMatches(objType, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	if strings.ContainsAny(pattern, "*?[") {
+		ok, err := path.Match(pattern, objType)
+		return err == nil && ok
+	}
+
+	if !strings.HasPrefix(objType, pattern) {
+		return false
+	}
+	rest := objType[len(pattern):]
+	return rest == "" || strings.HasPrefix(rest, "::") || strings.HasPrefix(rest, "<")
+}
+
+// Keep only the states whose type matches pattern
+func FilterStatesByType(states []ObjectState, pattern string) []ObjectState {
+	filtered := []ObjectState{}
+	for _, state := range states {
+		if TypeMatches(state.Type, pattern) {
+			filtered = append(filtered, state)
+		}
+	}
+	return filtered
+}
+
+// Find IDs of objects of structType by scanning up to maxTransactions recent
+// transactions that called into the type's defining package. Objects of a Move
+// type can only be created by that package, but objects that were only ever
+// touched by other packages (or by built-in commands such as SplitCoins) will
+// not be found, so treat the result as a sample rather than a full census.
+func (c *Client) FindObjectIDsByType(structType string, maxTransactions int) ([]string, error) {
+	pkg, _, found := strings.Cut(structType, "::")
+	if !found || pkg == "" {
+		return nil, fmt.Errorf("invalid struct type %q, expected <package>::<module>::<name>", structType)
+	}
+
+	seen := make(map[string]bool)
+	ids := []string{}
+	scanned := 0
+
+	filter := map[string]interface{}{
+		"MoveFunction": map[string]interface{}{"package": pkg},
+	}
+	err := c.PageTransactionBlocks(filter, TransactionQueryOptions{
+		Show:       TransactionBlockOptions{ShowObjectChanges: true},
+		Descending: true,
+		Limit:      maxTransactions,
+	}, func(tx map[string]interface{}) {
+		scanned++
+		changes, _ := tx["objectChanges"].([]interface{})
+		for _, change := range changes {
+			changeObj, _ := change.(map[string]interface{})
+			objID, _ := changeObj["objectId"].(string)
+			objType, _ := changeObj["objectType"].(string)
+			if objID != "" && !seen[objID] && TypeMatches(objType, structType) {
+				seen[objID] = true
+				ids = append(ids, objID)
+			}
+		}
+	})
+	if err != nil {
+		return ids, err
+	}
+
+	c.DebugPrint("Found %d objects of type %s in %d transactions", len(ids), structType, scanned)
+	return ids, nil
+}
+
+// Enumerate objects of structType (see FindObjectIDsByType) and fetch the
+// history of each one whose current type still matches
+func (c *Client) FetchObjectsByType(structType string) ([]*ObjectHistory, error) {
+	ids, err := c.FindObjectIDsByType(structType, DefaultTypeScanLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	histories := []*ObjectHistory{}
+	for _, id := range ids {
+		history, err := c.FetchObjectHistory(id, HistoryOptions{})
+		if err != nil {
+			fmt.Printf("Warning: Failed to fetch history for %s: %v\n", id, err)
+			continue
+		}
+		if len(FilterStatesByType(history.States, structType)) > 0 {
+			histories = append(histories, history)
+		}
+	}
+
+	return histories, nil
+}
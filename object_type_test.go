@@ -0,0 +1,100 @@
+package suitrace
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypeMatches(t *testing.T) {
+	tests := []struct {
+		objType string
+		pattern string
+		want    bool
+	}{
+		{"0x2::coin::Coin<0x2::sui::SUI>", "", true},
+		{"0x2::coin::Coin<0x2::sui::SUI>", "0x2::coin::Coin<0x2::sui::SUI>", true},
+		{"0x2::coin::Coin<0x2::sui::SUI>", "0x2::coin::Coin", true},
+		{"0x2::coin::Coin<0x2::sui::SUI>", "0x2::coin", true},
+		{"0x2::coin::Coin<0x2::sui::SUI>", "0x2::coin::Co", false},
+		{"0x2::coin::CoinMetadata<0x2::sui::SUI>", "0x2::coin::Coin", false},
+		{"0x2::coin::Coin<0xabc::usdc::USDC>", "0x2::coin::Coin<0x2::sui::SUI>", false},
+		{"0x2::coin::Coin<0xabc::usdc::USDC>", "0x2::coin::Coin<*>", true},
+		{"0x2::coin::Coin<0xabc::usdc::USDC>", "*::usdc::USDC>", true},
+		{"0x2::coin::Coin<0xabc::usdc::USDC>", "*::usdt::USDT>", false},
+		{"0x2::coin::Coin<0xabc::usdc::USDC>", "0x2::*<*::usdc::USDC>", true},
+		{"0x2::kiosk::Kiosk", "0x2::coin::*", false},
+	}
+
+	for _, tt := range tests {
+		if got := TypeMatches(tt.objType, tt.pattern); got != tt.want {
+			t.Errorf("TypeMatches(%q, %q) = %v, want %v", tt.objType, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestFilterStatesByType(t *testing.T) {
+	states := []ObjectState{
+		{Version: 1, Type: "0x2::coin::Coin<0x2::sui::SUI>"},
+		{Version: 2, Type: "0x2::kiosk::Kiosk"},
+		{Version: 3, Type: "0x2::coin::Coin<0xabc::usdc::USDC>"},
+	}
+
+	got := FilterStatesByType(states, "0x2::coin::Coin")
+	if len(got) != 2 || got[0].Version != 1 || got[1].Version != 3 {
+		t.Errorf("FilterStatesByType = %+v", got)
+	}
+}
+
+func TestFindObjectIDsByType(t *testing.T) {
+	change := func(id, objType string) map[string]interface{} {
+		return map[string]interface{}{"type": "created", "objectId": id, "objectType": objType}
+	}
+	pages := []map[string]interface{}{
+		{
+			"data": []interface{}{
+				map[string]interface{}{"digest": "t1", "objectChanges": []interface{}{
+					change("0xa", "0x5::pool::Pool<0x2::sui::SUI>"),
+					change("0xb", "0x2::coin::Coin<0x2::sui::SUI>"),
+				}},
+			},
+			"nextCursor":  "t1",
+			"hasNextPage": true,
+		},
+		{
+			"data": []interface{}{
+				map[string]interface{}{"digest": "t2", "objectChanges": []interface{}{
+					change("0xa", "0x5::pool::Pool<0x2::sui::SUI>"),
+					change("0xc", "0x5::pool::Pool<0xabc::usdc::USDC>"),
+				}},
+			},
+			"nextCursor":  "t2",
+			"hasNextPage": false,
+		},
+	}
+
+	calls := 0
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_queryTransactionBlocks": func(params []interface{}) mockResponse {
+			query := params[0].(map[string]interface{})
+			filter := query["filter"].(map[string]interface{})["MoveFunction"].(map[string]interface{})
+			if filter["package"] != "0x5" {
+				t.Errorf("queried package %v, want 0x5", filter["package"])
+			}
+			page := pages[calls]
+			calls++
+			return mockResponse{Result: page}
+		},
+	})
+
+	ids, err := client.FindObjectIDsByType("0x5::pool::Pool", 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(ids, ",") != "0xa,0xc" {
+		t.Errorf("ids = %v, want [0xa 0xc]", ids)
+	}
+
+	if _, err := client.FindObjectIDsByType("Pool", 100); err == nil {
+		t.Error("expected error for struct type without package")
+	}
+}
@@ -0,0 +1,295 @@
+package suitrace
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Options shared by the Save* output writers
+type WriteOptions struct {
+	Compact     bool // Write JSON without indentation
+	MaxFileRows int  // Roll over to a new numbered file after this many rows; 0 writes a single file
+	Gzip        bool // Gzip-compress output even when the filename does not end in .gz
+
+	Fields       []string // Only write these fields, in this order; nil writes the default set
+	HumanTime    bool     // Add RFC3339 timestamps next to raw millisecond ones, in the -timezone zone (UTC by default)
+	FlattenOwner bool     // Add ownerType and ownerAddress next to each object state's owner map
+	Canonical    bool     // Sort every object's keys, recursively, so the same data always gives the same bytes
+
+	Metadata *ExportMetadata // Provenance to record with the export; nil writes bare data
+}
+
+// Layout of human-readable timestamps: RFC3339 with milliseconds
+const humanTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// Time zone of every human-readable timestamp: -human-time columns, object
+// summaries and graph labels. UTC unless changed, so output does not depend
+// on the machine's local zone.
+var OutputLocation = time.UTC
+
+// Format a millisecond Unix timestamp for humans, or "" when it is unset
+func FormatMillis(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).In(OutputLocation).Format(humanTimeLayout)
+}
+
+// A JSON object that keeps its keys in insertion order
+type orderedObject struct {
+	keys   []string
+	values []interface{}
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		keyData, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueData, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyData)
+		buf.WriteString(":")
+		buf.Write(valueData)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// An output file, optionally gzip-compressed. Close finalizes the gzip
+// stream before closing the file and is safe to call more than once. A file
+// that fails to close, or is discarded before being closed, is removed so a
+// failed export never leaves a truncated file that looks complete.
+type outputFile struct {
+	file      *os.File
+	gz        *gzip.Writer
+	closed    bool
+	removable bool // A regular file we created, not a device such as /dev/stdout
+}
+
+// Create filename for writing, compressing it when the name ends in .gz or
+// opts.Gzip is set
+func createOutputFile(filename string, opts WriteOptions) (*outputFile, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &outputFile{file: file}
+	if info, err := file.Stat(); err == nil && info.Mode().IsRegular() {
+		out.removable = true
+	}
+	if opts.Gzip || strings.HasSuffix(filename, ".gz") {
+		out.gz = gzip.NewWriter(file)
+	}
+	return out, nil
+}
+
+func (f *outputFile) Write(p []byte) (int, error) {
+	if f.gz != nil {
+		return f.gz.Write(p)
+	}
+	return f.file.Write(p)
+}
+
+func (f *outputFile) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	var err error
+	if f.gz != nil {
+		err = f.gz.Close()
+	}
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		f.remove()
+	}
+	return err
+}
+
+// Close and remove a file whose writing failed. Does nothing once Close has
+// been called, so it can be deferred right after createOutputFile.
+func (f *outputFile) discard() {
+	if f.closed {
+		return
+	}
+	f.closed = true
+
+	if f.gz != nil {
+		f.gz.Close()
+	}
+	f.file.Close()
+	f.remove()
+}
+
+func (f *outputFile) remove() {
+	if f.removable {
+		os.Remove(f.file.Name())
+	}
+}
+
+// Stream a JSON array one element at a time so large exports never build
+// the whole document in memory. Pretty output matches json.MarshalIndent.
+// With opts.Metadata the array is written as the "data" field of an object
+// holding the metadata.
+func writeJSONArray[T any](w io.Writer, items []T, opts WriteOptions) error {
+	bw := bufio.NewWriter(w)
+
+	indent, closing := "", "\n"
+	if opts.Metadata != nil {
+		header, err := metadataHeader(opts.Metadata, opts)
+		if err != nil {
+			return err
+		}
+		bw.Write(header)
+		if opts.Compact {
+			closing = "}\n"
+		} else {
+			indent, closing = "  ", "\n}\n"
+		}
+	}
+
+	if len(items) == 0 {
+		bw.WriteString("[]" + closing)
+		return bw.Flush()
+	}
+
+	bw.WriteString("[")
+	for i, item := range items {
+		data, err := marshalJSON(item, indent+"  ", opts)
+		if err != nil {
+			return fmt.Errorf("failed to marshal element %d: %w", i, err)
+		}
+
+		if i > 0 {
+			bw.WriteString(",")
+		}
+		if !opts.Compact {
+			bw.WriteString("\n" + indent + "  ")
+		}
+		if _, err := bw.Write(data); err != nil {
+			return err
+		}
+	}
+	if !opts.Compact {
+		bw.WriteString("\n" + indent)
+	}
+	bw.WriteString("]" + closing)
+
+	return bw.Flush()
+}
+
+// Write a single JSON value, indented unless Compact is set
+func writeJSON(w io.Writer, v interface{}, opts WriteOptions) error {
+	data, err := marshalJSON(v, "", opts)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Marshal v as the JSON writers do: indented with prefix unless Compact is
+// set, and canonical when Canonical is
+func marshalJSON(v interface{}, prefix string, opts WriteOptions) ([]byte, error) {
+	if !opts.Canonical {
+		if opts.Compact {
+			return json.Marshal(v)
+		}
+		return json.MarshalIndent(v, prefix, "  ")
+	}
+
+	data, err := canonicalMarshal(v)
+	if err != nil || opts.Compact {
+		return data, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, prefix, "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Marshal v compactly with the keys of every object sorted, recursively.
+// json.Marshal already sorts map keys but writes struct fields in
+// declaration order; going through a generic value sorts both. Array order
+// is kept, and numbers are copied exactly.
+func canonicalMarshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+// Name of the n-th shard of filename: checkpoints.csv becomes checkpoints-0001.csv
+// and checkpoints.csv.gz becomes checkpoints-0001.csv.gz
+func shardFilename(filename string, n int) string {
+	base := strings.TrimSuffix(filename, ".gz")
+	ext := filepath.Ext(base) + filename[len(base):]
+	return fmt.Sprintf("%s-%04d%s", strings.TrimSuffix(filename, ext), n, ext)
+}
+
+// Split items into chunks of at most maxRows. There is always at least one
+// chunk so an empty export still produces a file with a header.
+func shardItems[T any](items []T, maxRows int) [][]T {
+	if maxRows <= 0 || len(items) <= maxRows {
+		return [][]T{items}
+	}
+
+	shards := [][]T{}
+	for len(items) > 0 {
+		n := min(maxRows, len(items))
+		shards = append(shards, items[:n])
+		items = items[n:]
+	}
+	return shards
+}
+
+// Write items with write, rolling over to numbered files when opts.MaxFileRows
+// is set. Returns the names of the files written, in order.
+func saveShards[T any](items []T, filename string, opts WriteOptions, write func(items []T, filename string) error) ([]string, error) {
+	if opts.MaxFileRows <= 0 {
+		if err := write(items, filename); err != nil {
+			return nil, err
+		}
+		return []string{filename}, nil
+	}
+
+	files := []string{}
+	for i, shard := range shardItems(items, opts.MaxFileRows) {
+		name := shardFilename(filename, i+1)
+		if err := write(shard, name); err != nil {
+			return files, err
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
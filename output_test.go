@@ -0,0 +1,534 @@
+package suitrace
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteJSONArrayMatchesMarshal(t *testing.T) {
+	checkpoints := []CheckpointData{
+		{Digest: "a", SequenceNumber: 1, TransactionDigests: []string{"tx1", "tx2"}},
+		{Digest: "b<c>", SequenceNumber: 2},
+	}
+
+	tests := []struct {
+		name  string
+		items []CheckpointData
+		opts  WriteOptions
+		want  func() ([]byte, error)
+	}{
+		{
+			name:  "pretty",
+			items: checkpoints,
+			want:  func() ([]byte, error) { return json.MarshalIndent(checkpoints, "", "  ") },
+		},
+		{
+			name:  "compact",
+			items: checkpoints,
+			opts:  WriteOptions{Compact: true},
+			want:  func() ([]byte, error) { return json.Marshal(checkpoints) },
+		},
+		{
+			name:  "empty",
+			items: []CheckpointData{},
+			want:  func() ([]byte, error) { return json.Marshal([]CheckpointData{}) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeJSONArray(&buf, tt.items, tt.opts); err != nil {
+				t.Fatalf("writeJSONArray: %v", err)
+			}
+
+			want, err := tt.want()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, '\n')
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("got:\n%s\nwant:\n%s", buf.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestSaveCheckpointsToCSVShards(t *testing.T) {
+	checkpoints := []CheckpointData{}
+	for i := int64(1); i <= 5; i++ {
+		checkpoints = append(checkpoints, CheckpointData{Digest: "d", SequenceNumber: i})
+	}
+
+	filename := filepath.Join(t.TempDir(), "checkpoints.csv")
+	files, err := SaveCheckpointsToCSV(checkpoints, filename, WriteOptions{MaxFileRows: 2})
+	if err != nil {
+		t.Fatalf("SaveCheckpointsToCSV: %v", err)
+	}
+
+	wantRows := []int{2, 2, 1}
+	if len(files) != len(wantRows) {
+		t.Fatalf("got files %v, want %d files", files, len(wantRows))
+	}
+
+	for i, name := range files {
+		if want := shardFilename(filename, i+1); name != want {
+			t.Errorf("file %d = %s, want %s", i, name, want)
+		}
+
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if records[0][0] != "Digest" {
+			t.Errorf("%s: missing header, got %v", name, records[0])
+		}
+		if len(records)-1 != wantRows[i] {
+			t.Errorf("%s: got %d rows, want %d", name, len(records)-1, wantRows[i])
+		}
+	}
+}
+
+func TestShardFilename(t *testing.T) {
+	if got := shardFilename("out/checkpoints.csv", 2); got != "out/checkpoints-0002.csv" {
+		t.Errorf("shardFilename = %s", got)
+	}
+	if got := shardFilename("checkpoints.csv.gz", 3); got != "checkpoints-0003.csv.gz" {
+		t.Errorf("shardFilename = %s", got)
+	}
+	if got := shardFilename("events", 12); got != "events-0012" {
+		t.Errorf("shardFilename = %s", got)
+	}
+}
+
+func TestSaveCheckpointsGzipRoundTrip(t *testing.T) {
+	checkpoints := []CheckpointData{
+		{Digest: "a", SequenceNumber: 1, TransactionDigests: []string{"tx1"}},
+		{Digest: "b", SequenceNumber: 2},
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		opts     WriteOptions
+	}{
+		{name: "suffix", filename: "checkpoints.json.gz"},
+		{name: "flag", filename: "checkpoints.json", opts: WriteOptions{Gzip: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), tt.filename)
+			if _, err := SaveCheckpointsToJSON(checkpoints, filename, tt.opts); err != nil {
+				t.Fatalf("SaveCheckpointsToJSON: %v", err)
+			}
+
+			f, err := os.Open(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatalf("output is not gzip: %v", err)
+			}
+
+			var got []CheckpointData
+			if err := json.NewDecoder(gz).Decode(&got); err != nil {
+				t.Fatalf("failed to decode: %v", err)
+			}
+			if len(got) != 2 || got[1].Digest != "b" || got[0].TransactionDigests[0] != "tx1" {
+				t.Errorf("round trip mismatch: %+v", got)
+			}
+		})
+	}
+}
+
+func TestSaveEventsToCSVGzip(t *testing.T) {
+	events := []map[string]interface{}{{"id": map[string]interface{}{"txDigest": "1"}, "sender": "0xa"}}
+
+	filename := filepath.Join(t.TempDir(), "events.csv.gz")
+	if _, err := SaveEventsToCSV(events, filename, WriteOptions{}); err != nil {
+		t.Fatalf("SaveEventsToCSV: %v", err)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("output is not gzip: %v", err)
+	}
+	records, err := csv.NewReader(gz).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1][3] != "0xa" || records[1][4] != "1" {
+		t.Errorf("unexpected records: %v", records)
+	}
+}
+
+func TestCheckpointJSONMatchesRPCNames(t *testing.T) {
+	checkpoint := CheckpointData{
+		Digest:                   "digest",
+		SequenceNumber:           9007199254740993,
+		TimestampMs:              1734562800456,
+		ValidatorSignature:       "sig",
+		TransactionDigests:       []string{"tx1"},
+		NetworkTotalTransactions: 42,
+		EventRoot:                "root",
+	}
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw map[string]interface{}
+	if err := unmarshalUseNumber(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"digest", "sequenceNumber", "timestampMs", "validatorSignature", "transactions", "networkTotalTransactions", "eventRoot"} {
+		if _, ok := raw[key]; !ok {
+			t.Errorf("missing key %q in %s", key, data)
+		}
+	}
+
+	// Output parses back the same way as an RPC response
+	if got := parseCheckpoint(raw); got.SequenceNumber != checkpoint.SequenceNumber || got.TimestampMs != checkpoint.TimestampMs || got.Digest != checkpoint.Digest {
+		t.Errorf("round trip gave %+v, want %+v", got, checkpoint)
+	}
+}
+
+func TestCheckpointFieldSelection(t *testing.T) {
+	checkpoints := []CheckpointData{
+		{Digest: "a", SequenceNumber: 7, TimestampMs: 1000, TransactionDigests: []string{"tx1", "tx2"}},
+	}
+	dir := t.TempDir()
+	opts := WriteOptions{Compact: true, Fields: []string{"sequenceNumber", "digest", "transactionCount"}}
+
+	jsonFile := filepath.Join(dir, "checkpoints.json")
+	if _, err := SaveCheckpointsToJSON(checkpoints, jsonFile, opts); err != nil {
+		t.Fatalf("SaveCheckpointsToJSON: %v", err)
+	}
+	data, err := os.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"sequenceNumber":"7","digest":"a","transactionCount":2}]` + "\n"; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	csvFile := filepath.Join(dir, "checkpoints.csv")
+	if _, err := SaveCheckpointsToCSV(checkpoints, csvFile, opts); err != nil {
+		t.Fatalf("SaveCheckpointsToCSV: %v", err)
+	}
+	data, err = os.ReadFile(csvFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SequenceNumber,Digest,TransactionCount\n7,a,2\n"; string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+
+	if err := ValidateCheckpointFields([]string{"digest", "sequenceNumbr"}); err == nil || !strings.Contains(err.Error(), "sequenceNumbr") {
+		t.Errorf("expected an error naming the unknown field, got %v", err)
+	}
+}
+
+func TestCheckpointGasCostColumns(t *testing.T) {
+	checkpoints := []CheckpointData{
+		{Digest: "a", SequenceNumber: 7, Epoch: 512, EpochRollingGasCostSummary: &GasCostSummary{
+			ComputationCost: 10, StorageCost: 20, StorageRebate: 5, NonRefundableStorageFee: 1,
+		}},
+		{Digest: "b", SequenceNumber: 8, Epoch: 512},
+	}
+	dir := t.TempDir()
+
+	csvFile := filepath.Join(dir, "checkpoints.csv")
+	opts := WriteOptions{Fields: []string{"sequenceNumber", "epoch", "computationCost", "storageCost", "storageRebate", "nonRefundableStorageFee"}}
+	if _, err := SaveCheckpointsToCSV(checkpoints, csvFile, opts); err != nil {
+		t.Fatalf("SaveCheckpointsToCSV: %v", err)
+	}
+	data, err := os.ReadFile(csvFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SequenceNumber,Epoch,ComputationCost,StorageCost,StorageRebate,NonRefundableStorageFee\n7,512,10,20,5,1\n8,512,,,,\n"
+	if string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+
+	// Default JSON keeps the summary as a nested object with string amounts
+	jsonFile := filepath.Join(dir, "checkpoints.json")
+	if _, err := SaveCheckpointsToJSON(checkpoints[:1], jsonFile, WriteOptions{Compact: true}); err != nil {
+		t.Fatalf("SaveCheckpointsToJSON: %v", err)
+	}
+	data, err = os.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{`"epoch":"512"`, `"epochRollingGasCostSummary":{"computationCost":"10","storageCost":"20","storageRebate":"5","nonRefundableStorageFee":"1"}`} {
+		if !strings.Contains(string(data), part) {
+			t.Errorf("JSON missing %s: %s", part, data)
+		}
+	}
+}
+
+func TestHumanTimeColumns(t *testing.T) {
+	checkpoints := []CheckpointData{{Digest: "a", SequenceNumber: 1, TimestampMs: 1734562800456}}
+	filename := filepath.Join(t.TempDir(), "checkpoints.csv")
+
+	if _, err := SaveCheckpointsToCSV(checkpoints, filename, WriteOptions{HumanTime: true}); err != nil {
+		t.Fatalf("SaveCheckpointsToCSV: %v", err)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(f).ReadAll()
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, row := records[0], records[1]
+	if header[len(header)-1] != "Timestamp" || row[len(row)-1] != "2024-12-18T23:00:00.456Z" {
+		t.Errorf("unexpected human time column: %v / %v", header, row)
+	}
+	if row[2] != "1734562800456" {
+		t.Errorf("raw millis column was not kept: %v", row)
+	}
+
+	history := &ObjectHistory{ID: testObjectID, FirstSeen: 1734562800456}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, withHumanTime(history), WriteOptions{Compact: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"firstSeen":1734562800456`) || !strings.Contains(buf.String(), `"firstSeenTime":"2024-12-18T23:00:00.456Z"`) {
+		t.Errorf("unexpected object JSON: %s", buf.String())
+	}
+	if strings.Contains(buf.String(), "lastSeenTime") {
+		t.Errorf("unset lastSeen should not get a human time: %s", buf.String())
+	}
+}
+
+func TestFlattenOwner(t *testing.T) {
+	history := &ObjectHistory{ID: testObjectID, States: []ObjectState{
+		{Version: 1, Owner: map[string]interface{}{"AddressOwner": "0xa"}},
+		{Version: 2, Owner: map[string]interface{}{"ObjectOwner": "0xb"}},
+		{Version: 3, Owner: map[string]interface{}{"Shared": map[string]interface{}{"initial_shared_version": "2"}}},
+	}}
+	filename := filepath.Join(t.TempDir(), "history.json")
+	if err := SaveObjectHistoryToJSON(history, filename, WriteOptions{FlattenOwner: true}); err != nil {
+		t.Fatalf("SaveObjectHistoryToJSON: %v", err)
+	}
+
+	var saved struct {
+		States []map[string]interface{} `json:"states"`
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][2]interface{}{{"address", "0xa"}, {"object", "0xb"}, {"shared", nil}}
+	for i, state := range saved.States {
+		if state["ownerType"] != want[i][0] || state["ownerAddress"] != want[i][1] || state["owner"] == nil {
+			t.Errorf("state %d = %v, want ownerType %v and ownerAddress %v next to owner", i, state, want[i][0], want[i][1])
+		}
+	}
+	if history.States[0].OwnerType != "" {
+		t.Error("the history passed in was modified")
+	}
+
+	// Off by default
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, formatHistory(history, WriteOptions{}), WriteOptions{Compact: true}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "ownerType") {
+		t.Errorf("unexpected flattened owner: %s", buf.String())
+	}
+}
+
+func TestFormatMillisLocation(t *testing.T) {
+	defer func(loc *time.Location) { OutputLocation = loc }(OutputLocation)
+
+	if got, want := FormatMillis(1734562800456), "2024-12-18T23:00:00.456Z"; got != want {
+		t.Errorf("FormatMillis in UTC = %q, want %q", got, want)
+	}
+
+	OutputLocation = time.FixedZone("UTC+2", 2*60*60)
+	if got, want := FormatMillis(1734562800456), "2024-12-19T01:00:00.456+02:00"; got != want {
+		t.Errorf("FormatMillis in UTC+2 = %q, want %q", got, want)
+	}
+
+	if got := FormatMillis(0); got != "" {
+		t.Errorf("FormatMillis(0) = %q, want empty", got)
+	}
+}
+
+// A writer that fails every write, like a full disk
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("no space left on device") }
+
+func TestWriteJSONReportsWriteErrors(t *testing.T) {
+	items := []CheckpointData{{Digest: "a"}}
+	if err := writeJSONArray(failingWriter{}, items, WriteOptions{}); err == nil {
+		t.Error("writeJSONArray: expected write error")
+	}
+	if err := writeJSON(failingWriter{}, items[0], WriteOptions{}); err == nil {
+		t.Error("writeJSON: expected write error")
+	}
+	if err := WriteOwnershipDOT(failingWriter{}, &ObjectHistory{ID: testObjectID}); err == nil {
+		t.Error("WriteOwnershipDOT: expected write error")
+	}
+}
+
+// Writing to /dev/full fails with ENOSPC once data reaches the device
+func TestSaveReportsFullDisk(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full not available")
+	}
+
+	checkpoints := []CheckpointData{{Digest: "a", SequenceNumber: 1}}
+	events := []map[string]interface{}{{"type": "0x2::test::Event"}}
+	history := &ObjectHistory{ID: testObjectID, States: []ObjectState{{Version: 1}}}
+
+	saves := map[string]func(string) error{
+		"checkpoints csv":  func(f string) error { _, err := SaveCheckpointsToCSV(checkpoints, f, WriteOptions{}); return err },
+		"checkpoints json": func(f string) error { _, err := SaveCheckpointsToJSON(checkpoints, f, WriteOptions{}); return err },
+		"checkpoints gzip": func(f string) error {
+			_, err := SaveCheckpointsToJSON(checkpoints, f, WriteOptions{Gzip: true})
+			return err
+		},
+		"checkpoints parquet": func(f string) error { _, err := SaveCheckpointsToParquet(checkpoints, f, WriteOptions{}); return err },
+		"events csv":          func(f string) error { _, err := SaveEventsToCSV(events, f, WriteOptions{}); return err },
+		"object history":      func(f string) error { return SaveObjectHistoryToJSON(history, f, WriteOptions{}) },
+		"ownership dot":       func(f string) error { return SaveOwnershipDOT(history, f) },
+	}
+	for name, save := range saves {
+		t.Run(name, func(t *testing.T) {
+			if err := save("/dev/full"); err == nil {
+				t.Error("expected an error writing to a full device")
+			}
+		})
+	}
+
+	// Devices are never removed as failed output
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Fatalf("/dev/full is gone: %v", err)
+	}
+}
+
+func TestOutputFileRemovedOnFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	// Discarding before Close removes the partial file
+	partial := filepath.Join(dir, "partial.json")
+	file, err := createOutputFile(partial, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte(`[{"digest":`))
+	file.discard()
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("partial file still exists: %v", err)
+	}
+
+	// A failing Close reports the error and removes the file
+	broken := filepath.Join(dir, "broken.json.gz")
+	file, err = createOutputFile(broken, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte(`[]`))
+	file.file.Close() // The gzip trailer can no longer be written
+	if err := file.Close(); err == nil {
+		t.Error("expected Close to fail")
+	}
+	if _, err := os.Stat(broken); !os.IsNotExist(err) {
+		t.Errorf("broken file still exists: %v", err)
+	}
+
+	// Once closed successfully, discard keeps the file
+	complete := filepath.Join(dir, "complete.json")
+	file, err = createOutputFile(complete, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte(`[]`))
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.discard()
+	if _, err := os.Stat(complete); err != nil {
+		t.Errorf("complete file removed: %v", err)
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	data, err := canonicalMarshal(struct {
+		Zeta  int                    `json:"zeta"`
+		Alpha map[string]interface{} `json:"alpha"`
+	}{1, map[string]interface{}{"y": json.Number("18446744073709551615"), "b": []interface{}{"z", "a"}}})
+	if err != nil {
+		t.Fatalf("canonicalMarshal: %v", err)
+	}
+	if want := `{"alpha":{"b":["z","a"],"y":18446744073709551615},"zeta":1}`; string(data) != want {
+		t.Errorf("canonicalMarshal = %s, want %s", data, want)
+	}
+
+	// The same histories, given in a different order, save to the same bytes
+	history := func(id string) *ObjectHistory {
+		return &ObjectHistory{ID: id, States: []ObjectState{{
+			Version: 1, Digest: "d" + id, Type: "0x2::coin::Coin",
+			Owner:   map[string]interface{}{"AddressOwner": "0xb"},
+			Content: map[string]interface{}{"fields": map[string]interface{}{"value": "10", "id": map[string]interface{}{"id": id}}},
+		}}}
+	}
+	dir := t.TempDir()
+	save := func(name string, histories ...*ObjectHistory) []byte {
+		filename := filepath.Join(dir, name)
+		opts := WriteOptions{Canonical: true, FlattenOwner: true, Metadata: &ExportMetadata{GeneratedAt: "2024-01-02T03:04:05Z"}}
+		if err := SaveObjectHistoriesToJSON(histories, filename, opts); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	first := save("first.json", history("0x1"), history("0x2"))
+	second := save("second.json", history("0x2"), history("0x1"))
+	if !bytes.Equal(first, second) {
+		t.Errorf("canonical outputs differ:\n%s\n%s", first, second)
+	}
+	if !json.Valid(first) || bytes.Index(first, []byte(`"0x1"`)) > bytes.Index(first, []byte(`"0x2"`)) {
+		t.Errorf("histories not sorted by ID:\n%s", first)
+	}
+}
@@ -0,0 +1,74 @@
+package suitrace
+
+import (
+	"fmt"
+)
+
+// Options for GetOwnedObjectsWithOptions
+type OwnedObjectsOptions struct {
+	ShowContent bool   // Include each object's Move content
+	Type        string // Only return objects whose type matches this glob or prefix (see TypeMatches)
+}
+
+// List the current state of every object owned by address, with content
+func (c *Client) GetOwnedObjects(address string) ([]ObjectState, error) {
+	return c.GetOwnedObjectsWithOptions(address, OwnedObjectsOptions{ShowContent: true})
+}
+
+// List the current state of the objects owned by address, following the
+// pagination cursor. Timestamps are left at zero; fetch each object's history
+// for them.
+func (c *Client) GetOwnedObjectsWithOptions(address string, opts OwnedObjectsOptions) ([]ObjectState, error) {
+	address, err := NormalizeSuiAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	query := map[string]interface{}{
+		"options": map[string]interface{}{
+			"showContent":             opts.ShowContent,
+			"showOwner":               true,
+			"showType":                true,
+			"showPreviousTransaction": true,
+		},
+	}
+
+	states := []ObjectState{}
+	var cursor interface{}
+	total := 0
+
+	for {
+		result, err := c.MakeRPCCall("suix_getOwnedObjects", []interface{}{address, query, cursor, nil})
+		if err != nil {
+			return states, fmt.Errorf("failed to get owned objects: %w", err)
+		}
+
+		resultObj, _ := result["result"].(map[string]interface{})
+		data, _ := resultObj["data"].([]interface{})
+		for _, entry := range data {
+			entryObj, _ := entry.(map[string]interface{})
+			objData, ok := entryObj["data"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			total++
+
+			state := parseObjectData(objData)
+			if objID, ok := objData["objectId"].(string); ok {
+				state.ObjectID = objID
+			}
+			if TypeMatches(state.Type, opts.Type) {
+				states = append(states, state)
+			}
+		}
+
+		hasNext, _ := resultObj["hasNextPage"].(bool)
+		if !hasNext || resultObj["nextCursor"] == nil || len(data) == 0 {
+			break
+		}
+		cursor = resultObj["nextCursor"]
+	}
+
+	c.DebugPrint("Found %d objects owned by %s (%d matching)", total, address, len(states))
+	return states, nil
+}
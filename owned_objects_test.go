@@ -0,0 +1,73 @@
+package suitrace
+
+import (
+	"testing"
+)
+
+func TestGetOwnedObjectsPaginates(t *testing.T) {
+	pages := map[interface{}]mockResponse{
+		nil: {Result: map[string]interface{}{
+			"data": []interface{}{
+				map[string]interface{}{"data": map[string]interface{}{
+					"objectId":            "0xaa",
+					"version":             "7",
+					"digest":              "digestA",
+					"type":                "0x2::coin::Coin<0x2::sui::SUI>",
+					"owner":               map[string]interface{}{"AddressOwner": testObjectID},
+					"previousTransaction": "txA",
+				}},
+				map[string]interface{}{"error": map[string]interface{}{"code": "deleted"}},
+			},
+			"nextCursor":  "0xaa",
+			"hasNextPage": true,
+		}},
+		"0xaa": {Result: map[string]interface{}{
+			"data": []interface{}{
+				map[string]interface{}{"data": map[string]interface{}{
+					"objectId": "0xbb",
+					"version":  "9",
+					"digest":   "digestB",
+					"type":     "0x2::kiosk::KioskOwnerCap",
+					"owner":    map[string]interface{}{"AddressOwner": testObjectID},
+				}},
+			},
+			"nextCursor":  "0xbb",
+			"hasNextPage": false,
+		}},
+	}
+
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_getOwnedObjects": func(params []interface{}) mockResponse {
+			if len(params) != 4 || params[0] != testObjectID {
+				t.Errorf("unexpected params: %v", params)
+			}
+			resp, ok := pages[params[2]]
+			if !ok {
+				t.Errorf("unexpected cursor: %v", params[2])
+			}
+			return resp
+		},
+	})
+
+	states, err := client.GetOwnedObjects(testObjectID)
+	if err != nil {
+		t.Fatalf("GetOwnedObjects: %v", err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("got %d objects, want 2", len(states))
+	}
+	if states[0].ObjectID != "0xaa" || states[0].Version != 7 || states[0].PreviousTx != "txA" {
+		t.Errorf("unexpected first object: %+v", states[0])
+	}
+	if states[1].ObjectID != "0xbb" || states[1].Type != "0x2::kiosk::KioskOwnerCap" {
+		t.Errorf("unexpected second object: %+v", states[1])
+	}
+
+	coins, err := client.GetOwnedObjectsWithOptions(testObjectID, OwnedObjectsOptions{Type: "0x2::coin::Coin"})
+	if err != nil {
+		t.Fatalf("GetOwnedObjectsWithOptions: %v", err)
+	}
+	if len(coins) != 1 || coins[0].ObjectID != "0xaa" {
+		t.Errorf("type filter returned %+v, want only 0xaa", coins)
+	}
+}
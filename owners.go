@@ -0,0 +1,105 @@
+package suitrace
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Number of objects held by one owner
+type OwnerCount struct {
+	Kind  string `json:"kind"`            // One of the OwnerKind constants
+	Owner string `json:"owner,omitempty"` // Address or object ID; empty for shared, immutable and unknown owners
+	Count int    `json:"count"`
+}
+
+// Count the current owners of states. Shared, immutable and unknown owners
+// are each counted as a single owner of their kind. The result is sorted by
+// count, largest first, then by kind and owner.
+func CountOwners(states []*ObjectState) []OwnerCount {
+	type ownerKey struct{ kind, owner string }
+	counts := map[ownerKey]int{}
+	for _, state := range states {
+		kind, owner := ClassifyOwner(state.Owner)
+		counts[ownerKey{kind, owner}]++
+	}
+
+	owners := make([]OwnerCount, 0, len(counts))
+	for key, count := range counts {
+		owners = append(owners, OwnerCount{Kind: key.kind, Owner: key.owner, Count: count})
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		if owners[i].Count != owners[j].Count {
+			return owners[i].Count > owners[j].Count
+		}
+		if owners[i].Kind != owners[j].Kind {
+			return owners[i].Kind < owners[j].Kind
+		}
+		return owners[i].Owner < owners[j].Owner
+	})
+	return owners
+}
+
+// Write owner counts as CSV
+func WriteOwnerCountsCSV(w io.Writer, owners []OwnerCount) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"Kind", "Owner", "Count"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, owner := range owners {
+		if err := writer.Write([]string{owner.Kind, owner.Owner, strconv.Itoa(owner.Count)}); err != nil {
+			return fmt.Errorf("failed to write record to CSV: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV file: %w", err)
+	}
+	return nil
+}
+
+// Write owner counts as a JSON array
+func WriteOwnerCountsJSON(w io.Writer, owners []OwnerCount, opts WriteOptions) error {
+	return writeJSONArray(w, owners, opts)
+}
+
+// Save owner counts to CSV
+func SaveOwnerCountsToCSV(owners []OwnerCount, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.discard()
+
+	if err := WriteOwnerCountsCSV(file, owners); err != nil {
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close CSV file: %w", err)
+	}
+
+	return saveMetadataSidecar(filename, opts)
+}
+
+// Save owner counts to a JSON array
+func SaveOwnerCountsToJSON(owners []OwnerCount, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create JSON file: %w", err)
+	}
+	defer file.discard()
+
+	if err := writeJSONArray(file, owners, opts); err != nil {
+		return fmt.Errorf("failed to write JSON data: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close JSON file: %w", err)
+	}
+
+	return nil
+}
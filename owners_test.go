@@ -0,0 +1,71 @@
+package suitrace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyOwner(t *testing.T) {
+	tests := []struct {
+		owner    map[string]interface{}
+		wantKind string
+		wantID   string
+	}{
+		{map[string]interface{}{"AddressOwner": "0xa"}, OwnerKindAddress, "0xa"},
+		{map[string]interface{}{"ObjectOwner": "0xb"}, OwnerKindObject, "0xb"},
+		{map[string]interface{}{"ConsensusAddressOwner": map[string]interface{}{"owner": "0xc", "start_version": "5"}}, OwnerKindAddress, "0xc"},
+		{map[string]interface{}{"Shared": map[string]interface{}{"initial_shared_version": "1"}}, OwnerKindShared, ""},
+		{map[string]interface{}{"Immutable": true}, OwnerKindImmutable, ""},
+		{nil, OwnerKindUnknown, ""},
+	}
+
+	for _, tt := range tests {
+		kind, id := ClassifyOwner(tt.owner)
+		if kind != tt.wantKind || id != tt.wantID {
+			t.Errorf("ClassifyOwner(%v) = %s, %s; want %s, %s", tt.owner, kind, id, tt.wantKind, tt.wantID)
+		}
+	}
+}
+
+func TestCountOwners(t *testing.T) {
+	owned := func(owner map[string]interface{}) *ObjectState {
+		return &ObjectState{Owner: owner}
+	}
+	states := []*ObjectState{
+		owned(map[string]interface{}{"AddressOwner": "0xb"}),
+		owned(map[string]interface{}{"AddressOwner": "0xa"}),
+		owned(map[string]interface{}{"AddressOwner": "0xb"}),
+		owned(map[string]interface{}{"Shared": map[string]interface{}{"initial_shared_version": "1"}}),
+		owned(map[string]interface{}{"Shared": map[string]interface{}{"initial_shared_version": "7"}}),
+		owned(map[string]interface{}{"ObjectOwner": "0xc"}),
+	}
+
+	owners := CountOwners(states)
+	want := []OwnerCount{
+		{Kind: OwnerKindAddress, Owner: "0xb", Count: 2},
+		{Kind: OwnerKindShared, Count: 2},
+		{Kind: OwnerKindAddress, Owner: "0xa", Count: 1},
+		{Kind: OwnerKindObject, Owner: "0xc", Count: 1},
+	}
+	if len(owners) != len(want) {
+		t.Fatalf("got %+v, want %+v", owners, want)
+	}
+	for i := range want {
+		if owners[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, owners[i], want[i])
+		}
+	}
+
+	filename := filepath.Join(t.TempDir(), "owners.csv")
+	if err := SaveOwnerCountsToCSV(owners[:2], filename, WriteOptions{}); err != nil {
+		t.Fatalf("SaveOwnerCountsToCSV: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "Kind,Owner,Count\naddress,0xb,2\nshared,,2\n"; got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,206 @@
+package suitrace
+
+import (
+	"fmt"
+)
+
+// Default depth limit for BuildOwnershipTree
+const DefaultOwnershipDepth = 5
+
+// An object in an ownership tree. The root carries the full owner of the
+// top object; children are the objects its dynamic fields hold.
+type OwnershipNode struct {
+	ObjectID  string                 `json:"objectId"`
+	Type      string                 `json:"type"`
+	Version   uint64                 `json:"version,string"`
+	Owner     map[string]interface{} `json:"owner,omitempty"`
+	FieldName *DynamicFieldName      `json:"fieldName,omitempty"` // Name of the parent's dynamic field holding this object
+	FieldKind string                 `json:"fieldKind,omitempty"` // DynamicFieldKindField or DynamicFieldKindObject
+	Children  []*OwnershipNode       `json:"children,omitempty"`
+	Truncated bool                   `json:"truncated,omitempty"` // Children not listed because maxDepth was reached
+
+	// Provenance of the export, set on the root by SaveOwnershipTreeToJSON
+	// from WriteOptions.Metadata
+	Metadata *ExportMetadata `json:"metadata,omitempty"`
+}
+
+// Kinds of owner reported by ClassifyOwner
+const (
+	OwnerKindAddress   = "address"   // Owned by an account, or by one through consensus
+	OwnerKindObject    = "object"    // Held by another object, e.g. in a dynamic field
+	OwnerKindShared    = "shared"    // Shared object anyone can use
+	OwnerKindImmutable = "immutable" // Frozen, owned by no one
+	OwnerKindUnknown   = "unknown"   // Missing or unrecognized owner
+)
+
+// Classify an object's owner, returning its kind and, for address and object
+// owners, the owning address or object ID
+func ClassifyOwner(owner map[string]interface{}) (kind, id string) {
+	if addr, ok := owner["AddressOwner"].(string); ok {
+		return OwnerKindAddress, addr
+	}
+	if parent, ok := objectOwnerID(owner); ok {
+		return OwnerKindObject, parent
+	}
+	if consensus, ok := owner["ConsensusAddressOwner"].(map[string]interface{}); ok {
+		if addr, ok := consensus["owner"].(string); ok {
+			return OwnerKindAddress, addr
+		}
+	}
+	if _, ok := owner["Shared"]; ok {
+		return OwnerKindShared, ""
+	}
+	if _, ok := owner["Immutable"]; ok {
+		return OwnerKindImmutable, ""
+	}
+	return OwnerKindUnknown, ""
+}
+
+// The parent object ID when owner is an ObjectOwner
+func objectOwnerID(owner map[string]interface{}) (string, bool) {
+	parent, ok := owner["ObjectOwner"].(string)
+	return parent, ok && parent != ""
+}
+
+// Build the tree of objects around objectID. ObjectOwner links are followed
+// up from objectID to the top object, which is not owned by another object,
+// and the tree is then built down from there through dynamic fields, up to
+// maxDepth levels below the top. Objects already seen are not revisited,
+// which guards against ownership cycles.
+func (c *Client) BuildOwnershipTree(objectID string, maxDepth int) (*OwnershipNode, error) {
+	if maxDepth < 0 {
+		return nil, fmt.Errorf("max depth must be >= 0, got %d", maxDepth)
+	}
+
+	objectID, err := NormalizeSuiAddress(objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk up to the top object
+	seen := map[string]bool{}
+	var top *ObjectState
+	for id := objectID; ; {
+		seen[id] = true
+		state, err := c.GetObjectCurrentState(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk up from %s: %w", objectID, err)
+		}
+		top = state
+		top.ObjectID = id
+
+		parent, ok := objectOwnerID(state.Owner)
+		if !ok {
+			break
+		}
+		if parent, err = NormalizeSuiAddress(parent); err != nil {
+			return nil, fmt.Errorf("object %s has an invalid ObjectOwner: %w", id, err)
+		}
+		if seen[parent] {
+			c.DebugPrint("Ownership cycle at %s, stopping the walk up", parent)
+			break
+		}
+		id = parent
+	}
+
+	root := &OwnershipNode{
+		ObjectID: top.ObjectID,
+		Type:     top.Type,
+		Version:  top.Version,
+		Owner:    top.Owner,
+	}
+
+	visited := map[string]bool{root.ObjectID: true}
+	if err := c.addOwnedChildren(root, maxDepth, visited); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// Attach node's dynamic fields as children, recursing into dynamic object
+// fields while depth remains
+func (c *Client) addOwnedChildren(node *OwnershipNode, depth int, visited map[string]bool) error {
+	if depth == 0 {
+		node.Truncated = true
+		return nil
+	}
+
+	fields, err := c.GetDynamicFields(node.ObjectID)
+	if err != nil {
+		return fmt.Errorf("failed to list children of %s: %w", node.ObjectID, err)
+	}
+	for _, field := range fields {
+		childID, err := NormalizeSuiAddress(field.ObjectID)
+		if err != nil || visited[childID] {
+			continue
+		}
+		visited[childID] = true
+
+		name := field.Name
+		child := &OwnershipNode{
+			ObjectID:  childID,
+			Type:      field.ObjectType,
+			Version:   field.Version,
+			FieldName: &name,
+			FieldKind: field.Kind,
+		}
+		node.Children = append(node.Children, child)
+
+		// Inline field values are not objects of their own
+		if field.Kind != DynamicFieldKindObject {
+			continue
+		}
+		if err := c.addOwnedChildren(child, depth-1, visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Save an ownership tree as nested JSON
+func SaveOwnershipTreeToJSON(tree *OwnershipNode, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create JSON file: %w", err)
+	}
+	defer file.discard()
+
+	stamped := *tree
+	stamped.Metadata = opts.Metadata.embedded()
+	if err := writeJSON(file, &stamped, opts); err != nil {
+		return fmt.Errorf("failed to write JSON data: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close JSON file: %w", err)
+	}
+
+	return nil
+}
+
+// A change of owner in an object's history. From is nil for the first
+// known state.
+type OwnershipChange struct {
+	Version   uint64                 `json:"version,string"`
+	Timestamp int64                  `json:"timestamp"`
+	From      map[string]interface{} `json:"from"`
+	To        map[string]interface{} `json:"to"`
+}
+
+// The timeline of owners in history: the first state's owner, then every
+// version at which the owner differs from the previous state's
+func OwnershipChanges(history *ObjectHistory) []OwnershipChange {
+	var changes []OwnershipChange
+	for i, state := range history.States {
+		if i > 0 && GetOwnerKey(state.Owner) == GetOwnerKey(history.States[i-1].Owner) {
+			continue
+		}
+		change := OwnershipChange{Version: state.Version, Timestamp: state.Timestamp, To: state.Owner}
+		if i > 0 {
+			change.From = history.States[i-1].Owner
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
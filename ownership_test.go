@@ -0,0 +1,97 @@
+package suitrace
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBuildOwnershipTree(t *testing.T) {
+	id := func(n int) string { return fmt.Sprintf("0x%064x", n) }
+	a, b, c, f := id(0xa), id(0xb), id(0xc), id(0xf)
+
+	owners := map[string]map[string]interface{}{
+		a: {"AddressOwner": id(1)},
+		b: {"ObjectOwner": a},
+		c: {"ObjectOwner": b},
+	}
+	dynamicObject := func(objectID, objectType string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":       map[string]interface{}{"type": "0x2::object::ID", "value": objectID},
+			"type":       DynamicFieldKindObject,
+			"objectType": objectType,
+			"objectId":   objectID,
+			"version":    "3",
+		}
+	}
+	children := map[string][]interface{}{
+		a: {dynamicObject(b, "0x1::nft::Bag")},
+		b: {
+			dynamicObject(c, "0x1::nft::Nft"),
+			map[string]interface{}{
+				"name":       map[string]interface{}{"type": "u64", "value": "7"},
+				"type":       DynamicFieldKindField,
+				"objectType": "u64",
+				"objectId":   f,
+				"version":    "4",
+			},
+		},
+		c: {dynamicObject(a, "0x1::nft::Kiosk")}, // Cycle back to the top
+	}
+
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getObject": func(params []interface{}) mockResponse {
+			objectID := params[0].(string)
+			return mockResponse{Result: map[string]interface{}{"data": map[string]interface{}{
+				"objectId": objectID,
+				"version":  "9",
+				"type":     "0x1::nft::Kiosk",
+				"owner":    owners[objectID],
+			}}}
+		},
+		"suix_getDynamicFields": func(params []interface{}) mockResponse {
+			return mockResponse{Result: map[string]interface{}{
+				"data":        children[params[0].(string)],
+				"hasNextPage": false,
+			}}
+		},
+	})
+
+	tree, err := client.BuildOwnershipTree(c, DefaultOwnershipDepth)
+	if err != nil {
+		t.Fatalf("BuildOwnershipTree: %v", err)
+	}
+
+	if tree.ObjectID != a || tree.Owner["AddressOwner"] != id(1) {
+		t.Fatalf("root = %s owned by %v, want %s owned by %s", tree.ObjectID, tree.Owner, a, id(1))
+	}
+	if len(tree.Children) != 1 || tree.Children[0].ObjectID != b {
+		t.Fatalf("root children = %+v, want only %s", tree.Children, b)
+	}
+
+	bag := tree.Children[0]
+	if len(bag.Children) != 2 {
+		t.Fatalf("got %d children of %s, want 2", len(bag.Children), b)
+	}
+	nft, field := bag.Children[0], bag.Children[1]
+	if nft.ObjectID != c || nft.FieldKind != DynamicFieldKindObject || nft.Type != "0x1::nft::Nft" {
+		t.Errorf("unexpected object child: %+v", nft)
+	}
+	if len(nft.Children) != 0 {
+		t.Errorf("cycle back to %s was followed: %+v", a, nft.Children)
+	}
+	if field.ObjectID != f || field.FieldKind != DynamicFieldKindField || field.FieldName.Value != "7" || field.Version != 4 {
+		t.Errorf("unexpected field child: %+v", field)
+	}
+
+	shallow, err := client.BuildOwnershipTree(c, 1)
+	if err != nil {
+		t.Fatalf("BuildOwnershipTree with depth 1: %v", err)
+	}
+	if len(shallow.Children) != 1 || !shallow.Children[0].Truncated || len(shallow.Children[0].Children) != 0 {
+		t.Errorf("depth 1 should list %s without expanding it: %+v", b, shallow.Children)
+	}
+
+	if _, err := client.BuildOwnershipTree(c, -1); err == nil {
+		t.Error("expected an error for a negative depth")
+	}
+}
@@ -0,0 +1,49 @@
+package suitrace
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Parquet files are written with parquet-go. Each export has a row struct
+// whose fields are the file's columns, in order: pointer fields are
+// nullable, timestamps are int64 annotated TIMESTAMP(MILLIS), and lists use
+// the standard three-level LIST layout. Append new fields at the end so
+// existing tables keep reading older files.
+
+// Write rows as an uncompressed Parquet file with a single row group
+func writeParquet[T any](w io.Writer, rows []T) error {
+	version, commit := BuildVersion()
+	writer := parquet.NewGenericWriter[T](w, parquet.CreatedBy("suitrace", version, commit))
+	if _, err := writer.Write(rows); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// Create filename and write rows to it as Parquet. Parquet files cannot be
+// gzipped as a whole, so a .gz name or opts.Gzip is an error.
+func saveParquetFile[T any](filename string, rows []T, opts WriteOptions) error {
+	if opts.Gzip || strings.HasSuffix(filename, ".gz") {
+		return fmt.Errorf("parquet output cannot be gzip-compressed")
+	}
+
+	file, err := createOutputFile(filename, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create Parquet file: %w", err)
+	}
+	defer file.discard()
+
+	if err := writeParquet(file, rows); err != nil {
+		return fmt.Errorf("failed to write Parquet data: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close Parquet file: %w", err)
+	}
+
+	return saveMetadataSidecar(filename, opts)
+}
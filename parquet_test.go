@@ -0,0 +1,181 @@
+package suitrace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Open a Parquet file written by one of the Save*ToParquet functions
+func openParquetFile(t *testing.T, filename string) *parquet.File {
+	t.Helper()
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := parquet.OpenFile(f, info.Size())
+	if err != nil {
+		t.Fatalf("not a readable Parquet file: %v", err)
+	}
+	return file
+}
+
+// Names of the leaf columns of a file, in order
+func parquetColumnNames(file *parquet.File) []string {
+	var names []string
+	for _, path := range file.Schema().Columns() {
+		names = append(names, path[0])
+	}
+	return names
+}
+
+func TestSaveCheckpointsToParquet(t *testing.T) {
+	checkpoints := []CheckpointData{
+		{
+			Digest: "a", SequenceNumber: 1, TimestampMs: 1734562800456, TransactionDigests: []string{"t1", "t2"},
+			EpochRollingGasCostSummary: &GasCostSummary{ComputationCost: 10, StorageCost: 20, StorageRebate: 5, NonRefundableStorageFee: 1},
+		},
+		{Digest: "b", PreviousDigest: "a", SequenceNumber: 2, TimestampMs: 1734562801000},
+	}
+
+	dir := t.TempDir()
+	files, err := SaveCheckpointsToParquet(checkpoints, filepath.Join(dir, "checkpoints.parquet"), WriteOptions{})
+	if err != nil {
+		t.Fatalf("SaveCheckpointsToParquet failed: %v", err)
+	}
+
+	file := openParquetFile(t, files[0])
+	wantColumns := []string{
+		"digest", "previousDigest", "sequenceNumber", "timestampMs", "validatorSignature", "transactions",
+		"networkTotalTransactions", "eventRoot", "epoch",
+		"computationCost", "storageCost", "storageRebate", "nonRefundableStorageFee",
+	}
+	if got := parquetColumnNames(file); !reflect.DeepEqual(got, wantColumns) {
+		t.Errorf("columns = %v, want %v", got, wantColumns)
+	}
+	if n := len(file.RowGroups()); n != 1 {
+		t.Errorf("%d row groups, want 1", n)
+	}
+
+	timestamp := file.Schema().Fields()[3].Type().LogicalType()
+	if timestamp == nil || timestamp.Timestamp == nil || timestamp.Timestamp.Unit.Millis == nil {
+		t.Errorf("timestampMs logical type = %v, want TIMESTAMP(MILLIS)", timestamp)
+	}
+	if list := file.Schema().Fields()[5].Type().LogicalType(); list == nil || list.List == nil {
+		t.Errorf("transactions logical type = %v, want LIST", list)
+	}
+
+	rows, err := parquet.ReadFile[checkpointParquetRow](files[0])
+	if err != nil {
+		t.Fatalf("reading rows back failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("read %d rows, want 2", len(rows))
+	}
+	first, second := rows[0], rows[1]
+	if first.Digest != "a" || first.PreviousDigest != nil || first.TimestampMs != 1734562800456 {
+		t.Errorf("first row = %+v", first)
+	}
+	if !reflect.DeepEqual(first.Transactions, []string{"t1", "t2"}) {
+		t.Errorf("first row transactions = %v", first.Transactions)
+	}
+	if first.StorageCost == nil || *first.StorageCost != 20 || first.NonRefundableStorageFee == nil || *first.NonRefundableStorageFee != 1 {
+		t.Errorf("first row gas costs were not kept: %+v", first)
+	}
+	if second.PreviousDigest == nil || *second.PreviousDigest != "a" || len(second.Transactions) != 0 {
+		t.Errorf("second row = %+v", second)
+	}
+	if second.ComputationCost != nil || second.StorageRebate != nil {
+		t.Error("missing gas costs should be null")
+	}
+
+	if _, err := SaveCheckpointsToParquet(checkpoints, filepath.Join(dir, "checkpoints.parquet.gz"), WriteOptions{}); err == nil {
+		t.Error("expected an error for a gzipped Parquet filename")
+	}
+}
+
+func TestSaveEventsToParquet(t *testing.T) {
+	events := []map[string]interface{}{
+		{
+			"id":          map[string]interface{}{"txDigest": "tx1", "eventSeq": "3"},
+			"type":        "0x2::coin::Mint",
+			"timestampMs": "1734562800456",
+			"parsedJson":  map[string]interface{}{"amount": "5"},
+		},
+		{"sender": "0xabc"},
+	}
+
+	files, err := SaveEventsToParquet(events, filepath.Join(t.TempDir(), "events.parquet"), WriteOptions{})
+	if err != nil {
+		t.Fatalf("SaveEventsToParquet failed: %v", err)
+	}
+
+	file := openParquetFile(t, files[0])
+	wantColumns := []string{"txDigest", "eventSeq", "packageId", "transactionModule", "sender", "type", "timestampMs", "parsedJson", "bcs"}
+	if got := parquetColumnNames(file); !reflect.DeepEqual(got, wantColumns) {
+		t.Errorf("columns = %v, want %v", got, wantColumns)
+	}
+
+	rows, err := parquet.ReadFile[eventParquetRow](files[0])
+	if err != nil {
+		t.Fatalf("reading rows back failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("read %d rows, want 2", len(rows))
+	}
+	first := rows[0]
+	if first.TxDigest == nil || *first.TxDigest != "tx1" || first.EventSeq == nil || *first.EventSeq != 3 {
+		t.Errorf("event id was not kept: %+v", first)
+	}
+	if first.TimestampMs != 1734562800456 {
+		t.Errorf("timestampMs = %v", first.TimestampMs)
+	}
+	if first.ParsedJSON == nil || *first.ParsedJSON != `{"amount":"5"}` {
+		t.Errorf("parsedJson = %v", first.ParsedJSON)
+	}
+	if first.Sender != nil || first.BCS != nil {
+		t.Errorf("absent fields should be null: %+v", first)
+	}
+	if second := rows[1]; second.Sender == nil || *second.Sender != "0xabc" || second.TxDigest != nil || second.EventSeq != nil || second.TimestampMs != 0 {
+		t.Errorf("second row = %+v", second)
+	}
+}
+
+func TestSaveTransactionsToParquet(t *testing.T) {
+	gas := int64(1500)
+	txs := []CheckpointTransaction{
+		{Checkpoint: 7, TimestampMs: 1734562800456, Index: 0, Digest: "d1", Sender: "0x1", Status: "success", GasUsed: &gas},
+		{Checkpoint: 7, TimestampMs: 1734562800456, Index: 1, Digest: "d2"},
+	}
+
+	files, err := SaveTransactionsToParquet(txs, filepath.Join(t.TempDir(), "transactions.parquet"), WriteOptions{})
+	if err != nil {
+		t.Fatalf("SaveTransactionsToParquet failed: %v", err)
+	}
+
+	file := openParquetFile(t, files[0])
+	wantColumns := []string{"checkpoint", "timestampMs", "index", "digest", "sender", "status", "gasUsed"}
+	if got := parquetColumnNames(file); !reflect.DeepEqual(got, wantColumns) {
+		t.Errorf("columns = %v, want %v", got, wantColumns)
+	}
+
+	rows, err := parquet.ReadFile[transactionParquetRow](files[0])
+	if err != nil {
+		t.Fatalf("reading rows back failed: %v", err)
+	}
+	want := []transactionParquetRow{
+		{Checkpoint: 7, TimestampMs: 1734562800456, Index: 0, Digest: "d1", Sender: nullIfEmpty("0x1"), Status: nullIfEmpty("success"), GasUsed: &gas},
+		{Checkpoint: 7, TimestampMs: 1734562800456, Index: 1, Digest: "d2"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v, want %+v", rows, want)
+	}
+}
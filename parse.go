@@ -0,0 +1,42 @@
+package suitrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Largest integer a float64 can represent without losing precision (2^53)
+const maxExactFloat = 1 << 53
+
+// Parse an unsigned 64-bit value that the RPC may encode as a JSON string or number.
+// Numbers should be decoded with UseNumber so values above 2^53 keep full precision.
+func parseU64(v interface{}) (uint64, error) {
+	switch n := v.(type) {
+	case string:
+		return strconv.ParseUint(n, 10, 64)
+	case json.Number:
+		return strconv.ParseUint(n.String(), 10, 64)
+	case float64:
+		if n < 0 || n != math.Trunc(n) {
+			return 0, fmt.Errorf("invalid unsigned integer: %v", n)
+		}
+		if n > maxExactFloat {
+			return 0, fmt.Errorf("value %v exceeds float64 precision", n)
+		}
+		return uint64(n), nil
+	case nil:
+		return 0, fmt.Errorf("missing value")
+	default:
+		return 0, fmt.Errorf("unsupported type %T for unsigned integer", v)
+	}
+}
+
+// Unmarshal JSON keeping numbers as json.Number instead of float64
+func unmarshalUseNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
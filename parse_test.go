@@ -0,0 +1,46 @@
+package suitrace
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseU64(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      interface{}
+		want    uint64
+		wantErr bool
+	}{
+		{name: "string", in: "421337", want: 421337},
+		{name: "string above 2^53", in: "9007199254740993", want: 9007199254740993},
+		{name: "max uint64 string", in: "18446744073709551615", want: 18446744073709551615},
+		{name: "json.Number above 2^53", in: json.Number("9007199254740993"), want: 9007199254740993},
+		{name: "float64", in: float64(421337), want: 421337},
+		{name: "float64 above 2^53", in: float64(1 << 60), wantErr: true},
+		{name: "negative float64", in: float64(-1), wantErr: true},
+		{name: "fractional float64", in: 1.5, wantErr: true},
+		{name: "negative string", in: "-1", wantErr: true},
+		{name: "garbage string", in: "0x10", wantErr: true},
+		{name: "nil", in: nil, wantErr: true},
+		{name: "bool", in: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseU64(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseU64(%v) = %d, want error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseU64(%v) unexpected error: %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseU64(%v) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package suitrace
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Version of the files a ResponseCache keeps in Dir. Entries are stored
+// under a v<N> subdirectory, so bumping it leaves stale ones unread.
+const responseCacheVersion = 1
+
+// A bounded LRU cache of successful JSON-RPC response bodies, keyed on the
+// endpoint and the request's method and params, safe for concurrent use.
+// Responses that can never change, such as a checkpoint, a transaction or a
+// past object version, are kept until evicted. The rest, such as an
+// object's current state, expire after TTL. A nil cache holds nothing.
+//
+// When Dir is set, immutable responses are also written there, one file per
+// request, and outlive the process: a backfill run again after a crash reads
+// what it already downloaded from disk.
+type ResponseCache struct {
+	TTL time.Duration // How long responses that may change are kept; 0 keeps only immutable ones
+	Dir string        // Directory for immutable responses shared across runs; empty for memory only
+
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List // Most recently used first
+	now     func() time.Time
+}
+
+type responseCacheEntry struct {
+	key     string
+	body    []byte
+	expires time.Time // Zero for immutable responses
+}
+
+// Create a cache holding up to size responses in memory; 0 keeps none
+// there, for a cache that only uses Dir
+func NewResponseCache(size int, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		TTL:     ttl,
+		size:    size,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// Number of responses cached in memory, expired ones included until they
+// are looked up
+func (rc *ResponseCache) Len() int {
+	if rc == nil {
+		return 0
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.order.Len()
+}
+
+// The cached response body for payload sent to url
+func (rc *ResponseCache) get(url string, payload []byte) ([]byte, bool) {
+	if rc == nil {
+		return nil, false
+	}
+	key := url + "\n" + string(payload)
+
+	if body, ok := rc.getMemory(key); ok {
+		return body, true
+	}
+	if rc.Dir == "" {
+		return nil, false
+	}
+
+	// Only immutable responses are written to disk
+	body, err := os.ReadFile(rc.filename(url, payload))
+	if err != nil || !json.Valid(body) {
+		return nil, false
+	}
+	rc.putMemory(key, body, time.Time{})
+	return body, true
+}
+
+func (rc *ResponseCache) getMemory(key string) ([]byte, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	elem, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*responseCacheEntry)
+	if !entry.expires.IsZero() && !rc.now().Before(entry.expires) {
+		rc.order.Remove(elem)
+		delete(rc.entries, key)
+		return nil, false
+	}
+	rc.order.MoveToFront(elem)
+	return entry.body, true
+}
+
+// Cache body, the response to payload sent to url, if it is a successful
+// response worth keeping. The error is from writing it to Dir.
+func (rc *ResponseCache) put(url string, payload, body []byte) error {
+	if rc == nil || (rc.size <= 0 && rc.Dir == "") {
+		return nil
+	}
+
+	var request struct {
+		Method string        `json:"method"`
+		Params []interface{} `json:"params"`
+	}
+	var response struct {
+		Result interface{} `json:"result"`
+		Error  interface{} `json:"error"`
+	}
+	if json.Unmarshal(payload, &request) != nil || json.Unmarshal(body, &response) != nil {
+		return nil
+	}
+	if response.Error != nil || response.Result == nil {
+		return nil
+	}
+
+	key := url + "\n" + string(payload)
+	if !immutableResponse(request.Method, request.Params, response.Result) {
+		if rc.TTL > 0 {
+			rc.putMemory(key, body, rc.now().Add(rc.TTL))
+		}
+		return nil
+	}
+
+	rc.putMemory(key, body, time.Time{})
+	if rc.Dir != "" {
+		return rc.writeFile(rc.filename(url, payload), body)
+	}
+	return nil
+}
+
+// Add an entry to the in-memory LRU, expiring at expires unless it is zero
+func (rc *ResponseCache) putMemory(key string, body []byte, expires time.Time) {
+	if rc.size <= 0 {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if elem, ok := rc.entries[key]; ok {
+		rc.order.Remove(elem)
+	}
+	rc.entries[key] = rc.order.PushFront(&responseCacheEntry{key: key, body: body, expires: expires})
+	for rc.order.Len() > rc.size {
+		oldest := rc.order.Back()
+		rc.order.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*responseCacheEntry).key)
+	}
+}
+
+// File in Dir for the response to payload sent to url, named by the method
+// and a hash of the endpoint and params like the files of Client.RawDir
+func (rc *ResponseCache) filename(url string, payload []byte) string {
+	var request struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	json.Unmarshal(payload, &request)
+	if request.Method == "" {
+		request.Method = "unknown"
+	}
+
+	sum := sha256.Sum256(append([]byte(url+"\n"), request.Params...))
+	name := fmt.Sprintf("%s-%s.json", request.Method, hex.EncodeToString(sum[:16]))
+	return filepath.Join(rc.Dir, fmt.Sprintf("v%d", responseCacheVersion), name)
+}
+
+// Write body to filename through a temporary file, so an interrupted write
+// never leaves a truncated entry behind
+func (rc *ResponseCache) writeFile(filename string, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Whether a successful response to method with params can never change
+func immutableResponse(method string, params []interface{}, result interface{}) bool {
+	switch method {
+	case "sui_getCheckpoint", "sui_getChainIdentifier":
+		return true
+	case "sui_getTransactionBlock":
+		// A transaction that has run but is not yet in a checkpoint has no
+		// checkpoint or timestamp yet
+		return inCheckpoint(result)
+	case "sui_tryGetPastObject":
+		// A version that was not found may still be created
+		past, _ := result.(map[string]interface{})
+		return past["status"] == "VersionFound"
+	case "sui_getCheckpoints":
+		// A page that ends before the chain head. Ascending pages with a next
+		// page are full; descending pages after a cursor only go back in time.
+		page, _ := result.(map[string]interface{})
+		descending := len(params) > 2 && params[2] == true
+		if descending {
+			return len(params) > 0 && params[0] != nil
+		}
+		hasNext, _ := page["hasNextPage"].(bool)
+		return hasNext
+	case "sui_multiGetTransactionBlocks":
+		// Only when every transaction was found and is in a checkpoint
+		if len(params) == 0 {
+			return false
+		}
+		digests, _ := params[0].([]interface{})
+		blocks, _ := result.([]interface{})
+		if len(blocks) != len(digests) {
+			return false
+		}
+		for _, block := range blocks {
+			if b, ok := block.(map[string]interface{}); !ok || b["error"] != nil || !inCheckpoint(b) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Whether a transaction block result has been included in a checkpoint
+func inCheckpoint(block interface{}) bool {
+	b, _ := block.(map[string]interface{})
+	return b["checkpoint"] != nil
+}
@@ -0,0 +1,205 @@
+package suitrace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Client whose RPC calls are cached in a cache of size entries, counting
+// the requests that reach the node by method
+func newCachedTestClient(t *testing.T, size int, ttl time.Duration) (*Client, map[string]int) {
+	calls := map[string]int{}
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getCheckpoint": func(params []interface{}) mockResponse {
+			calls["sui_getCheckpoint"]++
+			if params[0] == "404" {
+				return mockResponse{Error: map[string]interface{}{"code": -32602, "message": "not found"}}
+			}
+			return mockResponse{Result: map[string]interface{}{"digest": "digest-" + params[0].(string), "sequenceNumber": params[0]}}
+		},
+		"sui_getObject": func(params []interface{}) mockResponse {
+			calls["sui_getObject"]++
+			return mockResponse{Result: map[string]interface{}{"data": map[string]interface{}{"version": "7"}}}
+		},
+	})
+	client.Timestamps = nil
+	client.Cache = NewResponseCache(size, ttl)
+	return client, calls
+}
+
+func TestResponseCacheKeepsImmutableResponses(t *testing.T) {
+	client, calls := newCachedTestClient(t, 10, 0)
+
+	for i := 0; i < 3; i++ {
+		checkpoint, err := client.FetchCheckpoint(5)
+		if err != nil || checkpoint.Digest != "digest-5" {
+			t.Fatalf("FetchCheckpoint = %+v, %v", checkpoint, err)
+		}
+	}
+	if calls["sui_getCheckpoint"] != 1 {
+		t.Errorf("sui_getCheckpoint reached the node %d times, want 1", calls["sui_getCheckpoint"])
+	}
+
+	// Errors are not cached
+	for i := 0; i < 2; i++ {
+		if _, err := client.FetchCheckpoint(404); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if calls["sui_getCheckpoint"] != 3 {
+		t.Errorf("sui_getCheckpoint reached the node %d times, want 3", calls["sui_getCheckpoint"])
+	}
+
+	// Without a TTL the current state of an object is never cached
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetObjectCurrentState(testObjectID); err != nil {
+			t.Fatalf("GetObjectCurrentState: %v", err)
+		}
+	}
+	if calls["sui_getObject"] != 2 {
+		t.Errorf("sui_getObject reached the node %d times, want 2", calls["sui_getObject"])
+	}
+}
+
+func TestResponseCacheExpiresMutableResponses(t *testing.T) {
+	client, calls := newCachedTestClient(t, 10, time.Minute)
+	now := time.Now()
+	client.Cache.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetObjectCurrentState(testObjectID); err != nil {
+			t.Fatalf("GetObjectCurrentState: %v", err)
+		}
+	}
+	if calls["sui_getObject"] != 1 {
+		t.Errorf("sui_getObject reached the node %d times, want 1", calls["sui_getObject"])
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := client.GetObjectCurrentState(testObjectID); err != nil {
+		t.Fatalf("GetObjectCurrentState: %v", err)
+	}
+	if calls["sui_getObject"] != 2 {
+		t.Errorf("sui_getObject reached the node %d times after the TTL, want 2", calls["sui_getObject"])
+	}
+}
+
+func TestResponseCacheSkipsTransactionsOutsideCheckpoints(t *testing.T) {
+	var calls int
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getTransactionBlock": func(params []interface{}) mockResponse {
+			calls++
+			if calls == 1 {
+				// Executed, but not yet in a checkpoint
+				return mockResponse{Result: map[string]interface{}{"digest": params[0]}}
+			}
+			return mockResponse{Result: map[string]interface{}{"digest": params[0], "checkpoint": "12", "timestampMs": "1734562800456"}}
+		},
+	})
+	client.Timestamps = nil
+	client.Cache = NewResponseCache(10, 0)
+
+	if _, err := client.GetTransactionTimestamp("tx1"); err == nil {
+		t.Fatal("expected an error for a transaction without a timestamp")
+	}
+	for i := 0; i < 2; i++ {
+		timestamp, err := client.GetTransactionTimestamp("tx1")
+		if err != nil || timestamp != 1734562800456 {
+			t.Fatalf("GetTransactionTimestamp = %d, %v", timestamp, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("sui_getTransactionBlock reached the node %d times, want 2", calls)
+	}
+
+	blocks := []interface{}{
+		map[string]interface{}{"digest": "a", "checkpoint": "12"},
+		map[string]interface{}{"digest": "b"},
+	}
+	if immutableResponse("sui_multiGetTransactionBlocks", []interface{}{[]interface{}{"a", "b"}}, blocks) {
+		t.Error("a multi-get with a block outside a checkpoint was treated as immutable")
+	}
+}
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	client, calls := newCachedTestClient(t, 2, 0)
+
+	for _, seq := range []int64{1, 2, 1, 3, 1, 2} {
+		if _, err := client.FetchCheckpoint(seq); err != nil {
+			t.Fatalf("FetchCheckpoint(%d): %v", seq, err)
+		}
+	}
+	// 1 stays cached as it keeps being used; 2 is evicted by 3
+	if calls["sui_getCheckpoint"] != 4 {
+		t.Errorf("sui_getCheckpoint reached the node %d times, want 4", calls["sui_getCheckpoint"])
+	}
+	if client.Cache.Len() != 2 {
+		t.Errorf("Len = %d, want 2", client.Cache.Len())
+	}
+}
+
+func TestImmutableCheckpointPages(t *testing.T) {
+	tests := []struct {
+		params []interface{}
+		result map[string]interface{}
+		want   bool
+	}{
+		{[]interface{}{"10", 100, false}, map[string]interface{}{"hasNextPage": true}, true},
+		{[]interface{}{"10", 100, false}, map[string]interface{}{"hasNextPage": false}, false},
+		{[]interface{}{nil, 100, true}, map[string]interface{}{"hasNextPage": true}, false},
+		{[]interface{}{"10", 100, true}, map[string]interface{}{"hasNextPage": false}, true},
+	}
+	for _, tt := range tests {
+		if got := immutableResponse("sui_getCheckpoints", tt.params, tt.result); got != tt.want {
+			t.Errorf("immutableResponse(%v, %v) = %v, want %v", tt.params, tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestResponseCacheDir(t *testing.T) {
+	dir := t.TempDir()
+	client, calls := newCachedTestClient(t, 0, 0)
+	client.Cache.Dir = dir
+
+	if _, err := client.FetchCheckpoint(5); err != nil {
+		t.Fatalf("FetchCheckpoint: %v", err)
+	}
+	if _, err := client.GetObjectCurrentState(testObjectID); err != nil {
+		t.Fatalf("GetObjectCurrentState: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "v1", "*.json"))
+	if len(files) != 1 || !strings.HasPrefix(filepath.Base(files[0]), "sui_getCheckpoint-") {
+		t.Fatalf("cache files = %v, want only the checkpoint", files)
+	}
+
+	// A later run with an empty memory cache reads it back from disk
+	client.Cache = NewResponseCache(10, 0)
+	client.Cache.Dir = dir
+	checkpoint, err := client.FetchCheckpoint(5)
+	if err != nil || checkpoint.Digest != "digest-5" {
+		t.Fatalf("FetchCheckpoint = %+v, %v", checkpoint, err)
+	}
+	if calls["sui_getCheckpoint"] != 1 {
+		t.Errorf("sui_getCheckpoint reached the node %d times, want 1", calls["sui_getCheckpoint"])
+	}
+
+	// A damaged entry is fetched again and rewritten
+	if err := os.WriteFile(files[0], []byte(`{"result": {"dig`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client.Cache = NewResponseCache(0, 0)
+	client.Cache.Dir = dir
+	if _, err := client.FetchCheckpoint(5); err != nil {
+		t.Fatalf("FetchCheckpoint: %v", err)
+	}
+	if calls["sui_getCheckpoint"] != 2 {
+		t.Errorf("sui_getCheckpoint reached the node %d times, want 2", calls["sui_getCheckpoint"])
+	}
+	if data, _ := os.ReadFile(files[0]); !json.Valid(data) {
+		t.Errorf("damaged entry not rewritten: %s", data)
+	}
+}
@@ -0,0 +1,92 @@
+package suitrace
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mockResponse is what a mock handler returns for a single RPC call
+type mockResponse struct {
+	Result interface{}
+	Error  map[string]interface{}
+	Raw    string // written verbatim instead of a JSON-RPC envelope when set
+	Status int    // HTTP status code, 200 when zero
+}
+
+type mockHandler func(params []interface{}) mockResponse
+
+// Start an httptest server that dispatches JSON-RPC requests to handlers by method name
+func newMockRPCServer(t *testing.T, handlers map[string]mockHandler) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("mock RPC server: failed to decode request: %v", err)
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		handler, ok := handlers[req.Method]
+		if !ok {
+			t.Errorf("mock RPC server: unexpected method %q", req.Method)
+			http.Error(w, "unknown method", http.StatusNotFound)
+			return
+		}
+
+		resp := handler(req.Params)
+		w.Header().Set("Content-Type", "application/json")
+		if resp.Status != 0 {
+			w.WriteHeader(resp.Status)
+		}
+		if resp.Raw != "" {
+			io.WriteString(w, resp.Raw)
+			return
+		}
+
+		envelope := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+		}
+		if resp.Error != nil {
+			envelope["error"] = resp.Error
+		} else {
+			envelope["result"] = resp.Result
+		}
+		json.NewEncoder(w).Encode(envelope)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// Create a client pointed at a fresh mock RPC server
+func newTestClient(t *testing.T, handlers map[string]mockHandler) *Client {
+	t.Helper()
+	return NewClient(newMockRPCServer(t, handlers).URL)
+}
+
+// Load a recorded JSON-RPC response from testdata
+func fixture(t *testing.T, name string) mockResponse {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", name, err)
+	}
+	return mockResponse{Raw: string(data)}
+}
+
+// Handler that always returns the same response
+func respond(resp mockResponse) mockHandler {
+	return func(params []interface{}) mockResponse {
+		return resp
+	}
+}
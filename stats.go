@@ -0,0 +1,142 @@
+package suitrace
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Minimum, maximum and mean of a series of values
+type Summary struct {
+	Min int64   `json:"min"`
+	Max int64   `json:"max"`
+	Avg float64 `json:"avg"`
+}
+
+func summarize(values []int64) Summary {
+	if len(values) == 0 {
+		return Summary{}
+	}
+	s := Summary{Min: values[0], Max: values[0]}
+	var total int64
+	for _, v := range values {
+		s.Min = min(s.Min, v)
+		s.Max = max(s.Max, v)
+		total += v
+	}
+	s.Avg = float64(total) / float64(len(values))
+	return s
+}
+
+// Throughput of the network over a set of checkpoints
+type CheckpointStats struct {
+	// Tool and version that wrote the file, set by the JSON writers
+	GeneratedBy string `json:"generatedBy,omitempty"`
+	// Provenance of the export, set by the JSON writers from
+	// WriteOptions.Metadata
+	Metadata *ExportMetadata `json:"metadata,omitempty"`
+
+	Start       int64 `json:"start,string"`
+	End         int64 `json:"end,string"`
+	Checkpoints int   `json:"checkpoints"`
+
+	FirstTimestampMs int64 `json:"firstTimestampMs"`
+	LastTimestampMs  int64 `json:"lastTimestampMs"`
+
+	// Transactions in the checkpoints, and the network's running total as of
+	// the last one
+	Transactions             int64 `json:"transactions"`
+	NetworkTotalTransactions int64 `json:"networkTotalTransactions"`
+
+	TransactionsPerCheckpoint Summary `json:"transactionsPerCheckpoint"`
+	IntervalMs                Summary `json:"intervalMs"` // Time between consecutive checkpoints
+
+	// Transactions per second over the time the checkpoints span
+	TPS float64 `json:"tps"`
+}
+
+// Compute throughput statistics for checkpoints in any order. Intervals and
+// TPS only count consecutive sequence numbers, so checkpoints from several
+// disjoint ranges do not count the gaps between them. When no two are
+// consecutive, as with sampled checkpoints, TPS comes from the growth of the
+// network's transaction total from the first to the last instead.
+func ComputeCheckpointStats(checkpoints []CheckpointData) CheckpointStats {
+	if len(checkpoints) == 0 {
+		return CheckpointStats{}
+	}
+
+	sorted := append([]CheckpointData(nil), checkpoints...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].SequenceNumber < sorted[j].SequenceNumber })
+
+	first, last := sorted[0], sorted[len(sorted)-1]
+	stats := CheckpointStats{
+		Start:                    first.SequenceNumber,
+		End:                      last.SequenceNumber,
+		Checkpoints:              len(sorted),
+		FirstTimestampMs:         first.TimestampMs,
+		LastTimestampMs:          last.TimestampMs,
+		NetworkTotalTransactions: last.NetworkTotalTransactions,
+	}
+
+	perCheckpoint := make([]int64, len(sorted))
+	var intervals []int64
+	var spanTxs, spanMs int64
+	for i, cp := range sorted {
+		txs := int64(len(cp.TransactionDigests))
+		perCheckpoint[i] = txs
+		stats.Transactions += txs
+
+		if i > 0 && cp.SequenceNumber == sorted[i-1].SequenceNumber+1 {
+			interval := cp.TimestampMs - sorted[i-1].TimestampMs
+			intervals = append(intervals, interval)
+			spanTxs += txs
+			spanMs += interval
+		}
+	}
+
+	stats.TransactionsPerCheckpoint = summarize(perCheckpoint)
+	stats.IntervalMs = summarize(intervals)
+	if spanMs > 0 {
+		stats.TPS = float64(spanTxs) / (float64(spanMs) / 1000)
+	} else if ms := last.TimestampMs - first.TimestampMs; ms > 0 && last.NetworkTotalTransactions > first.NetworkTotalTransactions {
+		stats.TPS = float64(last.NetworkTotalTransactions-first.NetworkTotalTransactions) / (float64(ms) / 1000)
+	}
+
+	return stats
+}
+
+// Print the statistics for people
+func (s CheckpointStats) WriteSummary(w io.Writer) {
+	fmt.Fprintf(w, "Checkpoints:          %d (%d-%d)\n", s.Checkpoints, s.Start, s.End)
+	if s.FirstTimestampMs > 0 {
+		span := time.Duration(s.LastTimestampMs-s.FirstTimestampMs) * time.Millisecond
+		fmt.Fprintf(w, "Time span:            %s to %s (%s)\n", FormatMillis(s.FirstTimestampMs), FormatMillis(s.LastTimestampMs), span)
+	}
+	fmt.Fprintf(w, "Transactions:         %d (network total %d)\n", s.Transactions, s.NetworkTotalTransactions)
+	fmt.Fprintf(w, "Tx per checkpoint:    min %d, max %d, avg %.2f\n",
+		s.TransactionsPerCheckpoint.Min, s.TransactionsPerCheckpoint.Max, s.TransactionsPerCheckpoint.Avg)
+	fmt.Fprintf(w, "Checkpoint interval:  min %dms, max %dms, avg %.0fms\n", s.IntervalMs.Min, s.IntervalMs.Max, s.IntervalMs.Avg)
+	fmt.Fprintf(w, "Throughput:           %.2f tx/s\n", s.TPS)
+}
+
+// Save the statistics as a JSON object
+func SaveCheckpointStatsToJSON(stats CheckpointStats, filename string, opts WriteOptions) error {
+	file, err := createOutputFile(filename, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create JSON file: %w", err)
+	}
+	defer file.discard()
+
+	stats.GeneratedBy = GeneratedBy()
+	stats.Metadata = opts.Metadata.embedded()
+	if err := writeJSON(file, stats, opts); err != nil {
+		return fmt.Errorf("failed to write JSON data: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close JSON file: %w", err)
+	}
+
+	return nil
+}
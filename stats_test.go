@@ -0,0 +1,105 @@
+package suitrace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComputeCheckpointStats(t *testing.T) {
+	cp := func(seq, ts int64, txs int, total int64) CheckpointData {
+		return CheckpointData{SequenceNumber: seq, TimestampMs: ts, TransactionDigests: make([]string, txs), NetworkTotalTransactions: total}
+	}
+
+	// Two ranges, out of order; the gap between 12 and 20 is not an interval
+	checkpoints := []CheckpointData{
+		cp(20, 9000, 6, 130),
+		cp(10, 1000, 4, 104),
+		cp(11, 1250, 2, 106),
+		cp(12, 2000, 9, 115),
+		cp(21, 9500, 1, 131),
+	}
+
+	stats := ComputeCheckpointStats(checkpoints)
+
+	if stats.Start != 10 || stats.End != 21 || stats.Checkpoints != 5 {
+		t.Errorf("range = %d-%d (%d), want 10-21 (5)", stats.Start, stats.End, stats.Checkpoints)
+	}
+	if stats.FirstTimestampMs != 1000 || stats.LastTimestampMs != 9500 {
+		t.Errorf("timestamps = %d-%d", stats.FirstTimestampMs, stats.LastTimestampMs)
+	}
+	if stats.Transactions != 22 || stats.NetworkTotalTransactions != 131 {
+		t.Errorf("transactions = %d (network %d), want 22 (131)", stats.Transactions, stats.NetworkTotalTransactions)
+	}
+	if want := (Summary{Min: 1, Max: 9, Avg: 4.4}); stats.TransactionsPerCheckpoint != want {
+		t.Errorf("per checkpoint = %+v, want %+v", stats.TransactionsPerCheckpoint, want)
+	}
+	if want := (Summary{Min: 250, Max: 750, Avg: 500}); stats.IntervalMs != want {
+		t.Errorf("intervals = %+v, want %+v", stats.IntervalMs, want)
+	}
+	// 2+9+1 transactions over 250+750+500 ms
+	if stats.TPS != 8 {
+		t.Errorf("TPS = %v, want 8", stats.TPS)
+	}
+
+	var buf strings.Builder
+	stats.WriteSummary(&buf)
+	if !strings.Contains(buf.String(), "8.00 tx/s") {
+		t.Errorf("summary missing TPS:\n%s", buf.String())
+	}
+}
+
+func TestComputeCheckpointStatsSingle(t *testing.T) {
+	stats := ComputeCheckpointStats([]CheckpointData{{SequenceNumber: 5, TimestampMs: 1000, TransactionDigests: []string{"a"}}})
+	if stats.Checkpoints != 1 || stats.TPS != 0 || stats.IntervalMs != (Summary{}) {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	if stats := ComputeCheckpointStats(nil); stats.Checkpoints != 0 {
+		t.Errorf("empty stats = %+v", stats)
+	}
+}
+
+func TestComputeCheckpointStatsSampled(t *testing.T) {
+	// 1 in 1000: no two checkpoints are consecutive
+	stats := ComputeCheckpointStats([]CheckpointData{
+		{SequenceNumber: 0, TimestampMs: 1000, NetworkTotalTransactions: 100},
+		{SequenceNumber: 1000, TimestampMs: 6000, NetworkTotalTransactions: 600},
+		{SequenceNumber: 2000, TimestampMs: 11000, NetworkTotalTransactions: 2100},
+	})
+	if stats.IntervalMs != (Summary{}) {
+		t.Errorf("intervals = %+v, want none", stats.IntervalMs)
+	}
+	// 2000 transactions over 10 seconds
+	if stats.TPS != 200 {
+		t.Errorf("TPS = %v, want 200", stats.TPS)
+	}
+}
+
+func TestSaveCheckpointStatsToJSON(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stats.json")
+	stats := CheckpointStats{Start: 1, End: 2, Checkpoints: 2, TPS: 1.5}
+	if err := SaveCheckpointStatsToJSON(stats, filename, WriteOptions{}); err != nil {
+		t.Fatalf("SaveCheckpointStatsToJSON: %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got["start"] != "1" || got["tps"] != 1.5 {
+		t.Errorf("saved = %v", got)
+	}
+	if _, ok := got["metadata"]; ok {
+		t.Errorf("metadata written without WriteOptions.Metadata: %v", got)
+	}
+	if _, ok := got["intervalMs"].(map[string]interface{}); !ok {
+		t.Errorf("intervalMs missing: %v", got)
+	}
+}
@@ -0,0 +1,167 @@
+package suitrace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Wait before reconnecting a dropped subscription
+var reconnectDelay = 2 * time.Second
+
+// Resolve the WebSocket endpoint, deriving it from the RPC URL when not configured
+func (c *Client) wsURL() (string, error) {
+	if c.WSURL != "" {
+		return c.WSURL, nil
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid RPC URL %q: %w", c.URL, err)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	case "ws", "wss":
+	default:
+		return "", fmt.Errorf("cannot derive WebSocket URL from %q", c.URL)
+	}
+
+	return u.String(), nil
+}
+
+// Open a WebSocket connection and subscribe to events matching filter
+func (c *Client) dialEventSubscription(ctx context.Context, filter map[string]interface{}) (*websocket.Conn, error) {
+	endpoint, err := c.wsURL()
+	if err != nil {
+		return nil, err
+	}
+
+	c.DebugPrint("Connecting to %s", endpoint)
+
+	header := http.Header{}
+	c.setAuthHeaders(header)
+	dialer := *websocket.DefaultDialer
+	if c.Proxy != nil {
+		dialer.Proxy = http.ProxyURL(c.Proxy)
+	}
+	conn, _, err := dialer.DialContext(ctx, endpoint, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", endpoint, err)
+	}
+
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "suix_subscribeEvent",
+		"params":  []interface{}{filter},
+	}
+	if err := conn.WriteJSON(request); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send subscription request: %w", err)
+	}
+
+	// The first reply carries the subscription id or an error
+	var reply struct {
+		Result interface{}            `json:"result"`
+		Error  map[string]interface{} `json:"error"`
+	}
+	if err := conn.ReadJSON(&reply); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read subscription reply: %w", err)
+	}
+	if reply.Error != nil {
+		conn.Close()
+		return nil, parseRPCError(reply.Error)
+	}
+
+	c.DebugPrint("Subscribed to events with subscription %v", reply.Result)
+	return conn, nil
+}
+
+// Stream events matching filter as they are emitted, until ctx is cancelled.
+// Dropped connections are re-established and the subscription is renewed.
+// The returned channel is closed when ctx is done.
+func (c *Client) SubscribeEvents(ctx context.Context, filter map[string]interface{}) (<-chan map[string]interface{}, error) {
+	conn, err := c.dialEventSubscription(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan map[string]interface{})
+
+	go func() {
+		defer close(events)
+
+		for {
+			// Closing the connection unblocks the reader when ctx is cancelled
+			var once sync.Once
+			closeConn := func() { once.Do(func() { conn.Close() }) }
+			stop := context.AfterFunc(ctx, closeConn)
+
+			err := c.readEventNotifications(ctx, conn, events)
+			stop()
+			closeConn()
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			fmt.Printf("Event subscription dropped: %v\nReconnecting...\n", err)
+
+			// Keep trying until we're subscribed again or cancelled
+			for {
+				if sleepContext(ctx, reconnectDelay) != nil {
+					return
+				}
+
+				conn, err = c.dialEventSubscription(ctx, filter)
+				if err == nil {
+					break
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				fmt.Printf("Reconnect failed: %v\n", err)
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// Forward subscription notifications to events until the connection fails
+func (c *Client) readEventNotifications(ctx context.Context, conn *websocket.Conn, events chan<- map[string]interface{}) error {
+	for {
+		var notification struct {
+			Method string `json:"method"`
+			Params struct {
+				Subscription interface{}            `json:"subscription"`
+				Result       map[string]interface{} `json:"result"`
+			} `json:"params"`
+		}
+		if err := conn.ReadJSON(&notification); err != nil {
+			return err
+		}
+
+		if notification.Params.Result == nil {
+			c.DebugPrint("Ignoring message without event payload (method %q)", notification.Method)
+			continue
+		}
+
+		select {
+		case events <- notification.Params.Result:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
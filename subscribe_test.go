@@ -0,0 +1,96 @@
+package suitrace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSubscribeEventsReconnects(t *testing.T) {
+	oldDelay := reconnectDelay
+	reconnectDelay = 10 * time.Millisecond
+	defer func() { reconnectDelay = oldDelay }()
+
+	var subscriptions int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		var req struct {
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		if req.Method != "suix_subscribeEvent" || len(req.Params) != 1 {
+			t.Errorf("unexpected subscription request %+v", req)
+		}
+		n := atomic.AddInt32(&subscriptions, 1)
+
+		conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": n})
+		conn.WriteJSON(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "suix_subscribeEvent",
+			"params": map[string]interface{}{
+				"subscription": n,
+				"result":       map[string]interface{}{"type": "E", "seq": float64(n)},
+			},
+		})
+
+		// Drop the first connection, keep the second open until the client leaves
+		if n > 1 {
+			conn.ReadMessage()
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	if got, _ := client.wsURL(); !strings.HasPrefix(got, "ws://") {
+		t.Fatalf("derived WebSocket URL = %q, want ws:// scheme", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := client.SubscribeEvents(ctx, EventTypeFilter("E"))
+	if err != nil {
+		t.Fatalf("SubscribeEvents: %v", err)
+	}
+
+	for want := float64(1); want <= 2; want++ {
+		select {
+		case event := <-events:
+			if event["seq"] != want {
+				t.Fatalf("event seq = %v, want %v", event["seq"], want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for event %v", want)
+		}
+	}
+
+	if n := atomic.LoadInt32(&subscriptions); n != 2 {
+		t.Errorf("subscriptions = %d, want 2 (resubscribe after drop)", n)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected channel to be closed after cancel")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
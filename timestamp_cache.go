@@ -0,0 +1,69 @@
+package suitrace
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Transaction timestamps in Unix milliseconds by digest, safe for
+// concurrent use. A nil cache holds nothing and ignores Put.
+type TimestampCache struct {
+	mu         sync.Mutex
+	timestamps map[string]int64
+}
+
+func NewTimestampCache() *TimestampCache {
+	return &TimestampCache{timestamps: map[string]int64{}}
+}
+
+// The cached timestamp of the transaction digest
+func (tc *TimestampCache) Get(digest string) (int64, bool) {
+	if tc == nil {
+		return 0, false
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	ms, ok := tc.timestamps[digest]
+	return ms, ok
+}
+
+// Remember the timestamp of the transaction digest
+func (tc *TimestampCache) Put(digest string, ms int64) {
+	if tc == nil || digest == "" || ms <= 0 {
+		return
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.timestamps[digest] = ms
+}
+
+// Number of cached timestamps
+func (tc *TimestampCache) Len() int {
+	if tc == nil {
+		return 0
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return len(tc.timestamps)
+}
+
+// Timestamp of a transaction block response. The RPC names it timestampMs;
+// timestamp_ms is accepted from responses recorded by older tools.
+func transactionTimestamp(block map[string]interface{}) (int64, bool) {
+	for _, key := range []string{"timestampMs", "timestamp_ms"} {
+		if s, ok := block[key].(string); ok {
+			if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return ms, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Record the timestamp of a transaction block response in c.Timestamps
+func (c *Client) cacheTimestamp(block map[string]interface{}) {
+	digest, _ := block["digest"].(string)
+	if ms, ok := transactionTimestamp(block); ok {
+		c.Timestamps.Put(digest, ms)
+	}
+}
@@ -0,0 +1,66 @@
+package suitrace
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestGetTransactionTimestampUsesCache(t *testing.T) {
+	const txDigest = "Cq9sP2vX4mT7yB1nR5kW8zA3dF6hJ9uL2eG4oQ7iN1cV"
+
+	calls := 0
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getTransactionBlock": func(params []interface{}) mockResponse {
+			calls++
+			return mockResponse{Result: map[string]interface{}{"digest": params[0], "timestampMs": "1734562800456"}}
+		},
+	})
+
+	// Reading the object's state from the transaction caches its timestamp
+	if _, err := client.GetObjectDetailsFromTransaction(txDigest, testObjectID, HistoryOptions{}); err == nil {
+		t.Fatal("expected an error, the transaction does not change the object")
+	}
+	for i := 0; i < 2; i++ {
+		ms, err := client.GetTransactionTimestamp(txDigest)
+		if err != nil || ms != 1734562800456 {
+			t.Fatalf("GetTransactionTimestamp = %d, %v", ms, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("sui_getTransactionBlock called %d times, want 1", calls)
+	}
+
+	// Without a cache every lookup asks the node
+	client.Timestamps = nil
+	if _, err := client.GetTransactionTimestamp(txDigest); err != nil {
+		t.Fatalf("GetTransactionTimestamp: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("sui_getTransactionBlock called %d times, want 2", calls)
+	}
+}
+
+func TestTimestampCacheConcurrentUse(t *testing.T) {
+	cache := NewTimestampCache()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 1; j <= 100; j++ {
+				digest := strconv.Itoa(j)
+				cache.Put(digest, int64(j))
+				if ms, ok := cache.Get(digest); !ok || ms != int64(j) {
+					t.Errorf("worker %d: Get(%s) = %d, %v", worker, digest, ms, ok)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.Len() != 100 {
+		t.Errorf("Len = %d, want 100", cache.Len())
+	}
+}
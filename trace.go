@@ -0,0 +1,161 @@
+package suitrace
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"math"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Where the time of one request went. Phases that did not happen, such as
+// DNS and connect on a reused connection, are zero.
+type RequestTrace struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	Server  time.Duration // From the request being written to the first response byte
+	Body    time.Duration // From the first response byte to the body being closed
+	Total   time.Duration
+}
+
+func (r RequestTrace) String() string {
+	return fmt.Sprintf("dns=%s connect=%s tls=%s server=%s body=%s total=%s",
+		r.DNS, r.Connect, r.TLS, r.Server, r.Body, r.Total)
+}
+
+// Tracer records a RequestTrace for every request of the clients it is set
+// on. Share one between clients to aggregate a whole run.
+type Tracer struct {
+	mu     sync.Mutex
+	traces []RequestTrace
+}
+
+func NewTracer() *Tracer {
+	return &Tracer{}
+}
+
+// Timestamps of one request's phases, filled in by httptrace callbacks
+type requestTimer struct {
+	mu sync.Mutex
+	start, dnsStart, dnsDone, connectStart, connectDone,
+	tlsStart, tlsDone, wroteRequest, firstByte time.Time
+}
+
+// Set a timestamp under the timer's lock; callbacks can run concurrently
+func (r *requestTimer) mark(t *time.Time) {
+	r.mu.Lock()
+	*t = time.Now()
+	r.mu.Unlock()
+}
+
+// Attach a ClientTrace to ctx that times the request it is used for
+func (t *Tracer) start(ctx context.Context) (context.Context, *requestTimer) {
+	timer := &requestTimer{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { timer.mark(&timer.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { timer.mark(&timer.dnsDone) },
+		ConnectStart:         func(string, string) { timer.mark(&timer.connectStart) },
+		ConnectDone:          func(string, string, error) { timer.mark(&timer.connectDone) },
+		TLSHandshakeStart:    func() { timer.mark(&timer.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { timer.mark(&timer.tlsDone) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { timer.mark(&timer.wroteRequest) },
+		GotFirstResponseByte: func() { timer.mark(&timer.firstByte) },
+	}
+	return httptrace.WithClientTrace(ctx, trace), timer
+}
+
+// Record the request timed by timer, which ended now
+func (t *Tracer) finish(timer *requestTimer) RequestTrace {
+	end := time.Now()
+
+	timer.mu.Lock()
+	span := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() {
+			return 0
+		}
+		return to.Sub(from)
+	}
+	trace := RequestTrace{
+		DNS:     span(timer.dnsStart, timer.dnsDone),
+		Connect: span(timer.connectStart, timer.connectDone),
+		TLS:     span(timer.tlsStart, timer.tlsDone),
+		Server:  span(timer.wroteRequest, timer.firstByte),
+		Body:    span(timer.firstByte, end),
+		Total:   end.Sub(timer.start),
+	}
+	timer.mu.Unlock()
+
+	t.mu.Lock()
+	t.traces = append(t.traces, trace)
+	t.mu.Unlock()
+
+	return trace
+}
+
+// Wrap a response body so closing it finishes the request's trace
+func (t *Tracer) traceBody(body io.ReadCloser, timer *requestTimer, done func(RequestTrace)) io.ReadCloser {
+	return &tracedBody{ReadCloser: body, finish: func() { done(t.finish(timer)) }}
+}
+
+type tracedBody struct {
+	io.ReadCloser
+	once   sync.Once
+	finish func()
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.finish)
+	return err
+}
+
+// Traces recorded so far
+func (t *Tracer) Traces() []RequestTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]RequestTrace(nil), t.traces...)
+}
+
+// Print the p50 and p95 of every phase over the recorded requests
+func (t *Tracer) WriteSummary(w io.Writer) {
+	traces := t.Traces()
+	if len(traces) == 0 {
+		fmt.Fprintln(w, "Request timing: no requests traced")
+		return
+	}
+
+	phases := []struct {
+		name string
+		get  func(RequestTrace) time.Duration
+	}{
+		{"dns", func(r RequestTrace) time.Duration { return r.DNS }},
+		{"connect", func(r RequestTrace) time.Duration { return r.Connect }},
+		{"tls", func(r RequestTrace) time.Duration { return r.TLS }},
+		{"server", func(r RequestTrace) time.Duration { return r.Server }},
+		{"body", func(r RequestTrace) time.Duration { return r.Body }},
+		{"total", func(r RequestTrace) time.Duration { return r.Total }},
+	}
+
+	fmt.Fprintf(w, "Request timing over %d requests:\n", len(traces))
+	for _, phase := range phases {
+		durations := make([]time.Duration, len(traces))
+		for i, trace := range traces {
+			durations[i] = phase.get(trace)
+		}
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		fmt.Fprintf(w, "  %-8s p50 %-12s p95 %s\n", phase.name,
+			percentile(durations, 0.50).Round(time.Microsecond),
+			percentile(durations, 0.95).Round(time.Microsecond))
+	}
+}
+
+// Nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(0, min(rank, len(sorted)-1))]
+}
@@ -0,0 +1,61 @@
+package suitrace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTracer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"42"}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	client.Tracer = NewTracer()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.FetchLatestSequenceNumber(); err != nil {
+			t.Fatalf("FetchLatestSequenceNumber: %v", err)
+		}
+	}
+
+	traces := client.Tracer.Traces()
+	if len(traces) != 2 {
+		t.Fatalf("got %d traces, want 2", len(traces))
+	}
+	for i, trace := range traces {
+		if trace.Server < 20*time.Millisecond || trace.Total < trace.Server {
+			t.Errorf("trace %d = %s, want at least 20ms of server time within the total", i, trace)
+		}
+	}
+	if traces[0].Connect == 0 || traces[1].Connect != 0 {
+		t.Errorf("want a connect on the first request only, got %s and %s", traces[0], traces[1])
+	}
+
+	var summary strings.Builder
+	client.Tracer.WriteSummary(&summary)
+	if !strings.Contains(summary.String(), "over 2 requests") || !strings.Contains(summary.String(), "server") {
+		t.Errorf("unexpected summary:\n%s", summary.String())
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 20; i++ {
+		sorted = append(sorted, time.Duration(i))
+	}
+	if got := percentile(sorted, 0.50); got != 10 {
+		t.Errorf("p50 = %d, want 10", got)
+	}
+	if got := percentile(sorted, 0.95); got != 19 {
+		t.Errorf("p95 = %d, want 19", got)
+	}
+	if got := percentile(sorted[:1], 0.95); got != 1 {
+		t.Errorf("p95 of one = %d, want 1", got)
+	}
+}
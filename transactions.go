@@ -0,0 +1,288 @@
+package suitrace
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+)
+
+// Maximum number of digests in one sui_multiGetTransactionBlocks call
+const MaxMultiGetTransactions = 50
+
+// One transaction of a checkpoint, with the checkpoint it was included in.
+// Sender, Status, GasUsed and Kind are only set by FetchTransactionDetails.
+type CheckpointTransaction struct {
+	Checkpoint  int64  `json:"checkpoint,string"`
+	TimestampMs int64  `json:"timestampMs,string"` // Timestamp of the checkpoint
+	Index       int    `json:"index"`              // Position within the checkpoint
+	Digest      string `json:"digest"`
+
+	Sender  string `json:"sender,omitempty"`
+	Status  string `json:"status,omitempty"`  // "success" or "failure"
+	GasUsed *int64 `json:"gasUsed,omitempty"` // Computation plus storage cost minus storage rebate, in MIST
+	Kind    string `json:"kind,omitempty"`    // Transaction kind, e.g. ProgrammableTransaction or ConsensusCommitPrologueV3
+}
+
+// Kind of the transactions users submit. Every other kind (consensus commit
+// prologues, epoch changes, genesis, randomness and authenticator state
+// updates) is created by the system.
+const UserTransactionKind = "ProgrammableTransaction"
+
+// Report whether kind is a system transaction kind. An unknown (empty) kind
+// is not treated as a system one.
+func IsSystemTransaction(kind string) bool {
+	return kind != "" && kind != UserTransactionKind
+}
+
+// Drop system transactions from txs, whose details must have been fetched,
+// returning the remaining ones and how many were dropped
+func ExcludeSystemTransactions(txs []CheckpointTransaction) ([]CheckpointTransaction, int) {
+	kept := []CheckpointTransaction{}
+	for _, tx := range txs {
+		if !IsSystemTransaction(tx.Kind) {
+			kept = append(kept, tx)
+		}
+	}
+	return kept, len(txs) - len(kept)
+}
+
+// Expand checkpoints into one row per transaction digest, in checkpoint order
+func ExpandTransactions(checkpoints []CheckpointData) []CheckpointTransaction {
+	txs, _ := ExpandTransactionsWithOptions(checkpoints, ExpandOptions{})
+	return txs
+}
+
+// Limits on transaction expansion, to bound the work spent on checkpoints
+// with thousands of transactions
+type ExpandOptions struct {
+	MaxPerCheckpoint int  // Transactions kept per checkpoint, 0 for all
+	SkipLarge        bool // Drop checkpoints over MaxPerCheckpoint entirely instead of keeping their first transactions
+}
+
+// What ExpandOptions left out
+type ExpandSummary struct {
+	LimitedCheckpoints  []int64 // Sequence numbers of checkpoints with more than MaxPerCheckpoint transactions
+	TransactionsSkipped int64   // Transactions not expanded because of the limit
+}
+
+// Expand checkpoints into transaction rows like ExpandTransactions, keeping
+// at most opts.MaxPerCheckpoint per checkpoint
+func ExpandTransactionsWithOptions(checkpoints []CheckpointData, opts ExpandOptions) ([]CheckpointTransaction, ExpandSummary) {
+	txs := []CheckpointTransaction{}
+	var summary ExpandSummary
+	for _, cp := range checkpoints {
+		digests := cp.TransactionDigests
+		if opts.MaxPerCheckpoint > 0 && len(digests) > opts.MaxPerCheckpoint {
+			summary.LimitedCheckpoints = append(summary.LimitedCheckpoints, cp.SequenceNumber)
+			if opts.SkipLarge {
+				summary.TransactionsSkipped += int64(len(digests))
+				continue
+			}
+			summary.TransactionsSkipped += int64(len(digests) - opts.MaxPerCheckpoint)
+			digests = digests[:opts.MaxPerCheckpoint]
+		}
+
+		for i, digest := range digests {
+			txs = append(txs, CheckpointTransaction{
+				Checkpoint:  cp.SequenceNumber,
+				TimestampMs: cp.TimestampMs,
+				Index:       i,
+				Digest:      digest,
+			})
+		}
+	}
+	return txs, summary
+}
+
+// Fill in sender, status and gas used for txs with
+// sui_multiGetTransactionBlocks, in batches of MaxMultiGetTransactions.
+// Transactions the node does not return are left without details.
+func (c *Client) FetchTransactionDetails(txs []CheckpointTransaction) error {
+	for start := 0; start < len(txs); start += MaxMultiGetTransactions {
+		batch := txs[start:min(start+MaxMultiGetTransactions, len(txs))]
+
+		digests := make([]string, len(batch))
+		for i, tx := range batch {
+			digests[i] = tx.Digest
+		}
+
+		result, err := c.MakeRPCCall("sui_multiGetTransactionBlocks", []interface{}{
+			digests,
+			map[string]interface{}{
+				"showInput":   true,
+				"showEffects": true,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get transactions: %w", err)
+		}
+
+		blocks := map[string]map[string]interface{}{}
+		entries, _ := result["result"].([]interface{})
+		for _, entry := range entries {
+			if block, ok := entry.(map[string]interface{}); ok {
+				if digest, ok := block["digest"].(string); ok {
+					blocks[digest] = block
+					c.cacheTimestamp(block)
+				}
+			}
+		}
+
+		for i := range batch {
+			block, ok := blocks[batch[i].Digest]
+			if !ok {
+				c.DebugPrint("Transaction %s not returned by the node", batch[i].Digest)
+				continue
+			}
+			parseTransactionDetails(block, &batch[i])
+		}
+
+		c.DebugPrint("Fetched details for %d of %d transactions", min(start+len(batch), len(txs)), len(txs))
+	}
+
+	return nil
+}
+
+// Copy sender, kind, status and net gas from a transaction block response
+// into tx
+func parseTransactionDetails(block map[string]interface{}, tx *CheckpointTransaction) {
+	if transaction, ok := block["transaction"].(map[string]interface{}); ok {
+		if data, ok := transaction["data"].(map[string]interface{}); ok {
+			tx.Sender, _ = data["sender"].(string)
+			if kind, ok := data["transaction"].(map[string]interface{}); ok {
+				tx.Kind, _ = kind["kind"].(string)
+			}
+		}
+	}
+
+	effects, ok := block["effects"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if status, ok := effects["status"].(map[string]interface{}); ok {
+		tx.Status, _ = status["status"].(string)
+	}
+
+	if gas, ok := effects["gasUsed"].(map[string]interface{}); ok {
+		computation, err1 := parseU64(gas["computationCost"])
+		storage, err2 := parseU64(gas["storageCost"])
+		rebate, err3 := parseU64(gas["storageRebate"])
+		if err1 == nil && err2 == nil && err3 == nil {
+			used := int64(computation) + int64(storage) - int64(rebate)
+			tx.GasUsed = &used
+		}
+	}
+}
+
+// Header of transaction CSV exports
+var transactionCSVHeader = []string{"Checkpoint", "TimestampMs", "Index", "Digest", "Sender", "Status", "GasUsed"}
+
+// Save transaction rows to CSV, returning the files written. HumanTime adds
+// a Timestamp column after TimestampMs; Fields does not apply.
+func SaveTransactionsToCSV(txs []CheckpointTransaction, filename string, opts WriteOptions) ([]string, error) {
+	return saveShards(txs, filename, opts, func(txs []CheckpointTransaction, filename string) error {
+		file, err := createOutputFile(filename, opts)
+		if err != nil {
+			return fmt.Errorf("failed to create CSV file: %w", err)
+		}
+		defer file.discard()
+
+		writer := csv.NewWriter(file)
+
+		header := transactionCSVHeader
+		if opts.HumanTime {
+			header = append([]string{"Checkpoint", "TimestampMs", "Timestamp"}, transactionCSVHeader[2:]...)
+		}
+		if err := writer.Write(header); err != nil {
+			return fmt.Errorf("failed to write CSV header: %w", err)
+		}
+
+		for _, tx := range txs {
+			gasUsed := ""
+			if tx.GasUsed != nil {
+				gasUsed = strconv.FormatInt(*tx.GasUsed, 10)
+			}
+
+			record := []string{strconv.FormatInt(tx.Checkpoint, 10), strconv.FormatInt(tx.TimestampMs, 10)}
+			if opts.HumanTime {
+				record = append(record, FormatMillis(tx.TimestampMs))
+			}
+			record = append(record, strconv.Itoa(tx.Index), tx.Digest, tx.Sender, tx.Status, gasUsed)
+
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write record to CSV: %w", err)
+			}
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("failed to flush CSV file: %w", err)
+		}
+
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to close CSV file: %w", err)
+		}
+
+		return saveMetadataSidecar(filename, opts)
+	})
+}
+
+// Save transaction rows to a JSON array, returning the files written
+func SaveTransactionsToJSON(txs []CheckpointTransaction, filename string, opts WriteOptions) ([]string, error) {
+	return saveShards(txs, filename, opts, func(txs []CheckpointTransaction, filename string) error {
+		file, err := createOutputFile(filename, opts)
+		if err != nil {
+			return fmt.Errorf("failed to create JSON file: %w", err)
+		}
+		defer file.discard()
+
+		if err := writeJSONArray(file, txs, opts); err != nil {
+			return fmt.Errorf("failed to write JSON data: %w", err)
+		}
+
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to close JSON file: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// A row of a transaction Parquet export
+type transactionParquetRow struct {
+	Checkpoint  int64   `parquet:"checkpoint"`
+	TimestampMs int64   `parquet:"timestampMs,timestamp(millisecond)"`
+	Index       int64   `parquet:"index"`
+	Digest      string  `parquet:"digest"`
+	Sender      *string `parquet:"sender,optional"`
+	Status      *string `parquet:"status,optional"`
+	GasUsed     *int64  `parquet:"gasUsed,optional"`
+}
+
+// Save transaction rows to Parquet, returning the files written
+func SaveTransactionsToParquet(txs []CheckpointTransaction, filename string, opts WriteOptions) ([]string, error) {
+	return saveShards(txs, filename, opts, func(txs []CheckpointTransaction, filename string) error {
+		rows := make([]transactionParquetRow, len(txs))
+		for i, tx := range txs {
+			rows[i] = transactionParquetRow{
+				Checkpoint:  tx.Checkpoint,
+				TimestampMs: tx.TimestampMs,
+				Index:       int64(tx.Index),
+				Digest:      tx.Digest,
+				Sender:      nullIfEmpty(tx.Sender),
+				Status:      nullIfEmpty(tx.Status),
+				GasUsed:     tx.GasUsed,
+			}
+		}
+		return saveParquetFile(filename, rows, opts)
+	})
+}
+
+// A value for a nullable Parquet string column, nil when s is empty
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,174 @@
+package suitrace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandTransactions(t *testing.T) {
+	checkpoints := []CheckpointData{
+		{SequenceNumber: 7, TimestampMs: 1000, TransactionDigests: []string{"a", "b"}},
+		{SequenceNumber: 8, TimestampMs: 2000},
+		{SequenceNumber: 9, TimestampMs: 3000, TransactionDigests: []string{"c"}},
+	}
+
+	txs := ExpandTransactions(checkpoints)
+	want := []CheckpointTransaction{
+		{Checkpoint: 7, TimestampMs: 1000, Index: 0, Digest: "a"},
+		{Checkpoint: 7, TimestampMs: 1000, Index: 1, Digest: "b"},
+		{Checkpoint: 9, TimestampMs: 3000, Index: 0, Digest: "c"},
+	}
+	if len(txs) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(txs), len(want))
+	}
+	for i := range want {
+		if txs[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, txs[i], want[i])
+		}
+	}
+}
+
+func TestExpandTransactionsWithOptions(t *testing.T) {
+	checkpoints := []CheckpointData{
+		{SequenceNumber: 7, TransactionDigests: []string{"a", "b", "c", "d"}},
+		{SequenceNumber: 8, TransactionDigests: []string{"e", "f"}},
+		{SequenceNumber: 9, TransactionDigests: []string{"g", "h", "i"}},
+	}
+
+	digests := func(txs []CheckpointTransaction) string {
+		s := ""
+		for _, tx := range txs {
+			s += tx.Digest
+		}
+		return s
+	}
+
+	tests := []struct {
+		name        string
+		opts        ExpandOptions
+		wantDigests string
+		wantLimited []int64
+		wantSkipped int64
+	}{
+		{"no limit", ExpandOptions{}, "abcdefghi", nil, 0},
+		{"cap", ExpandOptions{MaxPerCheckpoint: 2}, "abefgh", []int64{7, 9}, 3},
+		{"skip", ExpandOptions{MaxPerCheckpoint: 2, SkipLarge: true}, "ef", []int64{7, 9}, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txs, summary := ExpandTransactionsWithOptions(checkpoints, tt.opts)
+			if got := digests(txs); got != tt.wantDigests {
+				t.Errorf("digests = %q, want %q", got, tt.wantDigests)
+			}
+			if fmt.Sprint(summary.LimitedCheckpoints) != fmt.Sprint(tt.wantLimited) || summary.TransactionsSkipped != tt.wantSkipped {
+				t.Errorf("summary = %+v, want limited %v, skipped %d", summary, tt.wantLimited, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestFetchTransactionDetails(t *testing.T) {
+	var txs []CheckpointTransaction
+	for i := 0; i < MaxMultiGetTransactions+1; i++ {
+		txs = append(txs, CheckpointTransaction{Checkpoint: 1, Index: i, Digest: fmt.Sprintf("tx%d", i)})
+	}
+
+	calls := 0
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_multiGetTransactionBlocks": func(params []interface{}) mockResponse {
+			calls++
+			digests, _ := params[0].([]interface{})
+			var blocks []interface{}
+			for _, d := range digests {
+				if d == "tx3" {
+					continue // Not returned by the node
+				}
+				blocks = append(blocks, map[string]interface{}{
+					"digest":      d,
+					"transaction": map[string]interface{}{"data": map[string]interface{}{"sender": "0xabc"}},
+					"effects": map[string]interface{}{
+						"status":  map[string]interface{}{"status": "success"},
+						"gasUsed": map[string]interface{}{"computationCost": "1000", "storageCost": "500", "storageRebate": "1200"},
+					},
+				})
+			}
+			return mockResponse{Result: blocks}
+		},
+	})
+
+	if err := client.FetchTransactionDetails(txs); err != nil {
+		t.Fatalf("FetchTransactionDetails: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("got %d calls, want 2", calls)
+	}
+
+	tx := txs[MaxMultiGetTransactions]
+	if tx.Sender != "0xabc" || tx.Status != "success" || tx.GasUsed == nil || *tx.GasUsed != 300 {
+		t.Errorf("unexpected details: %+v", tx)
+	}
+	if txs[3].Status != "" || txs[3].GasUsed != nil {
+		t.Errorf("missing transaction got details: %+v", txs[3])
+	}
+
+	filename := filepath.Join(t.TempDir(), "transactions.csv")
+	if _, err := SaveTransactionsToCSV(txs[2:4], filename, WriteOptions{HumanTime: true}); err != nil {
+		t.Fatalf("SaveTransactionsToCSV: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Checkpoint,TimestampMs,Timestamp,Index,Digest,Sender,Status,GasUsed\n" +
+		"1,0,,2,tx2,0xabc,success,300\n" +
+		"1,0,,3,tx3,,,\n"
+	if string(data) != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", data, want)
+	}
+}
+
+// Respond to sui_multiGetTransactionBlocks with blocks of the given kinds
+func kindsHandler(kinds map[string]string) mockHandler {
+	return func(params []interface{}) mockResponse {
+		digests, _ := params[0].([]interface{})
+		var blocks []interface{}
+		for _, d := range digests {
+			digest, _ := d.(string)
+			blocks = append(blocks, map[string]interface{}{
+				"digest": digest,
+				"transaction": map[string]interface{}{"data": map[string]interface{}{
+					"sender":      "0x0",
+					"transaction": map[string]interface{}{"kind": kinds[digest]},
+				}},
+			})
+		}
+		return mockResponse{Result: blocks}
+	}
+}
+
+func TestExcludeSystemTransactions(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_multiGetTransactionBlocks": kindsHandler(map[string]string{
+			"prologue": "ConsensusCommitPrologueV3",
+			"user":     "ProgrammableTransaction",
+			"epoch":    "ChangeEpoch",
+		}),
+	})
+
+	txs := ExpandTransactions([]CheckpointData{{SequenceNumber: 1, TransactionDigests: []string{"prologue", "user", "epoch", "unknown"}}})
+	if err := client.FetchTransactionDetails(txs); err != nil {
+		t.Fatalf("FetchTransactionDetails: %v", err)
+	}
+
+	kept, excluded := ExcludeSystemTransactions(txs)
+	if excluded != 2 {
+		t.Errorf("excluded %d transactions, want 2", excluded)
+	}
+	// A transaction of unknown kind is kept
+	if len(kept) != 2 || kept[0].Digest != "user" || kept[0].Index != 1 || kept[1].Digest != "unknown" {
+		t.Errorf("unexpected remaining transactions: %+v", kept)
+	}
+}
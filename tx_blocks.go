@@ -0,0 +1,318 @@
+package suitrace
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parts of a transaction block to fetch, mirroring the node's show* options
+type TransactionBlockOptions struct {
+	ShowInput          bool
+	ShowRawInput       bool
+	ShowEffects        bool
+	ShowEvents         bool
+	ShowObjectChanges  bool
+	ShowBalanceChanges bool
+}
+
+// Names accepted by ParseTransactionBlockOptions
+var transactionBlockOptionNames = map[string]func(*TransactionBlockOptions){
+	"input":          func(o *TransactionBlockOptions) { o.ShowInput = true },
+	"rawInput":       func(o *TransactionBlockOptions) { o.ShowRawInput = true },
+	"effects":        func(o *TransactionBlockOptions) { o.ShowEffects = true },
+	"events":         func(o *TransactionBlockOptions) { o.ShowEvents = true },
+	"objectChanges":  func(o *TransactionBlockOptions) { o.ShowObjectChanges = true },
+	"balanceChanges": func(o *TransactionBlockOptions) { o.ShowBalanceChanges = true },
+}
+
+// Parse a comma-separated list of parts such as "input,effects,events"
+func ParseTransactionBlockOptions(list string) (TransactionBlockOptions, error) {
+	opts := TransactionBlockOptions{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		set, ok := transactionBlockOptionNames[name]
+		if !ok {
+			names := make([]string, 0, len(transactionBlockOptionNames))
+			for n := range transactionBlockOptionNames {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return opts, fmt.Errorf("unknown transaction block option %q (use %s)", name, strings.Join(names, ", "))
+		}
+		set(&opts)
+	}
+	return opts, nil
+}
+
+func (o TransactionBlockOptions) params() map[string]interface{} {
+	return map[string]interface{}{
+		"showInput":          o.ShowInput,
+		"showRawInput":       o.ShowRawInput,
+		"showEffects":        o.ShowEffects,
+		"showEvents":         o.ShowEvents,
+		"showObjectChanges":  o.ShowObjectChanges,
+		"showBalanceChanges": o.ShowBalanceChanges,
+	}
+}
+
+// Fetch transaction blocks by digest, as returned by the node, in the order
+// given. A single digest uses sui_getTransactionBlock; more are fetched with
+// sui_multiGetTransactionBlocks in batches of MaxMultiGetTransactions.
+// Digests the node does not return are reported in the error after the
+// blocks that were found.
+func (c *Client) GetTransactionBlocks(digests []string, opts TransactionBlockOptions) ([]map[string]interface{}, error) {
+	if len(digests) == 1 {
+		result, err := c.MakeRPCCall("sui_getTransactionBlock", []interface{}{digests[0], opts.params()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transaction %s: %w", digests[0], err)
+		}
+		block, ok := result["result"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("transaction %s not found", digests[0])
+		}
+		c.cacheTimestamp(block)
+		return []map[string]interface{}{block}, nil
+	}
+
+	blocks := []map[string]interface{}{}
+	missing := []string{}
+	for _, batch := range shardItems(digests, MaxMultiGetTransactions) {
+		if len(batch) == 0 {
+			continue
+		}
+
+		result, err := c.MakeRPCCall("sui_multiGetTransactionBlocks", []interface{}{batch, opts.params()})
+		if err != nil {
+			return blocks, fmt.Errorf("failed to get transactions: %w", err)
+		}
+
+		found := map[string]map[string]interface{}{}
+		entries, _ := result["result"].([]interface{})
+		for _, entry := range entries {
+			if block, ok := entry.(map[string]interface{}); ok {
+				if digest, ok := block["digest"].(string); ok {
+					found[digest] = block
+					c.cacheTimestamp(block)
+				}
+			}
+		}
+
+		for _, digest := range batch {
+			if block, ok := found[digest]; ok {
+				blocks = append(blocks, block)
+			} else {
+				missing = append(missing, digest)
+			}
+		}
+
+		c.DebugPrint("Fetched %d of %d transactions", len(blocks)+len(missing), len(digests))
+	}
+
+	if len(missing) > 0 {
+		return blocks, fmt.Errorf("%d transactions not found: %s", len(missing), strings.Join(missing, ", "))
+	}
+	return blocks, nil
+}
+
+// Save transaction blocks to a JSON array as returned by the node
+func SaveTransactionBlocksToJSON(blocks []map[string]interface{}, filename string, opts WriteOptions) ([]string, error) {
+	return saveShards(blocks, filename, opts, func(blocks []map[string]interface{}, filename string) error {
+		file, err := createOutputFile(filename, opts)
+		if err != nil {
+			return fmt.Errorf("failed to create JSON file: %w", err)
+		}
+		defer file.discard()
+
+		if err := writeJSONArray(file, blocks, opts); err != nil {
+			return fmt.Errorf("failed to write JSON data: %w", err)
+		}
+
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to close JSON file: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Header of transaction block CSV exports
+var transactionBlockCSVHeader = []string{"Digest", "Checkpoint", "TimestampMs", "Sender", "Status", "GasUsed"}
+
+// Save one summary row per transaction block to CSV, returning the files
+// written. Sender needs the input and Status and GasUsed the effects; they
+// are left empty when those were not fetched. HumanTime adds a Timestamp
+// column after TimestampMs; Fields does not apply.
+func SaveTransactionBlocksToCSV(blocks []map[string]interface{}, filename string, opts WriteOptions) ([]string, error) {
+	return saveShards(blocks, filename, opts, func(blocks []map[string]interface{}, filename string) error {
+		file, err := createOutputFile(filename, opts)
+		if err != nil {
+			return fmt.Errorf("failed to create CSV file: %w", err)
+		}
+		defer file.discard()
+
+		writer := csv.NewWriter(file)
+
+		header := transactionBlockCSVHeader
+		if opts.HumanTime {
+			header = append([]string{"Digest", "Checkpoint", "TimestampMs", "Timestamp"}, transactionBlockCSVHeader[3:]...)
+		}
+		if err := writer.Write(header); err != nil {
+			return fmt.Errorf("failed to write CSV header: %w", err)
+		}
+
+		for _, block := range blocks {
+			tx := CheckpointTransaction{}
+			tx.Digest, _ = block["digest"].(string)
+			parseTransactionDetails(block, &tx)
+
+			checkpoint, timestamp := "", ""
+			if seq, err := parseU64(block["checkpoint"]); err == nil {
+				checkpoint = strconv.FormatUint(seq, 10)
+			}
+			if ms, err := parseU64(block["timestampMs"]); err == nil {
+				tx.TimestampMs = int64(ms)
+				timestamp = strconv.FormatUint(ms, 10)
+			}
+			gasUsed := ""
+			if tx.GasUsed != nil {
+				gasUsed = strconv.FormatInt(*tx.GasUsed, 10)
+			}
+
+			record := []string{tx.Digest, checkpoint, timestamp}
+			if opts.HumanTime {
+				record = append(record, FormatMillis(tx.TimestampMs))
+			}
+			record = append(record, tx.Sender, tx.Status, gasUsed)
+
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write record to CSV: %w", err)
+			}
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("failed to flush CSV file: %w", err)
+		}
+
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to close CSV file: %w", err)
+		}
+
+		return saveMetadataSidecar(filename, opts)
+	})
+}
+
+// Filters accepted by suix_queryTransactionBlocks
+var transactionFilterKinds = []string{
+	"Checkpoint", "MoveFunction", "InputObject", "ChangedObject", "FromAddress",
+	"ToAddress", "FromAndToAddress", "FromOrToAddress", "TransactionKind", "TransactionKindIn",
+}
+
+// Options for QueryTransactionBlocks
+type TransactionQueryOptions struct {
+	Show       TransactionBlockOptions // Parts of each transaction block to return
+	Descending bool                    // Newest first instead of oldest first
+	Limit      int                     // Stop after this many transactions, 0 for all
+	PageSize   int                     // Transactions per page; 0 uses the client's TransactionPageSize
+}
+
+// Check that filter is a single suix_queryTransactionBlocks filter such as
+// {"FromAddress": "0x..."}. A nil filter matches every transaction.
+func ValidateTransactionFilter(filter map[string]interface{}) error {
+	if filter == nil {
+		return nil
+	}
+	if len(filter) != 1 {
+		return fmt.Errorf("transaction filter must have exactly one key, got %d", len(filter))
+	}
+	for kind := range filter {
+		for _, known := range transactionFilterKinds {
+			if kind == known {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown transaction filter %q (use %s)", kind, strings.Join(transactionFilterKinds, ", "))
+	}
+	return nil
+}
+
+// Fetch every transaction block matching filter, following the pagination
+// cursor until the last page or opts.Limit
+func (c *Client) QueryTransactionBlocks(filter map[string]interface{}, opts TransactionQueryOptions) ([]map[string]interface{}, error) {
+	blocks := []map[string]interface{}{}
+	err := c.PageTransactionBlocks(filter, opts, func(block map[string]interface{}) {
+		blocks = append(blocks, block)
+	})
+	return blocks, err
+}
+
+// Page through the transaction blocks matching filter like
+// QueryTransactionBlocks, passing each one to handle instead of keeping them
+func (c *Client) PageTransactionBlocks(filter map[string]interface{}, opts TransactionQueryOptions, handle func(block map[string]interface{})) error {
+	if err := ValidateTransactionFilter(filter); err != nil {
+		return err
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = c.TransactionPageSize
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultTransactionPageSize
+	}
+
+	query := map[string]interface{}{
+		"options": opts.Show.params(),
+	}
+	if filter != nil {
+		query["filter"] = filter
+	}
+
+	var cursor interface{}
+	pages, total := 0, 0
+
+	for {
+		result, err := c.MakeRPCCall("suix_queryTransactionBlocks", []interface{}{query, cursor, pageSize, opts.Descending})
+		if err != nil {
+			return fmt.Errorf("failed to query transactions: %w", err)
+		}
+		pages++
+
+		resultObj, _ := result["result"].(map[string]interface{})
+		data, _ := resultObj["data"].([]interface{})
+		for _, entry := range data {
+			block, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			c.cacheTimestamp(block)
+			handle(block)
+			total++
+			if opts.Limit > 0 && total >= opts.Limit {
+				c.DebugPrint("Reached the limit of %d transactions after %d pages", opts.Limit, pages)
+				return nil
+			}
+		}
+
+		hasNext, _ := resultObj["hasNextPage"].(bool)
+		if !hasNext || resultObj["nextCursor"] == nil || len(data) == 0 {
+			break
+		}
+
+		// A cursor that doesn't move would refetch the same page forever
+		if cursor != nil && resultObj["nextCursor"] == cursor {
+			c.DebugPrint("Pagination cursor did not advance - stopping")
+			break
+		}
+		cursor = resultObj["nextCursor"]
+	}
+
+	c.DebugPrint("Queried %d transactions matching %v in %d pages", total, filter, pages)
+	return nil
+}
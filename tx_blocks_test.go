@@ -0,0 +1,168 @@
+package suitrace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTransactionBlockOptions(t *testing.T) {
+	opts, err := ParseTransactionBlockOptions("input, effects,objectChanges")
+	if err != nil {
+		t.Fatalf("ParseTransactionBlockOptions: %v", err)
+	}
+	want := TransactionBlockOptions{ShowInput: true, ShowEffects: true, ShowObjectChanges: true}
+	if opts != want {
+		t.Errorf("got %+v, want %+v", opts, want)
+	}
+
+	if _, err := ParseTransactionBlockOptions("input,bogus"); err == nil || !strings.Contains(err.Error(), "bogus") {
+		t.Errorf("expected an error naming the unknown option, got %v", err)
+	}
+}
+
+func TestGetTransactionBlockSingle(t *testing.T) {
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getTransactionBlock": func(params []interface{}) mockResponse {
+			options, _ := params[1].(map[string]interface{})
+			if options["showEvents"] != true || options["showInput"] != false {
+				t.Errorf("unexpected options: %v", options)
+			}
+			return fixture(t, "transaction_block.json")
+		},
+	})
+
+	blocks, err := client.GetTransactionBlocks([]string{"Cq9sP2vX4mT7yB1nR5kW8zA3dF6hJ9uL2eG4oQ7iN1cV"}, TransactionBlockOptions{ShowEvents: true})
+	if err != nil {
+		t.Fatalf("GetTransactionBlocks: %v", err)
+	}
+	if len(blocks) != 1 || blocks[0]["digest"] != "Cq9sP2vX4mT7yB1nR5kW8zA3dF6hJ9uL2eG4oQ7iN1cV" {
+		t.Errorf("unexpected blocks: %v", blocks)
+	}
+}
+
+func TestGetTransactionBlocksBatches(t *testing.T) {
+	var digests []string
+	for i := 0; i < MaxMultiGetTransactions+2; i++ {
+		digests = append(digests, fmt.Sprintf("tx%d", i))
+	}
+
+	calls := 0
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_multiGetTransactionBlocks": func(params []interface{}) mockResponse {
+			calls++
+			batch, _ := params[0].([]interface{})
+			var blocks []interface{}
+			// Returned in reverse to check the input order is restored
+			for i := len(batch) - 1; i >= 0; i-- {
+				if batch[i] == "tx1" {
+					continue // Not returned by the node
+				}
+				blocks = append(blocks, map[string]interface{}{
+					"digest":      batch[i],
+					"checkpoint":  "12",
+					"timestampMs": "1700000000123",
+					"transaction": map[string]interface{}{"data": map[string]interface{}{"sender": "0xabc"}},
+					"effects": map[string]interface{}{
+						"status":  map[string]interface{}{"status": "success"},
+						"gasUsed": map[string]interface{}{"computationCost": "1000", "storageCost": "500", "storageRebate": "1200"},
+					},
+				})
+			}
+			return mockResponse{Result: blocks}
+		},
+	})
+
+	blocks, err := client.GetTransactionBlocks(digests, TransactionBlockOptions{ShowInput: true, ShowEffects: true})
+	if err == nil || !strings.Contains(err.Error(), "tx1") {
+		t.Errorf("expected an error naming tx1, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("got %d calls, want 2", calls)
+	}
+	if len(blocks) != len(digests)-1 || blocks[0]["digest"] != "tx0" || blocks[1]["digest"] != "tx2" {
+		t.Fatalf("unexpected blocks: %d, first %v", len(blocks), blocks[0]["digest"])
+	}
+
+	filename := filepath.Join(t.TempDir(), "txs.csv")
+	if _, err := SaveTransactionBlocksToCSV(blocks[:1], filename, WriteOptions{}); err != nil {
+		t.Fatalf("SaveTransactionBlocksToCSV: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Digest,Checkpoint,TimestampMs,Sender,Status,GasUsed\n" +
+		"tx0,12,1700000000123,0xabc,success,300\n"
+	if string(data) != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestQueryTransactionBlocks(t *testing.T) {
+	pages := map[interface{}]mockResponse{
+		nil: {Result: map[string]interface{}{
+			"data":        []interface{}{map[string]interface{}{"digest": "a"}, map[string]interface{}{"digest": "b"}},
+			"nextCursor":  "b",
+			"hasNextPage": true,
+		}},
+		"b": {Result: map[string]interface{}{
+			"data":        []interface{}{map[string]interface{}{"digest": "c"}, map[string]interface{}{"digest": "d"}},
+			"nextCursor":  "d",
+			"hasNextPage": true,
+		}},
+	}
+
+	client := newTestClient(t, map[string]mockHandler{
+		"suix_queryTransactionBlocks": func(params []interface{}) mockResponse {
+			query, _ := params[0].(map[string]interface{})
+			filter, _ := query["filter"].(map[string]interface{})
+			options, _ := query["options"].(map[string]interface{})
+			if filter["FromAddress"] != "0xabc" || options["showEffects"] != true || params[2] != float64(2) || params[3] != false {
+				t.Errorf("unexpected params: %v", params)
+			}
+			resp, ok := pages[params[1]]
+			if !ok {
+				t.Errorf("unexpected cursor: %v", params[1])
+			}
+			return resp
+		},
+	})
+
+	blocks, err := client.QueryTransactionBlocks(map[string]interface{}{"FromAddress": "0xabc"}, TransactionQueryOptions{
+		Show:     TransactionBlockOptions{ShowEffects: true},
+		Limit:    3,
+		PageSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("QueryTransactionBlocks: %v", err)
+	}
+	if len(blocks) != 3 || blocks[2]["digest"] != "c" {
+		t.Errorf("got %v, want the first 3 transactions", blocks)
+	}
+}
+
+func TestValidateTransactionFilter(t *testing.T) {
+	tests := []struct {
+		filter  map[string]interface{}
+		wantErr string
+	}{
+		{nil, ""},
+		{map[string]interface{}{"ToAddress": "0x1"}, ""},
+		{map[string]interface{}{"ToAddress": "0x1", "FromAddress": "0x2"}, "exactly one key"},
+		{map[string]interface{}{"Sender": "0x1"}, "unknown transaction filter"},
+	}
+
+	for _, tt := range tests {
+		err := ValidateTransactionFilter(tt.filter)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%v: unexpected error %v", tt.filter, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%v: err = %v, want %q", tt.filter, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package suitrace
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// Signature schemes reported by ParseValidatorSignature
+const (
+	// Aggregated BLS12-381 signature of the committee in the min-sig
+	// variant Sui uses: a compressed G1 point
+	SignatureSchemeBLS12381 = "BLS12-381"
+)
+
+// Length of a compressed BLS12-381 G1 point
+const bls12381SignatureLength = 48
+
+// A checkpoint's validatorSignature, decoded
+type ValidatorSignature struct {
+	Scheme string
+	Bytes  []byte
+}
+
+// Decode a checkpoint's base64 validatorSignature and check that it is
+// structurally a BLS12-381 signature: 48 bytes, flagged as a compressed
+// point, and not the point at infinity. The signature is not verified
+// against the committee's public keys.
+func ParseValidatorSignature(encoded string) (*ValidatorSignature, error) {
+	if encoded == "" {
+		return nil, fmt.Errorf("validator signature is missing")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("validator signature is not valid base64: %w", err)
+	}
+	if len(raw) != bls12381SignatureLength {
+		return nil, fmt.Errorf("validator signature is %d bytes, want %d for %s", len(raw), bls12381SignatureLength, SignatureSchemeBLS12381)
+	}
+
+	// The top bits of the first byte flag compression, infinity and the sign of y
+	if raw[0]&0x80 == 0 {
+		return nil, fmt.Errorf("validator signature is not a compressed %s point", SignatureSchemeBLS12381)
+	}
+	if raw[0]&0x40 != 0 {
+		return nil, fmt.Errorf("validator signature is the %s point at infinity", SignatureSchemeBLS12381)
+	}
+
+	return &ValidatorSignature{Scheme: SignatureSchemeBLS12381, Bytes: raw}, nil
+}
+
+// Check the validatorSignature of each checkpoint with
+// ParseValidatorSignature, returning one error per malformed checkpoint
+func CheckValidatorSignatures(checkpoints []CheckpointData) []error {
+	var errs []error
+	for _, checkpoint := range checkpoints {
+		if _, err := ParseValidatorSignature(checkpoint.ValidatorSignature); err != nil {
+			errs = append(errs, fmt.Errorf("checkpoint %d: %w", checkpoint.SequenceNumber, err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,50 @@
+package suitrace
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestParseValidatorSignature(t *testing.T) {
+	// From testdata/checkpoint.json
+	const recorded = "qkGx3H6Bc7qJbz7VSuEXG1y3lf1p0fZ5C1kGQcd8vJyzv0K3Qy2tX3A7ZQ8v5m1T"
+
+	sig, err := ParseValidatorSignature(recorded)
+	if err != nil {
+		t.Fatalf("ParseValidatorSignature: %v", err)
+	}
+	if sig.Scheme != SignatureSchemeBLS12381 || len(sig.Bytes) != 48 {
+		t.Errorf("got %s signature of %d bytes", sig.Scheme, len(sig.Bytes))
+	}
+
+	encode := func(first byte, length int) string {
+		raw := bytes.Repeat([]byte{0x01}, length)
+		raw[0] = first
+		return base64.StdEncoding.EncodeToString(raw)
+	}
+	tests := []struct {
+		encoded string
+		wantErr string
+	}{
+		{"", "missing"},
+		{"not base64!", "not valid base64"},
+		{encode(0x80, 96), "96 bytes, want 48"},
+		{encode(0x20, 48), "not a compressed"},
+		{encode(0xc0, 48), "point at infinity"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseValidatorSignature(tt.encoded); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseValidatorSignature(%q) = %v, want an error containing %q", tt.encoded, err, tt.wantErr)
+		}
+	}
+
+	errs := CheckValidatorSignatures([]CheckpointData{
+		{SequenceNumber: 1, ValidatorSignature: recorded},
+		{SequenceNumber: 2, ValidatorSignature: encode(0x80, 47)},
+	})
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "checkpoint 2: ") {
+		t.Errorf("CheckValidatorSignatures = %v, want one error for checkpoint 2", errs)
+	}
+}
@@ -0,0 +1,55 @@
+package suitrace
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, set at link time:
+//
+//	go build -ldflags "-X github.com/VeerChaurasia/SuiTrace.Version=v1.4.0 -X github.com/VeerChaurasia/SuiTrace.Commit=$(git rev-parse --short HEAD)" ./cmd/suitrace
+//
+// Builds without ldflags fall back to the module version and VCS revision
+// the Go toolchain recorded, when there are any.
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// The version and commit of this build
+func BuildVersion() (version, commit string) {
+	version, commit = Version, Commit
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return version, commit
+	}
+	if version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	if commit == "" {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				commit = setting.Value
+			}
+		}
+	}
+	return version, commit
+}
+
+// One-line description of this build, as printed by -version
+func VersionString() string {
+	version, commit := BuildVersion()
+	if commit == "" {
+		commit = "unknown"
+	}
+	return fmt.Sprintf("suitrace %s (commit %s, %s)", version, commit, runtime.Version())
+}
+
+// Name and version of the tool, as sent in User-Agent and written to the
+// generatedBy field of JSON outputs
+func GeneratedBy() string {
+	version, _ := BuildVersion()
+	return "suitrace/" + version
+}
@@ -0,0 +1,61 @@
+package suitrace
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Emit the object's state each time its version advances past
+// afterVersion, polling its current state every PollInterval. A failed poll
+// is reported and retried on the next one. The returned channel is closed
+// when ctx is done.
+func (c *Client) WatchObject(ctx context.Context, objectID string, afterVersion uint64) (<-chan ObjectState, error) {
+	return WatchObject(ctx, c, objectID, afterVersion, c.PollInterval)
+}
+
+// Watch an object on any backend, polling every interval
+// (DefaultPollInterval when zero)
+func WatchObject(ctx context.Context, b Backend, objectID string, afterVersion uint64, interval time.Duration) (<-chan ObjectState, error) {
+	objectID, err := NormalizeSuiAddress(objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	states := make(chan ObjectState)
+
+	go func() {
+		defer close(states)
+
+		last := afterVersion
+		for {
+			state, err := b.GetObject(ctx, objectID)
+			switch {
+			case err != nil:
+				if ctx.Err() != nil {
+					return
+				}
+				fmt.Printf("Error fetching object %s: %v\n", objectID, err)
+			case state.Version > last:
+				select {
+				case states <- *state:
+					last = state.Version
+				case <-ctx.Done():
+					return
+				}
+			default:
+				debugPrint(b, "Object %s still at version %d", objectID, last)
+			}
+
+			if sleepContext(ctx, interval) != nil {
+				return
+			}
+		}
+	}()
+
+	return states, nil
+}
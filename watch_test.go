@@ -0,0 +1,71 @@
+package suitrace
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchObjectEmitsNewVersions(t *testing.T) {
+	// The object advances one version every other poll, and one poll fails
+	var polls int32
+	client := newTestClient(t, map[string]mockHandler{
+		"sui_getObject": func(params []interface{}) mockResponse {
+			n := atomic.AddInt32(&polls, 1)
+			if n == 2 {
+				return mockResponse{Error: map[string]interface{}{"code": -32000, "message": "transient"}}
+			}
+			version := 5 + int(n)/2
+			return mockResponse{Result: map[string]interface{}{"data": map[string]interface{}{
+				"objectId": testObjectID,
+				"version":  strconv.Itoa(version),
+				"content":  map[string]interface{}{"fields": map[string]interface{}{"value": strconv.Itoa(version)}},
+			}}}
+		},
+	})
+	client.PollInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	states, err := client.WatchObject(ctx, testObjectID, 5)
+	if err != nil {
+		t.Fatalf("WatchObject: %v", err)
+	}
+
+	for want := uint64(6); want <= 8; want++ {
+		select {
+		case state := <-states:
+			if state.Version != want {
+				t.Fatalf("got version %d, want %d", state.Version, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for version %d", want)
+		}
+	}
+
+	cancel()
+	for range states {
+		// Drain until closed
+	}
+}
+
+func TestWatchObjectRejectsInvalidID(t *testing.T) {
+	client := NewClient("http://unused")
+	if _, err := client.WatchObject(context.Background(), "not-an-id", 0); err == nil {
+		t.Fatal("expected error for invalid object ID")
+	}
+}
+
+func TestObjectHistoryAppend(t *testing.T) {
+	history := &ObjectHistory{ID: testObjectID}
+	history.Append(ObjectState{Version: 1, Timestamp: 1000, Owner: map[string]interface{}{"AddressOwner": "0x1"}})
+	history.Append(ObjectState{Version: 2, Timestamp: 3000, Owner: map[string]interface{}{"AddressOwner": "0x2"}})
+
+	if history.NumChanges != 1 || history.NumOwners != 2 || history.FirstSeen != 1000 || history.LastSeen != 3000 {
+		t.Errorf("stats = changes %d, owners %d, first %d, last %d",
+			history.NumChanges, history.NumOwners, history.FirstSeen, history.LastSeen)
+	}
+}
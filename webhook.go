@@ -0,0 +1,103 @@
+package suitrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Default number of times a failed webhook delivery is retried
+const DefaultWebhookRetries = 2
+
+// Payload POSTed to a webhook when a watched object moves to a new version
+type ObjectChangeNotification struct {
+	ObjectID     string                 `json:"objectId"`
+	OldVersion   uint64                 `json:"oldVersion,string"`
+	NewVersion   uint64                 `json:"newVersion,string"`
+	Transaction  string                 `json:"transaction"`
+	Timestamp    int64                  `json:"timestamp"` // Millis of the transaction that wrote the new version, 0 when unknown
+	OwnerChanged bool                   `json:"ownerChanged"`
+	OldOwner     map[string]interface{} `json:"oldOwner"`
+	NewOwner     map[string]interface{} `json:"newOwner"`
+	Changes      []ContentChange        `json:"changes"`
+}
+
+// Describe the change from one state of an object to the next
+func NewObjectChangeNotification(before, after ObjectState) ObjectChangeNotification {
+	objectID := after.ObjectID
+	if objectID == "" {
+		objectID = before.ObjectID
+	}
+	return ObjectChangeNotification{
+		ObjectID:     objectID,
+		OldVersion:   before.Version,
+		NewVersion:   after.Version,
+		Transaction:  after.PreviousTx,
+		Timestamp:    after.Timestamp,
+		OwnerChanged: GetOwnerKey(before.Owner) != GetOwnerKey(after.Owner),
+		OldOwner:     before.Owner,
+		NewOwner:     after.Owner,
+		Changes:      DiffContent(before.Content, after.Content),
+	}
+}
+
+// An HTTP endpoint that is sent JSON payloads
+type Webhook struct {
+	URL        string
+	HTTPClient *http.Client
+	Retries    int // Further attempts after a failed delivery
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		URL:        url,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+		Retries:    DefaultWebhookRetries,
+	}
+}
+
+// POST payload as JSON, retrying network errors, 429s and 5xx responses.
+// Any other non-2xx response fails at once.
+func (w *Webhook) Send(ctx context.Context, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !IsTransient(err) || attempt >= w.Retries {
+			return fmt.Errorf("webhook delivery failed after %d attempts: %w", attempt+1, err)
+		}
+
+		if err := sleepContext(ctx, retryDelay); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *Webhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		preview, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return &HTTPError{StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(preview))}
+	}
+	return nil
+}
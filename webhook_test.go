@@ -0,0 +1,110 @@
+package suitrace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewObjectChangeNotification(t *testing.T) {
+	before := ObjectState{
+		ObjectID: testObjectID,
+		Version:  4,
+		Owner:    map[string]interface{}{"AddressOwner": "0x1"},
+		Content:  map[string]interface{}{"fields": map[string]interface{}{"value": "1"}},
+	}
+	after := ObjectState{
+		ObjectID:   testObjectID,
+		Version:    7,
+		PreviousTx: "tx7",
+		Timestamp:  1700000000123,
+		Owner:      map[string]interface{}{"AddressOwner": "0x2"},
+		Content:    map[string]interface{}{"fields": map[string]interface{}{"value": "2"}},
+	}
+
+	n := NewObjectChangeNotification(before, after)
+	if n.ObjectID != testObjectID || n.OldVersion != 4 || n.NewVersion != 7 || n.Transaction != "tx7" || n.Timestamp != 1700000000123 {
+		t.Errorf("notification = %+v", n)
+	}
+	if !n.OwnerChanged {
+		t.Error("expected OwnerChanged")
+	}
+	if len(n.Changes) != 1 || n.Changes[0].Path != "fields.value" {
+		t.Errorf("changes = %v", n.Changes)
+	}
+
+	if n := NewObjectChangeNotification(before, before); n.OwnerChanged || len(n.Changes) != 0 {
+		t.Errorf("unchanged state: %+v", n)
+	}
+}
+
+func TestWebhookSendRetries(t *testing.T) {
+	defer func(d time.Duration) { retryDelay = d }(retryDelay)
+	retryDelay = 0
+
+	var calls int32
+	var got ObjectChangeNotification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(server.URL)
+	if err := webhook.Send(context.Background(), ObjectChangeNotification{ObjectID: testObjectID, NewVersion: 9}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+	if got.ObjectID != testObjectID || got.NewVersion != 9 {
+		t.Errorf("payload = %+v", got)
+	}
+}
+
+func TestWebhookSendGivesUp(t *testing.T) {
+	defer func(d time.Duration) { retryDelay = d }(retryDelay)
+	retryDelay = 0
+
+	tests := []struct {
+		name      string
+		status    int
+		wantCalls int32
+	}{
+		{"server error retried", http.StatusInternalServerError, 1 + DefaultWebhookRetries},
+		{"client error not retried", http.StatusNotFound, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewWebhook(server.URL).Send(context.Background(), map[string]string{})
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.status {
+				t.Fatalf("err = %v, want HTTP %d", err, tt.status)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}